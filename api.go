@@ -2,17 +2,18 @@ package tbot
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"mime/multipart"
 	"net/http"
 	"net/url"
-	"os"
+	"strings"
 )
 
 type responseParameters struct {
-	MigrateToChatID int `json:"migrate_to_chat_id"`
-	ReplyAfter      int `json:"retry_after"`
+	MigrateToChatID int64 `json:"migrate_to_chat_id"`
+	RetryAfter      int   `json:"retry_after"`
 }
 
 type apiResponse struct {
@@ -24,34 +25,28 @@ type apiResponse struct {
 }
 
 func (c *Client) doRequest(method string, request url.Values, response interface{}) error {
-	endpoint := c.getUrlFor(method)
-	var resp *http.Response
-	var err error
-	if request == nil {
-		resp, err = c.httpClient.Post(endpoint, "application/x-www-form-urlencoded", nil)
-	} else {
-		resp, err = c.httpClient.PostForm(endpoint, request)
+	var body io.Reader
+	if request != nil {
+		body = strings.NewReader(request.Encode())
 	}
+	req, err := http.NewRequest(http.MethodPost, c.getUrlFor(method), body)
 	if err != nil {
-		return fmt.Errorf("unable to send message: %v", err)
-	}
-
-	apiResp := &apiResponse{}
-	err = json.NewDecoder(resp.Body).Decode(&apiResp)
-	if err != nil {
-		return fmt.Errorf("unable to decode sendMessage response: %v", err)
+		return fmt.Errorf("unable to create request: %v", err)
 	}
-	err = resp.Body.Close()
+	req = req.WithContext(c.context())
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		c.logger.Errorf("unable to close response body: %v", err)
+		c.metrics.IncAPIError(method, 0)
+		return transportError(method, err)
 	}
-	if !apiResp.OK {
-		return fmt.Errorf(apiResp.Description)
-	}
-	return json.Unmarshal(apiResp.Result, response)
+	return c.decodeResponse(method, resp, response)
 }
 
 func (c *Client) doRequestWithFiles(method string, request url.Values, response interface{}, files ...inputFile) error {
+	if len(files) == 0 {
+		return c.doRequest(method, request, response)
+	}
 	endpoint := c.getUrlFor(method)
 	r, w := io.Pipe()
 
@@ -63,53 +58,113 @@ func (c *Client) doRequestWithFiles(method string, request url.Values, response
 
 	go func() {
 		defer close(done)
-		req, err := http.NewRequest(http.MethodPost, endpoint, r)
-		if err != nil {
-			c.logger.Error(err)
+		req, reqErr := http.NewRequest(http.MethodPost, endpoint, r)
+		if reqErr != nil {
+			err = reqErr
+			r.CloseWithError(reqErr)
 			return
 		}
+		req = req.WithContext(c.context())
 		req.Header.Set("Content-Type", mw.FormDataContentType())
 		resp, err = c.httpClient.Do(req)
+		// unblock writer if request failed before the whole body was sent
+		r.CloseWithError(errRequestDone)
 	}()
 
-	for k := range request {
-		mw.WriteField(k, request.Get(k))
-	}
-	for _, file := range files {
-		f, err := os.Open(file.name)
-		if err != nil {
-			return err
+	// fileErr is set if the local file can't be opened or read,
+	// unlike failed writes to the request it is the cause of the failed request
+	var fileErr error
+
+	upload := func() error {
+		for k := range request {
+			mw.WriteField(k, request.Get(k))
 		}
-		fileWriter, err := mw.CreateFormFile(file.field, file.name)
-		if err != nil {
-			return err
+		for _, file := range files {
+			f, err := file.open()
+			if err != nil {
+				fileErr = err
+				return err
+			}
+			fileWriter, err := mw.CreateFormFile(file.field, file.filename())
+			if err != nil {
+				f.Close()
+				return err
+			}
+			fr := &fileReader{r: f}
+			_, err = io.Copy(fileWriter, fr)
+			f.Close()
+			if fr.err != nil {
+				fileErr = fmt.Errorf("unable to upload %s: %v", file.field, fr.err)
+				return fileErr
+			}
+			if err != nil {
+				return err
+			}
 		}
-
-		io.Copy(fileWriter, f)
-		f.Close()
+		return mw.Close()
+	}
+	uploadErr := upload()
+	if uploadErr != nil {
+		w.CloseWithError(uploadErr)
+	} else {
+		w.Close()
 	}
-
-	mw.Close()
-	w.Close()
 
 	<-done // post request is done
 	if err != nil {
-		return err
+		c.metrics.IncAPIError(method, 0)
+		if fileErr != nil {
+			return fileErr
+		}
+		return transportError(method, err)
 	}
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("unexpected status code: %s", resp.Status)
+	return c.decodeResponse(method, resp, response)
+}
+
+// errRequestDone closes the request body pipe after the request is done,
+// failing upload of the rest of the body
+var errRequestDone = errors.New("request is done")
+
+// fileReader records read errors of the uploaded file
+type fileReader struct {
+	r   io.Reader
+	err error
+}
+
+func (f *fileReader) Read(p []byte) (int, error) {
+	n, err := f.r.Read(p)
+	if err != nil && err != io.EOF {
+		f.err = err
 	}
+	return n, err
+}
+
+func (c *Client) decodeResponse(method string, resp *http.Response, response interface{}) error {
 	apiResp := &apiResponse{}
-	err = json.NewDecoder(resp.Body).Decode(&apiResp)
-	if err != nil {
-		return fmt.Errorf("unable to decode sendMessage response: %v", err)
+	err := json.NewDecoder(resp.Body).Decode(apiResp)
+	closeErr := resp.Body.Close()
+	if closeErr != nil {
+		c.logger.Errorf("unable to close response body: %v", closeErr)
 	}
-	err = resp.Body.Close()
 	if err != nil {
-		c.logger.Errorf("unable to close response body: %v", err)
+		c.metrics.IncAPIError(method, resp.StatusCode)
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("unexpected status code: %s", resp.Status)
+		}
+		return fmt.Errorf("unable to decode %s response: %v", method, err)
 	}
 	if !apiResp.OK {
-		return fmt.Errorf(apiResp.Description)
+		c.metrics.IncAPIError(method, apiResp.ErrorCode)
+		apiErr := &APIError{
+			Method:      method,
+			Code:        apiResp.ErrorCode,
+			Description: apiResp.Description,
+		}
+		if apiResp.Parameters != nil {
+			apiErr.RetryAfter = apiResp.Parameters.RetryAfter
+			apiErr.MigrateToChatID = apiResp.Parameters.MigrateToChatID
+		}
+		return apiErr
 	}
 	return json.Unmarshal(apiResp.Result, response)
 }
@@ -1,41 +1,60 @@
 package tbot
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/url"
+	"os"
 	"strconv"
 	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
+// SendChatID is a target chat of the request, either ChatID or ChatName
 type SendChatID interface {
 	asChatID() string
 }
 
+// ChatID is a numeric chat identifier, e.g. ChatID(m.Chat.ID)
 type ChatID int64
 
 func (s ChatID) asChatID() string {
 	return strconv.FormatInt(int64(s), 10)
 }
 
+// ChatName is a username of a public channel or supergroup, e.g. ChatName("@mychannel").
+// The leading "@" is optional. Numeric IDs in strings, e.g. ChatName("-1001234567890"), are sent as is.
 type ChatName string
 
 func (s ChatName) asChatID() string {
-	return string(s)
+	if strings.HasPrefix(string(s), "@") {
+		return string(s)
+	}
+	if _, err := strconv.ParseInt(string(s), 10, 64); err == nil {
+		return string(s)
+	}
+	return "@" + string(s)
 }
 
 // Client is a low-level Telegram client
 type Client struct {
+	ctx           context.Context
 	token         string
 	baseURL       string
 	httpClient    *http.Client
 	nextOffset    int
 	logger        Logger
+	metrics       Metrics
 	bufferSize    int
 	timeout       int
 	updatesParams url.Values
+	fileCache     *fileCache
+
+	broadcastInterval time.Duration
 }
 
 func (s *Client) getUrlFor(call string) string {
@@ -48,22 +67,42 @@ func (s *Client) getUrlFor(call string) string {
 	return b.String()
 }
 
+// WithContext returns copy of the client making requests with given context.
+// Cancel the context to abort API calls in flight, e.g.
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+//	defer cancel()
+//	msg, err := client.WithContext(ctx).SendDocument(chatID, tbot.InputFilePath("report.pdf"))
+func (c *Client) WithContext(ctx context.Context) *Client {
+	client := *c
+	client.ctx = ctx
+	return &client
+}
+
+func (c *Client) context() context.Context {
+	if c.ctx == nil {
+		return context.Background()
+	}
+	return c.ctx
+}
+
 // NewClient creates new Telegram API client
 func NewClient(token string, httpClient *http.Client, baseURL string) *Client {
 	return &Client{
 		token:      token,
 		httpClient: httpClient,
 		baseURL:    baseURL,
+		logger:     nopLogger{},
+		metrics:    nopMetrics{},
 	}
 }
 
-type inputFile struct {
-	field string
-	name  string
-}
-
 type sendOption func(url.Values)
 
+// SendOption is an option of Bot API request, e.g. OptDisableNotification.
+// It's needed to implement TelegramClient, Opt* variables are ready to use options.
+type SendOption = sendOption
+
 // Generic message options
 var (
 	OptParseModeHTML = func(r url.Values) {
@@ -80,6 +119,34 @@ var (
 			r.Set("reply_to_message_id", strconv.Itoa(id))
 		}
 	}
+	// OptEntities sets special entities of the message text, can be used instead of parse mode.
+	// Entity offsets and lengths are in UTF-16 code units, use EntityOffset and EntityLength to compute them.
+	OptEntities = func(entities []*MessageEntity) sendOption {
+		return func(r url.Values) {
+			r.Set("entities", structString(entities))
+		}
+	}
+	OptCaptionEntities = func(entities []*MessageEntity) sendOption {
+		return func(r url.Values) {
+			r.Set("caption_entities", structString(entities))
+		}
+	}
+	// OptProtectContent protects the contents of the sent message from forwarding and saving
+	OptProtectContent = func(r url.Values) {
+		r.Set("protect_content", "true")
+	}
+	// OptMessageThreadID sets target message thread (topic) of the forum
+	OptMessageThreadID = func(id int) sendOption {
+		return func(r url.Values) {
+			r.Set("message_thread_id", strconv.Itoa(id))
+		}
+	}
+	// OptBusinessConnectionID sends, edits or deletes messages on behalf of the business account
+	OptBusinessConnectionID = func(id string) sendOption {
+		return func(r url.Values) {
+			r.Set("business_connection_id", id)
+		}
+	}
 )
 
 func structString(s interface{}) string {
@@ -111,12 +178,23 @@ type InlineKeyboardMarkup struct {
 
 // InlineKeyboardButton represents one button of an inline keyboard
 type InlineKeyboardButton struct {
-	Text                         string    `json:"text"`
-	URL                          string    `json:"url,omitempty"`
-	LoginURL                     *LoginURL `json:"login_url,omitempty"`
-	CallbackData                 string    `json:"callback_data,omitempty"`
-	SwitchInlineQuery            *string   `json:"switch_inline_query,omitempty"`
-	SwitchInlineQueryCurrentChat *string   `json:"switch_inline_query_current_chat,omitempty"`
+	Text                         string        `json:"text"`
+	URL                          string        `json:"url,omitempty"`
+	LoginURL                     *LoginURL     `json:"login_url,omitempty"`
+	CallbackData                 string        `json:"callback_data,omitempty"`
+	SwitchInlineQuery            *string       `json:"switch_inline_query,omitempty"`
+	SwitchInlineQueryCurrentChat *string       `json:"switch_inline_query_current_chat,omitempty"`
+	WebApp                       *WebAppInfo   `json:"web_app,omitempty"`
+	CallbackGame                 *CallbackGame `json:"callback_game,omitempty"`
+}
+
+// CallbackGame is set on the button launching the game of SendGame,
+// the button must be the first one in the first row
+type CallbackGame struct{}
+
+// WebAppInfo describes a Web App opened by a button
+type WebAppInfo struct {
+	URL string `json:"url"` // HTTPS URL of the Web App
 }
 
 // LoginURL is a property of InlineKeyboardButton for Seamless Login feature
@@ -141,6 +219,7 @@ type KeyboardButton struct {
 	RequestContact  bool                    `json:"request_contact"`
 	RequestLocation bool                    `json:"request_location"`
 	RequestPoll     *KeyboardButtonPollType `json:"request_poll,omitempty"`
+	WebApp          *WebAppInfo             `json:"web_app,omitempty"` // Web App can send data to the bot, see Message.WebAppData
 }
 
 // KeyboardButtonPollType represents type of a poll,
@@ -149,16 +228,84 @@ type KeyboardButtonPollType struct {
 	Type string `json:"type"`
 }
 
-func (c *Client) setWebhook(webhookURL string) error {
+func (c *Client) setWebhook(webhookURL string, allowedUpdates []string, maxConnections int, ipAddress string) error {
 	req := url.Values{}
 	req.Set("url", webhookURL)
+	if allowedUpdates != nil {
+		req.Set("allowed_updates", structString(allowedUpdates))
+	}
+	if maxConnections != 0 {
+		req.Set("max_connections", strconv.Itoa(maxConnections))
+	}
+	if ipAddress != "" {
+		req.Set("ip_address", ipAddress)
+	}
 	var set bool
 	return c.doRequest("setWebhook", req, &set)
 }
 
-func (c *Client) deleteWebhook() error {
+// DeleteWebhook removes webhook integration to switch to long polling,
+// dropPending drops all pending updates
+func (c *Client) DeleteWebhook(dropPending bool) error {
+	req := url.Values{}
+	if dropPending {
+		req.Set("drop_pending_updates", "true")
+	}
+	var ok bool
+	return c.doRequest("deleteWebhook", req, &ok)
+}
+
+// WebhookInfo contains information about the current status of a webhook
+type WebhookInfo struct {
+	URL                          string   `json:"url"`
+	HasCustomCertificate         bool     `json:"has_custom_certificate"`
+	PendingUpdateCount           int      `json:"pending_update_count"`
+	IPAddress                    string   `json:"ip_address"`
+	LastErrorDate                int64    `json:"last_error_date"`
+	LastErrorMessage             string   `json:"last_error_message"`
+	LastSynchronizationErrorDate int64    `json:"last_synchronization_error_date"`
+	MaxConnections               int      `json:"max_connections"`
+	AllowedUpdates               []string `json:"allowed_updates"`
+}
+
+// GetWebhookInfo returns current webhook status, URL is empty if the bot uses long polling
+func (c *Client) GetWebhookInfo() (*WebhookInfo, error) {
+	info := &WebhookInfo{}
+	err := c.doRequest("getWebhookInfo", url.Values{}, info)
+	return info, err
+}
+
+// LogOut logs out from the cloud Bot API server before launching the bot locally.
+// The bot can't log in back to the cloud server for 10 minutes after that.
+func (c *Client) LogOut() error {
+	var ok bool
+	return c.doRequest("logOut", url.Values{}, &ok)
+}
+
+/*
+Close closes the bot instance before moving it from one local Bot API server to another.
+Webhook should be deleted before the call. Close can't be called during the first 10 minutes
+after the bot is launched, Telegram responds with APIError with RetryAfter then:
+
+	err := client.Close()
+	var apiErr *tbot.APIError
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+		time.Sleep(time.Duration(apiErr.RetryAfter) * time.Second)
+		err = client.Close()
+	}
+*/
+func (c *Client) Close() error {
 	var ok bool
-	return c.doRequest("deleteWebhook", url.Values{}, &ok)
+	return c.doRequest("close", url.Values{}, &ok)
+}
+
+// LinkPreviewOptions describes the options used for link preview generation
+type LinkPreviewOptions struct {
+	IsDisabled       bool   `json:"is_disabled,omitempty"`
+	URL              string `json:"url,omitempty"`
+	PreferSmallMedia bool   `json:"prefer_small_media,omitempty"`
+	PreferLargeMedia bool   `json:"prefer_large_media,omitempty"`
+	ShowAboveText    bool   `json:"show_above_text,omitempty"`
 }
 
 // SendMessage options
@@ -166,6 +313,11 @@ var (
 	OptDisableWebPagePreview = func(r url.Values) {
 		r.Set("disable_web_page_preview", "true")
 	}
+	OptLinkPreviewOptions = func(options LinkPreviewOptions) sendOption {
+		return func(r url.Values) {
+			r.Set("link_preview_options", structString(options))
+		}
+	}
 	OptInlineKeyboardMarkup = func(markup *InlineKeyboardMarkup) sendOption {
 		return func(r url.Values) {
 			r.Set("reply_markup", structString(markup))
@@ -190,6 +342,13 @@ var (
 	}
 )
 
+func checkLinkPreview(req url.Values) error {
+	if req.Get("disable_web_page_preview") != "" && req.Get("link_preview_options") != "" {
+		return fmt.Errorf("OptDisableWebPagePreview and OptLinkPreviewOptions can't be used together")
+	}
+	return nil
+}
+
 func withChat(chatID SendChatID, opts ...sendOption) url.Values {
 	req := url.Values{}
 	req.Set("chat_id", chatID.asChatID())
@@ -199,11 +358,16 @@ func withChat(chatID SendChatID, opts ...sendOption) url.Values {
 	return req
 }
 
+// MaxMessageLength is the maximum length of message text in UTF-16 code units after entities parsing
+const MaxMessageLength = 4096
+
 /*
 SendMessage sends message to telegram chat. Available options:
 	- OptParseModeHTML
 	- OptParseModeMarkdown
+	- OptEntities(entities []*MessageEntity)
 	- OptDisableWebPagePreview
+	- OptLinkPreviewOptions(options LinkPreviewOptions)
 	- OptDisableNotification
 	- OptReplyToMessageID(id int)
 	- OptInlineKeyboardMarkup(markup *InlineKeyboardMarkup)
@@ -212,18 +376,32 @@ SendMessage sends message to telegram chat. Available options:
 	- OptReplyKeyboardRemoveSelective
 	- OptForceReply
 	- OptForceReplySelective
+
+Text without parse mode longer than MaxMessageLength is rejected with ErrMessageTooLong
+before the request, use SendLongMessage to split it into several messages.
 */
 func (c *Client) SendMessage(chatID SendChatID, text string, opts ...sendOption) (*Message, error) {
 	req := withChat(chatID, opts...)
+	// formatting of parse mode is not counted by Telegram, such text is checked by the server
+	if req.Get("parse_mode") == "" && EntityLength(text) > MaxMessageLength {
+		return nil, ErrMessageTooLong
+	}
 	req.Set("text", text)
+	if err := checkLinkPreview(req); err != nil {
+		return nil, err
+	}
 	msg := &Message{}
 	err := c.doRequest("sendMessage", req, msg)
 	return msg, err
 }
 
 /*
-ForwardMessage forwards message from one chat to another. Available options:
+ForwardMessage forwards message from one chat to another.
+Chats can be identified by ChatID or by ChatName of a channel, e.g. ChatName("@channelusername").
+Available options:
 	- OptDisableNotification
+	- OptProtectContent
+	- OptMessageThreadID(id int)
 */
 func (c *Client) ForwardMessage(chatID, fromChatID SendChatID, messageID int, opts ...sendOption) (*Message, error) {
 	req := withChat(chatID, opts...)
@@ -234,6 +412,104 @@ func (c *Client) ForwardMessage(chatID, fromChatID SendChatID, messageID int, op
 	return msg, err
 }
 
+/*
+CopyMessage copies message from one chat to another and returns ID of the copy.
+Unlike forwarded message, the copy has no link to the original message.
+Caption of media messages is kept unless replaced with OptCaption. Available options:
+	- OptCaption(caption string)
+	- OptCaptionEntities(entities []*MessageEntity)
+	- OptParseModeHTML
+	- OptParseModeMarkdown
+	- OptDisableNotification
+	- OptProtectContent
+	- OptMessageThreadID(id int)
+	- OptReplyToMessageID(id int)
+	- OptInlineKeyboardMarkup(markup *InlineKeyboardMarkup)
+	- OptReplyKeyboardMarkup(markup *ReplyKeyboardMarkup)
+	- OptReplyKeyboardRemove
+	- OptReplyKeyboardRemoveSelective
+	- OptForceReply
+	- OptForceReplySelective
+*/
+func (c *Client) CopyMessage(chatID, fromChatID SendChatID, messageID int, opts ...sendOption) (int, error) {
+	req := withChat(chatID, opts...)
+	req.Set("from_chat_id", fromChatID.asChatID())
+	req.Set("message_id", strconv.Itoa(messageID))
+	var copied struct {
+		MessageID int `json:"message_id"`
+	}
+	err := c.doRequest("copyMessage", req, &copied)
+	return copied.MessageID, err
+}
+
+// MaxMessageBatch is the maximum number of messages forwarded, copied or deleted at once
+const MaxMessageBatch = 100
+
+// checkMessageBatch validates number of message IDs, and their order if sorted is set
+func checkMessageBatch(messageIDs []int, sorted bool) error {
+	if len(messageIDs) == 0 || len(messageIDs) > MaxMessageBatch {
+		return &MessageBatchError{Count: len(messageIDs)}
+	}
+	for i := 1; sorted && i < len(messageIDs); i++ {
+		if messageIDs[i] <= messageIDs[i-1] {
+			return &MessageBatchError{Count: len(messageIDs), Unsorted: true}
+		}
+	}
+	return nil
+}
+
+/*
+ForwardMessages forwards 1-100 messages from one chat to another at once and returns
+IDs of the sent messages. Message IDs must be in strictly increasing order.
+Messages that can't be found or forwarded are skipped, album grouping is kept.
+Available options:
+	- OptDisableNotification
+	- OptProtectContent
+	- OptMessageThreadID(id int)
+*/
+func (c *Client) ForwardMessages(chatID, fromChatID SendChatID, messageIDs []int, opts ...sendOption) ([]MessageID, error) {
+	return c.sendMessageBatch("forwardMessages", chatID, fromChatID, messageIDs, opts...)
+}
+
+// CopyMessages options
+var (
+	// OptRemoveCaption copies media messages without their captions
+	OptRemoveCaption = func(r url.Values) {
+		r.Set("remove_caption", "true")
+	}
+)
+
+/*
+CopyMessages copies 1-100 messages from one chat to another at once and returns
+IDs of the copies. Message IDs must be in strictly increasing order.
+Messages that can't be found or copied are skipped, album grouping is kept.
+Available options:
+	- OptDisableNotification
+	- OptProtectContent
+	- OptMessageThreadID(id int)
+	- OptRemoveCaption
+*/
+func (c *Client) CopyMessages(chatID, fromChatID SendChatID, messageIDs []int, opts ...sendOption) ([]MessageID, error) {
+	return c.sendMessageBatch("copyMessages", chatID, fromChatID, messageIDs, opts...)
+}
+
+// MessageID is an identifier of the message sent by ForwardMessages or CopyMessages
+type MessageID struct {
+	MessageID int `json:"message_id"`
+}
+
+func (c *Client) sendMessageBatch(method string, chatID, fromChatID SendChatID, messageIDs []int, opts ...sendOption) ([]MessageID, error) {
+	if err := checkMessageBatch(messageIDs, true); err != nil {
+		return nil, err
+	}
+	req := withChat(chatID, opts...)
+	req.Set("from_chat_id", fromChatID.asChatID())
+	req.Set("message_ids", structString(messageIDs))
+	var sent []MessageID
+	err := c.doRequest(method, req, &sent)
+	return sent, err
+}
+
 // SendAudio options
 var (
 	OptDuration = func(duration int) sendOption {
@@ -254,11 +530,14 @@ var (
 )
 
 /*
-SendAudio sends pre-uploaded audio to the chat. Pass fileID of the uploaded file. Available options:
+SendAudio sends audio to the chat. Pass file_id or http(s) URL of the audio as a string,
+or *InputFile to upload it. Available options:
 	- OptCaption(caption string)
+	- OptCaptionEntities(entities []*MessageEntity)
 	- OptDuration(duration int)
 	- OptPerformer(performer string)
 	- OptTitle(title string)
+	- OptThumb(filename string)
 	- OptParseModeHTML
 	- OptParseModeMarkdown
 	- OptDisableNotification
@@ -270,20 +549,26 @@ SendAudio sends pre-uploaded audio to the chat. Pass fileID of the uploaded file
 	- OptForceReply
 	- OptForceReplySelective
 */
-func (c *Client) SendAudio(chatID SendChatID, fileID string, opts ...sendOption) (*Message, error) {
+func (c *Client) SendAudio(chatID SendChatID, audio interface{}, opts ...sendOption) (*Message, error) {
 	req := withChat(chatID, opts...)
-	req.Set("audio", fileID)
+	files, err := setInputFile(req, "audio", audio)
+	if err != nil {
+		return nil, err
+	}
+	files = append(files, thumbFile(req)...)
 	msg := &Message{}
-	err := c.doRequest("sendAudio", req, msg)
+	err = c.doRequestWithFiles("sendAudio", req, msg, files...)
 	return msg, err
 }
 
 /*
 SendAudioFile sends file contents as an audio to the chat. Pass filename to send. Available options:
 	- OptCaption(caption string)
+	- OptCaptionEntities(entities []*MessageEntity)
 	- OptDuration(duration int)
 	- OptPerformer(performer string)
 	- OptTitle(title string)
+	- OptThumb(filename string)
 	- OptParseModeHTML
 	- OptParseModeMarkdown
 	- OptDisableNotification
@@ -296,10 +581,7 @@ SendAudioFile sends file contents as an audio to the chat. Pass filename to send
 	- OptForceReplySelective
 */
 func (c *Client) SendAudioFile(chatID SendChatID, filename string, opts ...sendOption) (*Message, error) {
-	req := withChat(chatID, opts...)
-	msg := &Message{}
-	err := c.doRequestWithFiles("sendAudio", req, msg, inputFile{field: "audio", name: filename})
-	return msg, err
+	return c.SendAudio(chatID, InputFilePath(filename), opts...)
 }
 
 // SendPhoto options
@@ -312,7 +594,8 @@ var (
 )
 
 /*
-SendPhoto sends pre-uploaded photo to the chat. Pass fileID of the photo. Available options:
+SendPhoto sends photo to the chat. Pass file_id or http(s) URL of the photo as a string,
+or *InputFile to upload it (see InputFilePath and InputFileReader). Available options:
 	- OptCaption(caption string)
 	- OptParseModeHTML
 	- OptParseModeMarkdown
@@ -325,11 +608,14 @@ SendPhoto sends pre-uploaded photo to the chat. Pass fileID of the photo. Availa
 	- OptForceReply
 	- OptForceReplySelective
 */
-func (c *Client) SendPhoto(chatID SendChatID, fileID string, opts ...sendOption) (*Message, error) {
+func (c *Client) SendPhoto(chatID SendChatID, photo interface{}, opts ...sendOption) (*Message, error) {
 	req := withChat(chatID, opts...)
-	req.Set("photo", fileID)
+	files, err := setInputFile(req, "photo", photo)
+	if err != nil {
+		return nil, err
+	}
 	msg := &Message{}
-	err := c.doRequest("sendPhoto", req, msg)
+	err = c.doRequestWithFiles("sendPhoto", req, msg, files...)
 	return msg, err
 }
 
@@ -348,14 +634,13 @@ SendPhotoFile sends photo file contents to the chat. Pass filename to send. Avai
 	- OptForceReplySelective
 */
 func (c *Client) SendPhotoFile(chatID SendChatID, filename string, opts ...sendOption) (*Message, error) {
-	req := withChat(chatID, opts...)
-	msg := &Message{}
-	err := c.doRequestWithFiles("sendPhoto", req, msg, inputFile{field: "photo", name: filename})
-	return msg, err
+	return c.SendPhoto(chatID, InputFilePath(filename), opts...)
 }
 
 /*
-SendDocument sends document to the chat. Pass fileID of the document. Available options:
+SendDocument sends document to the chat. Pass file_id or http(s) URL of the document as a string,
+or *InputFile to upload it. Uploaded contents are streamed, not buffered in memory. Available options:
+	- OptThumb(filename string)
 	- OptCaption(caption string)
 	- OptParseModeHTML
 	- OptParseModeMarkdown
@@ -368,16 +653,21 @@ SendDocument sends document to the chat. Pass fileID of the document. Available
 	- OptForceReply
 	- OptForceReplySelective
 */
-func (c *Client) SendDocument(chatID SendChatID, fileID string, opts ...sendOption) (*Message, error) {
+func (c *Client) SendDocument(chatID SendChatID, document interface{}, opts ...sendOption) (*Message, error) {
 	req := withChat(chatID, opts...)
-	req.Set("document", fileID)
+	files, err := setInputFile(req, "document", document)
+	if err != nil {
+		return nil, err
+	}
+	files = append(files, thumbFile(req)...)
 	msg := &Message{}
-	err := c.doRequest("sendDocument", req, msg)
+	err = c.doRequestWithFiles("sendDocument", req, msg, files...)
 	return msg, err
 }
 
 /*
 SendDocumentFile sends document file contents to the chat. Pass filename to send. Available options:
+	- OptThumb(filename string)
 	- OptCaption(caption string)
 	- OptParseModeHTML
 	- OptParseModeMarkdown
@@ -391,10 +681,7 @@ SendDocumentFile sends document file contents to the chat. Pass filename to send
 	- OptForceReplySelective
 */
 func (c *Client) SendDocumentFile(chatID SendChatID, filename string, opts ...sendOption) (*Message, error) {
-	req := withChat(chatID, opts...)
-	msg := &Message{}
-	err := c.doRequestWithFiles("sendDocument", req, msg, inputFile{field: "document", name: filename})
-	return msg, err
+	return c.SendDocument(chatID, InputFilePath(filename), opts...)
 }
 
 // SendVideo options
@@ -412,15 +699,22 @@ var (
 	OptSupportsStreaming = func(r url.Values) {
 		r.Set("supports_streaming", "true")
 	}
+	OptHasSpoiler = func(r url.Values) {
+		r.Set("has_spoiler", "true")
+	}
 )
 
 /*
-SendVideo sends pre-uploaded video to chat. Pass fileID of the uploaded video. Available options:
+SendVideo sends video to chat. Pass file_id or http(s) URL of the video as a string,
+or *InputFile to upload it. Available options:
 	- OptDuration(duration int)
 	- OptWidth(width int)
 	- OptHeight(height int)
 	- OptSupportsStreaming
+	- OptHasSpoiler
+	- OptThumb(filename string)
 	- OptCaption(caption string)
+	- OptCaptionEntities(entities []*MessageEntity)
 	- OptParseModeHTML
 	- OptParseModeMarkdown
 	- OptDisableNotification
@@ -432,11 +726,15 @@ SendVideo sends pre-uploaded video to chat. Pass fileID of the uploaded video. A
 	- OptForceReply
 	- OptForceReplySelective
 */
-func (c *Client) SendVideo(chatID SendChatID, fileID string, opts ...sendOption) (*Message, error) {
+func (c *Client) SendVideo(chatID SendChatID, video interface{}, opts ...sendOption) (*Message, error) {
 	req := withChat(chatID, opts...)
-	req.Set("video", fileID)
+	files, err := setInputFile(req, "video", video)
+	if err != nil {
+		return nil, err
+	}
+	files = append(files, thumbFile(req)...)
 	msg := &Message{}
-	err := c.doRequest("sendVideo", req, msg)
+	err = c.doRequestWithFiles("sendVideo", req, msg, files...)
 	return msg, err
 }
 
@@ -446,7 +744,10 @@ SendVideoFile sends video file contents to the chat. Pass filename to send. Avai
 	- OptWidth(width int)
 	- OptHeight(height int)
 	- OptSupportsStreaming
+	- OptHasSpoiler
+	- OptThumb(filename string)
 	- OptCaption(caption string)
+	- OptCaptionEntities(entities []*MessageEntity)
 	- OptParseModeHTML
 	- OptParseModeMarkdown
 	- OptDisableNotification
@@ -459,10 +760,7 @@ SendVideoFile sends video file contents to the chat. Pass filename to send. Avai
 	- OptForceReplySelective
 */
 func (c *Client) SendVideoFile(chatID SendChatID, filename string, opts ...sendOption) (*Message, error) {
-	req := withChat(chatID, opts...)
-	msg := &Message{}
-	err := c.doRequestWithFiles("sendVideo", req, msg, inputFile{field: "video", name: filename})
-	return msg, err
+	return c.SendVideo(chatID, InputFilePath(filename), opts...)
 }
 
 // SendAnimation options
@@ -475,12 +773,16 @@ var (
 )
 
 /*
-SendAnimation sends animation to chat. Pass fileID to send. Available options:
+SendAnimation sends animation (GIF or H.264/MPEG-4 AVC video without sound) to chat.
+Pass file_id or http(s) URL of the animation as a string, or *InputFile to upload it.
+Sent message has both Animation and Document fields set. Available options:
 	- OptDuration(duration int)
 	- OptWidth(width int)
 	- OptHeight(height int)
 	- OptThumb(filename string)
+	- OptHasSpoiler
 	- OptCaption(caption string)
+	- OptCaptionEntities(entities []*MessageEntity)
 	- OptParseModeHTML
 	- OptParseModeMarkdown
 	- OptDisableNotification
@@ -492,17 +794,15 @@ SendAnimation sends animation to chat. Pass fileID to send. Available options:
 	- OptForceReply
 	- OptForceReplySelective
 */
-func (c *Client) SendAnimation(chatID SendChatID, fileID string, opts ...sendOption) (*Message, error) {
+func (c *Client) SendAnimation(chatID SendChatID, animation interface{}, opts ...sendOption) (*Message, error) {
 	req := withChat(chatID, opts...)
-	req.Set("animation", fileID)
-	msg := &Message{}
-	var err error
-	if thumb := req.Get("thumb"); len(thumb) > 0 {
-		req.Del("thumb")
-		err = c.doRequestWithFiles("sendAnimation", req, msg, inputFile{field: "thumb", name: thumb})
-	} else {
-		err = c.doRequest("sendAnimation", req, msg)
+	files, err := setInputFile(req, "animation", animation)
+	if err != nil {
+		return nil, err
 	}
+	files = append(files, thumbFile(req)...)
+	msg := &Message{}
+	err = c.doRequestWithFiles("sendAnimation", req, msg, files...)
 	return msg, err
 }
 
@@ -512,7 +812,9 @@ SendAnimationFile sends animation file contents to the chat. Pass filename to se
 	- OptWidth(width int)
 	- OptHeight(height int)
 	- OptThumb(filename string)
+	- OptHasSpoiler
 	- OptCaption(caption string)
+	- OptCaptionEntities(entities []*MessageEntity)
 	- OptParseModeHTML
 	- OptParseModeMarkdown
 	- OptDisableNotification
@@ -525,20 +827,14 @@ SendAnimationFile sends animation file contents to the chat. Pass filename to se
 	- OptForceReplySelective
 */
 func (c *Client) SendAnimationFile(chatID SendChatID, filename string, opts ...sendOption) (*Message, error) {
-	req := withChat(chatID, opts...)
-	msg := &Message{}
-	files := []inputFile{{field: "animation", name: filename}}
-	if thumb := req.Get("thumb"); len(thumb) > 0 {
-		req.Del("thumb")
-		files = append(files, inputFile{field: "thumb", name: thumb})
-	}
-	err := c.doRequestWithFiles("sendAnimation", req, msg, files...)
-	return msg, err
+	return c.SendAnimation(chatID, InputFilePath(filename), opts...)
 }
 
 /*
-SendVoice sends audio file as a voice message. Pass file_id of previously uploaded file. Available options:
+SendVoice sends OGG/OPUS audio as a voice message. Pass file_id or http(s) URL of the audio as a string,
+or *InputFile to upload it. Available options:
 	- OptCaption(caption string)
+	- OptCaptionEntities(entities []*MessageEntity)
 	- OptDuration(duration int)
 	- OptParseModeHTML
 	- OptParseModeMarkdown
@@ -551,17 +847,21 @@ SendVoice sends audio file as a voice message. Pass file_id of previously upload
 	- OptForceReply
 	- OptForceReplySelective
 */
-func (c *Client) SendVoice(chatID SendChatID, fileID string, opts ...sendOption) (*Message, error) {
+func (c *Client) SendVoice(chatID SendChatID, voice interface{}, opts ...sendOption) (*Message, error) {
 	req := withChat(chatID, opts...)
-	req.Set("voice", fileID)
+	files, err := setInputFile(req, "voice", voice)
+	if err != nil {
+		return nil, err
+	}
 	msg := &Message{}
-	err := c.doRequest("sendVoice", req, msg)
+	err = c.doRequestWithFiles("sendVoice", req, msg, files...)
 	return msg, err
 }
 
 /*
 SendVoiceFile sends the audio file as a voice message. Pass filename to send. Available options:
 	- OptCaption(caption string)
+	- OptCaptionEntities(entities []*MessageEntity)
 	- OptDuration(duration int)
 	- OptParseModeHTML
 	- OptParseModeMarkdown
@@ -575,10 +875,7 @@ SendVoiceFile sends the audio file as a voice message. Pass filename to send. Av
 	- OptForceReplySelective
 */
 func (c *Client) SendVoiceFile(chatID SendChatID, filename string, opts ...sendOption) (*Message, error) {
-	req := withChat(chatID, opts...)
-	msg := &Message{}
-	err := c.doRequestWithFiles("sendVoice", req, msg, inputFile{field: "voice", name: filename})
-	return msg, err
+	return c.SendVoice(chatID, InputFilePath(filename), opts...)
 }
 
 // SendVideoNote options
@@ -591,7 +888,8 @@ var (
 )
 
 /*
-SendVideoNote sends video note. Pass fileID of previously uploaded video note. Available options:
+SendVideoNote sends video note. Pass file_id of previously uploaded video note as a string,
+or *InputFile to upload it. Sending video notes by URL is not supported. Available options:
 	- OptDuration(duration int)
 	- OptLength(length int)
 	- OptThumb(filename string)
@@ -604,17 +902,18 @@ SendVideoNote sends video note. Pass fileID of previously uploaded video note. A
 	- OptForceReply
 	- OptForceReplySelective
 */
-func (c *Client) SendVideoNote(chatID SendChatID, fileID string, opts ...sendOption) (*Message, error) {
+func (c *Client) SendVideoNote(chatID SendChatID, videoNote interface{}, opts ...sendOption) (*Message, error) {
+	if u, ok := videoNote.(string); ok && isURL(u) {
+		return nil, fmt.Errorf("sending video notes by URL is not supported, pass file_id or *InputFile")
+	}
 	req := withChat(chatID, opts...)
-	req.Set("video_note", fileID)
-	msg := &Message{}
-	var err error
-	if thumb := req.Get("thumb"); len(thumb) > 0 {
-		req.Del("thumb")
-		err = c.doRequestWithFiles("sendVideoNote", req, msg, inputFile{field: "thumb", name: thumb})
-	} else {
-		err = c.doRequest("sendVideoNote", req, msg)
+	files, err := setInputFile(req, "video_note", videoNote)
+	if err != nil {
+		return nil, err
 	}
+	files = append(files, thumbFile(req)...)
+	msg := &Message{}
+	err = c.doRequestWithFiles("sendVideoNote", req, msg, files...)
 	return msg, err
 }
 
@@ -633,63 +932,143 @@ SendVideoNoteFile sends video note to chat. Pass filename to upload. Available o
 	- OptForceReplySelective
 */
 func (c *Client) SendVideoNoteFile(chatID SendChatID, filename string, opts ...sendOption) (*Message, error) {
-	req := withChat(chatID, opts...)
-	files := []inputFile{{field: "video_note", name: filename}}
-	if len(req.Get("thumb")) > 0 {
-		thumb := req.Get("thumb")
-		req.Del("thumb")
-		files = append(files, inputFile{field: "thumb", name: thumb})
-	}
-	msg := &Message{}
-	err := c.doRequestWithFiles("sendVideoNote", req, msg, files...)
-	return msg, err
+	return c.SendVideoNote(chatID, InputFilePath(filename), opts...)
 }
 
 // InputMedia file
 type InputMedia interface {
-	inputMedia()
+	// attach returns media ready to be sent, with file to upload referenced by the field name
+	attach(field string) (InputMedia, []inputFile)
 }
 
 var (
 	_ InputMedia = InputMediaPhoto{}
 	_ InputMedia = InputMediaVideo{}
+	_ InputMedia = InputMediaDocument{}
 )
 
-// InputMediaPhoto represents a photo to be sent
+// InputMediaPhoto represents a photo to be sent.
+// Media is file_id or URL, set File instead to upload the photo.
 type InputMediaPhoto struct {
-	Type      string `json:"type"`
-	Media     string `json:"media"`
-	Caption   string `json:"caption,omitempty"`
-	ParseMode string `json:"parse_mode,omitempty"`
+	Type      string     `json:"type"`
+	Media     string     `json:"media"`
+	File      *InputFile `json:"-"`
+	Caption   string     `json:"caption,omitempty"`
+	ParseMode string     `json:"parse_mode,omitempty"`
 }
 
-func (InputMediaPhoto) inputMedia() {}
+func (m InputMediaPhoto) attach(field string) (InputMedia, []inputFile) {
+	m.Type = "photo"
+	return m, attachFile(&m.Media, field, m.File)
+}
 
-// InputMediaVideo represents a video to be sent
+// InputMediaVideo represents a video to be sent.
+// Media is file_id or URL, set File instead to upload the video.
 type InputMediaVideo struct {
-	Type              string `json:"type"`
-	Media             string `json:"media"`
-	Thumb             string `json:"thumb,omitempty"`
-	Caption           string `json:"caption,omitempty"`
-	ParseMode         string `json:"parse_mode,omitempty"`
-	Width             int    `json:"width,omitempty"`
-	Height            int    `json:"height,omitempty"`
-	Duration          int    `json:"duration,omitempty"`
-	SupportsStreaming bool   `json:"supports_streaming,omitempty"`
+	Type              string     `json:"type"`
+	Media             string     `json:"media"`
+	File              *InputFile `json:"-"`
+	Thumb             string     `json:"thumb,omitempty"`
+	Caption           string     `json:"caption,omitempty"`
+	ParseMode         string     `json:"parse_mode,omitempty"`
+	Width             int        `json:"width,omitempty"`
+	Height            int        `json:"height,omitempty"`
+	Duration          int        `json:"duration,omitempty"`
+	SupportsStreaming bool       `json:"supports_streaming,omitempty"`
+}
+
+func (m InputMediaVideo) attach(field string) (InputMedia, []inputFile) {
+	m.Type = "video"
+	return m, attachFile(&m.Media, field, m.File)
+}
+
+// InputMediaDocument represents a general file to be sent.
+// Media is file_id or URL, set File instead to upload the document.
+type InputMediaDocument struct {
+	Type      string     `json:"type"`
+	Media     string     `json:"media"`
+	File      *InputFile `json:"-"`
+	Thumb     string     `json:"thumb,omitempty"`
+	Caption   string     `json:"caption,omitempty"`
+	ParseMode string     `json:"parse_mode,omitempty"`
+}
+
+func (m InputMediaDocument) attach(field string) (InputMedia, []inputFile) {
+	m.Type = "document"
+	return m, attachFile(&m.Media, field, m.File)
+}
+
+// attachFile replaces media with attach:// reference if the file is going to be uploaded
+func attachFile(media *string, field string, file *InputFile) []inputFile {
+	if file == nil {
+		return nil
+	}
+	*media = "attach://" + field
+	files, _ := setInputFile(nil, field, file)
+	return files
+}
+
+// attachMedia returns media of the group ready to be sent and files to be uploaded with the request
+func attachMedia(media []InputMedia) ([]InputMedia, []inputFile) {
+	attached := make([]InputMedia, len(media))
+	var files []inputFile
+	for i, m := range media {
+		var f []inputFile
+		attached[i], f = m.attach("media" + strconv.Itoa(i))
+		files = append(files, f...)
+	}
+	return attached, files
 }
 
-func (InputMediaVideo) inputMedia() {}
-
-// SendMediaGroup send a group of photos or videos as an album
+/*
+SendMediaGroup send a group of photos or videos as an album. Available options:
+	- OptDisableNotification
+	- OptReplyToMessageID(id int)
+*/
 func (c *Client) SendMediaGroup(chatID SendChatID, media []InputMedia, opts ...sendOption) ([]*Message, error) {
-	req := withChat(chatID)
-	m, _ := json.Marshal(media)
-	req.Set("media", string(m))
+	req := withChat(chatID, opts...)
+	attached, files := attachMedia(media)
+	req.Set("media", structString(attached))
 	var msgs []*Message
-	err := c.doRequest("sendMediaGroup", req, &msgs)
+	err := c.doRequestWithFiles("sendMediaGroup", req, &msgs, files...)
 	return msgs, err
 }
 
+/*
+EditMessageMedia replace animation, audio, document, photo or video of the message sent by the bot.
+Available options:
+	- OptInlineKeyboardMarkup(markup *InlineKeyboardMarkup)
+*/
+func (c *Client) EditMessageMedia(chatID SendChatID, messageID int, media InputMedia, opts ...sendOption) (*Message, error) {
+	req := withChat(chatID, opts...)
+	req.Set("message_id", strconv.Itoa(messageID))
+	attached, files := media.attach("media_file")
+	req.Set("media", structString(attached))
+	msg := &Message{}
+	err := c.doRequestWithFiles("editMessageMedia", req, msg, files...)
+	return msg, err
+}
+
+/*
+EditInlineMessageMedia replace media of the message sent via the bot (for inline bots).
+New file can't be uploaded, use file_id or URL. Available options:
+	- OptInlineKeyboardMarkup(markup *InlineKeyboardMarkup)
+*/
+func (c *Client) EditInlineMessageMedia(inlineMessageID string, media InputMedia, opts ...sendOption) error {
+	req := url.Values{}
+	req.Set("inline_message_id", inlineMessageID)
+	for _, opt := range opts {
+		opt(req)
+	}
+	attached, files := media.attach("media_file")
+	if len(files) != 0 {
+		return fmt.Errorf("inline message media can't be uploaded")
+	}
+	req.Set("media", structString(attached))
+	var edited bool
+	return c.doRequest("editMessageMedia", req, &edited)
+}
+
 // SendLocation options
 var (
 	OptLivePeriod = func(period int) sendOption {
@@ -697,6 +1076,25 @@ var (
 			v.Set("live_period", strconv.Itoa(period))
 		}
 	}
+	// OptHorizontalAccuracy sets radius of uncertainty for the location in meters, 0-1500
+	OptHorizontalAccuracy = func(meters float64) sendOption {
+		return func(v url.Values) {
+			v.Set("horizontal_accuracy", strconv.FormatFloat(meters, 'f', -1, 64))
+		}
+	}
+	// OptHeading sets direction in which the user is moving in degrees, 1-360, for live locations
+	OptHeading = func(heading int) sendOption {
+		return func(v url.Values) {
+			v.Set("heading", strconv.Itoa(heading))
+		}
+	}
+	// OptProximityAlertRadius sets maximum distance in meters for proximity alerts
+	// about approaching another chat member, 1-100000, for live locations
+	OptProximityAlertRadius = func(radius int) sendOption {
+		return func(v url.Values) {
+			v.Set("proximity_alert_radius", strconv.Itoa(radius))
+		}
+	}
 )
 
 func setLarLong(req url.Values, latitude, longitude float64) {
@@ -705,8 +1103,12 @@ func setLarLong(req url.Values, latitude, longitude float64) {
 }
 
 /*
-SendLocation sends point on the map to chat. Available options:
+SendLocation sends point on the map to chat. Location with live period is a live location,
+keep returned message to update it later with EditMessageLiveLocation. Available options:
 	- OptLivePeriod(period int)
+	- OptHorizontalAccuracy(meters float64)
+	- OptHeading(heading int)
+	- OptProximityAlertRadius(radius int)
 	- OptDisableNotification
 	- OptReplyToMessageID(id int)
 	- OptInlineKeyboardMarkup(markup *InlineKeyboardMarkup)
@@ -726,6 +1128,9 @@ func (c *Client) SendLocation(chatID SendChatID, latitude, longitude float64, op
 
 /*
 EditMessageLiveLocation edits location in message sent by the bot. Available options:
+	- OptHorizontalAccuracy(meters float64)
+	- OptHeading(heading int)
+	- OptProximityAlertRadius(radius int)
 	- OptInlineKeyboardMarkup(markup *InlineKeyboardMarkup)
 */
 func (c *Client) EditMessageLiveLocation(chatID SendChatID, messageID int, latitude, longitude float64, opts ...sendOption) (*Message, error) {
@@ -739,6 +1144,9 @@ func (c *Client) EditMessageLiveLocation(chatID SendChatID, messageID int, latit
 
 /*
 EditInlineMessageLiveLocation edits location in message sent via the bot (using inline mode). Available options:
+	- OptHorizontalAccuracy(meters float64)
+	- OptHeading(heading int)
+	- OptProximityAlertRadius(radius int)
 	- OptInlineKeyboardMarkup(markup *InlineKeyboardMarkup)
 */
 func (c *Client) EditInlineMessageLiveLocation(inlineMessageID string, latitude, longitude float64, opts ...sendOption) error {
@@ -791,12 +1199,24 @@ var (
 			v.Set("foursquare_type", foursquareType)
 		}
 	}
+	OptGooglePlaceID = func(googlePlaceID string) sendOption {
+		return func(v url.Values) {
+			v.Set("google_place_id", googlePlaceID)
+		}
+	}
+	OptGooglePlaceType = func(googlePlaceType string) sendOption {
+		return func(v url.Values) {
+			v.Set("google_place_type", googlePlaceType)
+		}
+	}
 )
 
 /*
-SendVenue sends information about a venue. Available options:
+SendVenue sends information about a venue. Sent message has Venue and Location fields set. Available options:
 	- OptFoursquareID(foursquareID string)
 	- OptFoursquareType(foursquareType string)
+	- OptGooglePlaceID(googlePlaceID string)
+	- OptGooglePlaceType(googlePlaceType string)
 	- OptDisableNotification
 	- OptReplyToMessageID(id int)
 	- OptInlineKeyboardMarkup(markup *InlineKeyboardMarkup)
@@ -833,7 +1253,7 @@ var (
 /*
 SendContact sends phone contact. Available options:
 	- OptLastName(lastName string)
-	- OptVCard(vCard string) TODO: implement vCard support (https://tools.ietf.org/html/rfc6350)
+	- OptVCard(vCard string), additional data about the contact in the form of a vCard (https://tools.ietf.org/html/rfc6350)
 	- OptDisableNotification
 	- OptReplyToMessageID(id int)
 	- OptInlineKeyboardMarkup(markup *InlineKeyboardMarkup)
@@ -854,43 +1274,57 @@ func (c *Client) SendContact(chatID SendChatID, phoneNumber, firstName string, o
 
 type chatAction string
 
+// ChatAction is an action for SendChatAction, e.g. ActionTyping
+type ChatAction = chatAction
+
 // Actions for SendChatAction
 const (
 	ActionTyping          chatAction = "typing"
 	ActionUploadPhoto     chatAction = "upload_photo"
 	ActionRecordVideo     chatAction = "record_video"
 	ActionUploadVideo     chatAction = "upload_video"
-	ActionRecordAudio     chatAction = "record_audio"
-	ActionUploadAudio     chatAction = "upload_audio"
+	ActionRecordVoice     chatAction = "record_voice"
+	ActionUploadVoice     chatAction = "upload_voice"
 	ActionUploadDocument  chatAction = "upload_document"
+	ActionChooseSticker   chatAction = "choose_sticker"
 	ActionFindLocation    chatAction = "find_location"
 	ActionRecordVideoNote chatAction = "record_video_note"
 	ActionUploadVideoNote chatAction = "upload_video_note"
+
+	// Deprecated: use ActionRecordVoice
+	ActionRecordAudio chatAction = "record_audio"
+	// Deprecated: use ActionUploadVoice
+	ActionUploadAudio chatAction = "upload_audio"
 )
 
 /*
-SendChatAction sends bot chat action. Available actions:
+SendChatAction sends bot chat action. Action is shown for 5 seconds or until the bot sends a message.
+Available actions:
 	- ActionTyping
 	- ActionUploadPhoto
 	- ActionRecordVideo
 	- ActionUploadVideo
-	- ActionRecordAudio
-	- ActionUploadAudio
+	- ActionRecordVoice
+	- ActionUploadVoice
 	- ActionUploadDocument
+	- ActionChooseSticker
 	- ActionFindLocation
 	- ActionRecordVideoNote
 	- ActionUploadVideoNote
+Available options:
+	- OptMessageThreadID(id int)
 */
-func (c *Client) SendChatAction(chatID SendChatID, action chatAction) error {
-	req := withChat(chatID)
+func (c *Client) SendChatAction(chatID SendChatID, action chatAction, opts ...sendOption) error {
+	req := withChat(chatID, opts...)
 	req.Set("action", string(action))
 	var sent bool
 	return c.doRequest("sendChatAction", req, &sent)
 }
 
-// UserProfilePhotos represent a user's profile pictures
+// UserProfilePhotos represent a user's profile pictures.
+// Each photo is a list of its sizes, from the smallest to the largest.
 type UserProfilePhotos struct {
-	TotalCount int           `json:"total_count"`
+	TotalCount int           `json:"total_count"` // total number of photos, may be larger than len(Photos)
 	Photos     [][]PhotoSize `json:"photos"`
 }
 
@@ -909,9 +1343,12 @@ var (
 )
 
 /*
-GetUserProfilePhotos returs user's profile pictures. Available options:
-	- OptOffset(offset int)
-	- OptLimit(limit int)
+GetUserProfilePhotos returns user's profile pictures, newest first.
+Use FileID of a photo size with GetFile to download it. Available options:
+	- OptOffset(offset int), number of the first photo to return
+	- OptLimit(limit int), 1-100, defaults to 100
+
+Use GetAllUserProfilePhotos to get all photos page by page.
 */
 func (c *Client) GetUserProfilePhotos(userID int64, opts ...sendOption) (*UserProfilePhotos, error) {
 	req := url.Values{}
@@ -974,10 +1411,38 @@ func (c *Client) UnbanChatMember(chatID SendChatID, userID int64) error {
 }
 
 /*
-RestrictChatMember restrict a user in a supergroup. Available options:
+BanChatSenderChat ban a channel chat in a supergroup or a channel, the owner of the banned chat
+can't send messages on behalf of any of their channels. Messages sent on behalf of a channel
+have Message.SenderChat set.
+*/
+func (c *Client) BanChatSenderChat(chatID SendChatID, senderChatID int64) error {
+	req := withChat(chatID)
+	req.Set("sender_chat_id", strconv.FormatInt(senderChatID, 10))
+	var banned bool
+	return c.doRequest("banChatSenderChat", req, &banned)
+}
+
+/*
+UnbanChatSenderChat unban a previously banned channel chat in a supergroup or channel
+*/
+func (c *Client) UnbanChatSenderChat(chatID SendChatID, senderChatID int64) error {
+	req := withChat(chatID)
+	req.Set("sender_chat_id", strconv.FormatInt(senderChatID, 10))
+	var unbanned bool
+	return c.doRequest("unbanChatSenderChat", req, &unbanned)
+}
+
+/*
+RestrictChatMember restrict a user in a supergroup, permissions replace current permissions of the user.
+Restrictions for less than 30 seconds or more than 366 days are forever,
+use OptUntilDate to lift them later. See MutePermissions. Available options:
 	- OptUntilDate(date time.Time)
+	- OptUseIndependentChatPermissions
 */
 func (c *Client) RestrictChatMember(chatID SendChatID, userID int64, perm *ChatPermissions, opts ...sendOption) error {
+	if perm == nil {
+		return fmt.Errorf("permissions are required")
+	}
 	req := withChat(chatID, opts...)
 	req.Set("user_id", strconv.FormatInt(userID, 10))
 	marshalledPermissions, _ := json.Marshal(perm)
@@ -986,36 +1451,91 @@ func (c *Client) RestrictChatMember(chatID SendChatID, userID int64, perm *ChatP
 	return c.doRequest("restrictChatMember", req, &restricted)
 }
 
-// Promotions give user permitions in a supergroup or channel.
+// Promotions give user permitions in a supergroup or channel, see OptPromotions.
 type Promotions struct {
-	CanChangeInfo      bool
-	CanPostMessages    bool
-	CanEditMessages    bool
-	CanDeleteMessages  bool
-	CanInviteUsers     bool
-	CanRestrictMembers bool
-	CanPinMessages     bool
-	CanPromoteMembers  bool
+	IsAnonymous         bool
+	CanManageChat       bool
+	CanChangeInfo       bool
+	CanPostMessages     bool
+	CanEditMessages     bool
+	CanDeleteMessages   bool
+	CanManageVideoChats bool
+	CanInviteUsers      bool
+	CanRestrictMembers  bool
+	CanPinMessages      bool
+	CanManageTopics     bool
+	CanPromoteMembers   bool
+}
+
+func setRight(name string) sendOption {
+	return func(v url.Values) {
+		v.Set(name, "true")
+	}
 }
 
+// PromoteChatMember options
+var (
+	OptIsAnonymous         = setRight("is_anonymous")
+	OptCanManageChat       = setRight("can_manage_chat")
+	OptCanChangeInfo       = setRight("can_change_info")
+	OptCanPostMessages     = setRight("can_post_messages") // channels only
+	OptCanEditMessages     = setRight("can_edit_messages") // channels only
+	OptCanDeleteMessages   = setRight("can_delete_messages")
+	OptCanManageVideoChats = setRight("can_manage_video_chats")
+	OptCanInviteUsers      = setRight("can_invite_users")
+	OptCanRestrictMembers  = setRight("can_restrict_members")
+	OptCanPinMessages      = setRight("can_pin_messages")
+	OptCanManageTopics     = setRight("can_manage_topics") // forum supergroups only
+	OptCanPromoteMembers   = setRight("can_promote_members")
+	// OptPromotions sets all rights from Promotions
+	OptPromotions = func(p *Promotions) sendOption {
+		return func(v url.Values) {
+			v.Set("is_anonymous", strconv.FormatBool(p.IsAnonymous))
+			v.Set("can_manage_chat", strconv.FormatBool(p.CanManageChat))
+			v.Set("can_change_info", strconv.FormatBool(p.CanChangeInfo))
+			v.Set("can_post_messages", strconv.FormatBool(p.CanPostMessages))
+			v.Set("can_edit_messages", strconv.FormatBool(p.CanEditMessages))
+			v.Set("can_delete_messages", strconv.FormatBool(p.CanDeleteMessages))
+			v.Set("can_manage_video_chats", strconv.FormatBool(p.CanManageVideoChats))
+			v.Set("can_invite_users", strconv.FormatBool(p.CanInviteUsers))
+			v.Set("can_restrict_members", strconv.FormatBool(p.CanRestrictMembers))
+			v.Set("can_pin_messages", strconv.FormatBool(p.CanPinMessages))
+			v.Set("can_manage_topics", strconv.FormatBool(p.CanManageTopics))
+			v.Set("can_promote_members", strconv.FormatBool(p.CanPromoteMembers))
+		}
+	}
+)
+
 /*
-PromoteChatMember promote or demote a user in a supergroup or a channel
+PromoteChatMember promote or demote a user in a supergroup or a channel.
+Rights not set by options are revoked, so calling it without options demotes the user
+to a regular member. The bot must have the rights it grants. Available options:
+	- OptIsAnonymous
+	- OptCanManageChat
+	- OptCanChangeInfo
+	- OptCanPostMessages
+	- OptCanEditMessages
+	- OptCanDeleteMessages
+	- OptCanManageVideoChats
+	- OptCanInviteUsers
+	- OptCanRestrictMembers
+	- OptCanPinMessages
+	- OptCanManageTopics
+	- OptCanPromoteMembers
+	- OptPromotions(p *Promotions)
 */
-func (c *Client) PromoteChatMember(chatID SendChatID, userID int64, p *Promotions) error {
-	req := withChat(chatID)
+func (c *Client) PromoteChatMember(chatID SendChatID, userID int64, opts ...sendOption) error {
+	req := withChat(chatID, opts...)
 	req.Set("user_id", strconv.FormatInt(userID, 10))
-	req.Set("can_change_info", fmt.Sprint(p.CanChangeInfo))
-	req.Set("can_post_messages", fmt.Sprint(p.CanPostMessages))
-	req.Set("can_edit_messages", fmt.Sprint(p.CanEditMessages))
-	req.Set("can_delete_messages", fmt.Sprint(p.CanDeleteMessages))
-	req.Set("can_invite_users", fmt.Sprint(p.CanInviteUsers))
-	req.Set("can_restrict_members", fmt.Sprint(p.CanRestrictMembers))
-	req.Set("can_pin_messages", fmt.Sprint(p.CanPinMessages))
-	req.Set("can_promote_member", fmt.Sprint(p.CanPromoteMembers))
 	var promoted bool
 	return c.doRequest("promoteChatMember", req, &promoted)
 }
 
+// DemoteChatMember revokes all administrator rights of the user
+func (c *Client) DemoteChatMember(chatID SendChatID, userID int64) error {
+	return c.PromoteChatMember(chatID, userID)
+}
+
 /*
 ExportChatInviteLink generate a new invite link for a chat; any previously generated link is revoked
 */
@@ -1026,47 +1546,185 @@ func (c *Client) ExportChatInviteLink(chatID SendChatID) (string, error) {
 	return link, err
 }
 
-/*
-SetChatPhoto set a new profile photo for the chat
-*/
-func (c *Client) SetChatPhoto(chatID SendChatID, filename string) error {
+// ApproveChatJoinRequest approve a chat join request, the bot must be an administrator
+// with can_invite_users right. APIError description tells why the request can't be approved,
+// e.g. USER_ALREADY_PARTICIPANT or HIDE_REQUESTER_MISSING if the request was already handled.
+func (c *Client) ApproveChatJoinRequest(chatID SendChatID, userID int64) error {
 	req := withChat(chatID)
-	var updated bool
-	return c.doRequestWithFiles("setChatPhoto", req, &updated, inputFile{field: "photo", name: filename})
+	req.Set("user_id", strconv.FormatInt(userID, 10))
+	var approved bool
+	return c.doRequest("approveChatJoinRequest", req, &approved)
 }
 
-/*
-DeleteChatPhoto deleta a chat photo
-*/
-func (c *Client) DeleteChatPhoto(chatID SendChatID) error {
+// DeclineChatJoinRequest decline a chat join request, the bot must be an administrator
+// with can_invite_users right
+func (c *Client) DeclineChatJoinRequest(chatID SendChatID, userID int64) error {
 	req := withChat(chatID)
-	var deleted bool
-	return c.doRequest("deleteChatPhoto", req, &deleted)
+	req.Set("user_id", strconv.FormatInt(userID, 10))
+	var declined bool
+	return c.doRequest("declineChatJoinRequest", req, &declined)
 }
 
-/*
-SetChatTitle change the title of the chat
-*/
-func (c *Client) SetChatTitle(chatID SendChatID, title string) error {
-	req := withChat(chatID)
-	req.Set("title", title)
-	var set bool
-	return c.doRequest("setChatTitle", req, &set)
+// ChatInviteLink represents an invite link for a chat
+type ChatInviteLink struct {
+	InviteLink              string `json:"invite_link"`
+	Creator                 User   `json:"creator"`
+	CreatesJoinRequest      bool   `json:"creates_join_request"`
+	IsPrimary               bool   `json:"is_primary"`
+	IsRevoked               bool   `json:"is_revoked"`
+	Name                    string `json:"name"`
+	ExpireDate              int64  `json:"expire_date"`
+	MemberLimit             int    `json:"member_limit"`
+	PendingJoinRequestCount int    `json:"pending_join_request_count"`
 }
 
+// CreateChatInviteLink and EditChatInviteLink options
+var (
+	OptInviteLinkName = func(name string) sendOption {
+		return func(v url.Values) {
+			v.Set("name", name)
+		}
+	}
+	OptExpireDate = func(date time.Time) sendOption {
+		return func(v url.Values) {
+			v.Set("expire_date", fmt.Sprint(date.Unix()))
+		}
+	}
+	// OptMemberLimit sets maximum number of users joining by the link, 1-99999
+	OptMemberLimit = func(limit int) sendOption {
+		return func(v url.Values) {
+			v.Set("member_limit", strconv.Itoa(limit))
+		}
+	}
+	// OptCreatesJoinRequest requires approval of users joining by the link, can't be used with OptMemberLimit
+	OptCreatesJoinRequest = func(v url.Values) {
+		v.Set("creates_join_request", "true")
+	}
+)
+
 /*
-SetChatDescription change the description of a supergroup or a channel
+CreateChatInviteLink create an additional invite link for a chat, the bot must be an administrator
+with can_invite_users right. Available options:
+	- OptInviteLinkName(name string)
+	- OptExpireDate(date time.Time)
+	- OptMemberLimit(limit int)
+	- OptCreatesJoinRequest
 */
-func (c *Client) SetChatDescription(chatID SendChatID, description string) error {
-	req := withChat(chatID)
-	req.Set("description", description)
-	var set bool
-	return c.doRequest("setChatDescription", req, &set)
+func (c *Client) CreateChatInviteLink(chatID SendChatID, opts ...sendOption) (*ChatInviteLink, error) {
+	req := withChat(chatID, opts...)
+	link := &ChatInviteLink{}
+	err := c.doRequest("createChatInviteLink", req, link)
+	return link, err
 }
 
 /*
-PinChatMessage pin a message in a supergroup or a channel. Available options:
-	- OptDisableNotification
+EditChatInviteLink edit a non-primary invite link created by the bot. Available options:
+	- OptInviteLinkName(name string)
+	- OptExpireDate(date time.Time)
+	- OptMemberLimit(limit int)
+	- OptCreatesJoinRequest
+*/
+func (c *Client) EditChatInviteLink(chatID SendChatID, inviteLink string, opts ...sendOption) (*ChatInviteLink, error) {
+	req := withChat(chatID, opts...)
+	req.Set("invite_link", inviteLink)
+	link := &ChatInviteLink{}
+	err := c.doRequest("editChatInviteLink", req, link)
+	return link, err
+}
+
+// RevokeChatInviteLink revoke an invite link created by the bot.
+// If the primary link is revoked, a new link is automatically generated.
+func (c *Client) RevokeChatInviteLink(chatID SendChatID, inviteLink string) (*ChatInviteLink, error) {
+	req := withChat(chatID)
+	req.Set("invite_link", inviteLink)
+	link := &ChatInviteLink{}
+	err := c.doRequest("revokeChatInviteLink", req, link)
+	return link, err
+}
+
+/*
+SetChatPhoto set a new profile photo for the chat, photo must be uploaded
+with InputFilePath or InputFileReader: Telegram doesn't accept file_id or URL here.
+Returns ErrNotEnoughRights if the bot is not an administrator with can_change_info right.
+*/
+func (c *Client) SetChatPhoto(chatID SendChatID, photo *InputFile) error {
+	if photo == nil {
+		return fmt.Errorf("chat photo is empty")
+	}
+	if photo.reader == nil {
+		if isURL(photo.path) {
+			return fmt.Errorf("chat photo can't be set by URL %s, download it and upload with InputFileReader", photo.path)
+		}
+		if _, err := os.Stat(photo.path); err != nil {
+			return fmt.Errorf("chat photo must be a local file, file_id is not supported: %v", err)
+		}
+	}
+	req := withChat(chatID)
+	files, err := setInputFile(req, "photo", photo)
+	if err != nil {
+		return err
+	}
+	var updated bool
+	return c.doRequestWithFiles("setChatPhoto", req, &updated, files...)
+}
+
+/*
+SetChatPhotoFile set a new profile photo for the chat from the file.
+Returns ErrNotEnoughRights if the bot is not an administrator with can_change_info right.
+*/
+func (c *Client) SetChatPhotoFile(chatID SendChatID, filename string) error {
+	return c.SetChatPhoto(chatID, InputFilePath(filename))
+}
+
+/*
+DeleteChatPhoto delete a chat photo.
+Returns ErrNotEnoughRights if the bot is not an administrator with can_change_info right.
+*/
+func (c *Client) DeleteChatPhoto(chatID SendChatID) error {
+	req := withChat(chatID)
+	var deleted bool
+	return c.doRequest("deleteChatPhoto", req, &deleted)
+}
+
+// Chat title and description length limits in characters
+const (
+	MaxChatTitleLength       = 128
+	MaxChatDescriptionLength = 255
+)
+
+/*
+SetChatTitle change the title of the chat, title is 1-128 characters.
+Returns ErrNotEnoughRights if the bot is not an administrator with can_change_info right.
+*/
+func (c *Client) SetChatTitle(chatID SendChatID, title string) error {
+	if n := utf8.RuneCountInString(title); n == 0 || n > MaxChatTitleLength {
+		return fmt.Errorf("chat title must be 1-%d characters, got %d", MaxChatTitleLength, n)
+	}
+	req := withChat(chatID)
+	req.Set("title", title)
+	var set bool
+	return c.doRequest("setChatTitle", req, &set)
+}
+
+/*
+SetChatDescription change the description of a group, a supergroup or a channel.
+Description is 0-255 characters, empty description removes the current one.
+Returns ErrNotEnoughRights if the bot is not an administrator with can_change_info right.
+*/
+func (c *Client) SetChatDescription(chatID SendChatID, description string) error {
+	if n := utf8.RuneCountInString(description); n > MaxChatDescriptionLength {
+		return fmt.Errorf("chat description is %d characters long, max is %d", n, MaxChatDescriptionLength)
+	}
+	req := withChat(chatID)
+	req.Set("description", description)
+	var set bool
+	return c.doRequest("setChatDescription", req, &set)
+}
+
+/*
+PinChatMessage pin a message in a chat. The bot must be an administrator with
+the rights to pin messages in groups and channels. Available options:
+	- OptDisableNotification
 */
 func (c *Client) PinChatMessage(chatID SendChatID, messageID int, opts ...sendOption) error {
 	req := withChat(chatID, opts...)
@@ -1076,16 +1734,155 @@ func (c *Client) PinChatMessage(chatID SendChatID, messageID int, opts ...sendOp
 }
 
 /*
-UnpinChatMessage unpin a message in a supergroup or a channel
+UnpinChatMessage unpin a message in a chat, messageID 0 unpins the most recent pinned message
 */
-func (c *Client) UnpinChatMessage(chatID SendChatID) error {
+func (c *Client) UnpinChatMessage(chatID SendChatID, messageID int) error {
 	req := withChat(chatID)
+	if messageID != 0 {
+		req.Set("message_id", strconv.Itoa(messageID))
+	}
 	var unpinned bool
 	return c.doRequest("unpinChatMessage", req, &unpinned)
 }
 
 /*
-LeaveChat leave a group, supergroup or channel
+UnpinAllChatMessages clear the list of pinned messages in a chat
+*/
+func (c *Client) UnpinAllChatMessages(chatID SendChatID) error {
+	req := withChat(chatID)
+	var unpinned bool
+	return c.doRequest("unpinAllChatMessages", req, &unpinned)
+}
+
+// ForumTopic represents a topic of a forum supergroup
+type ForumTopic struct {
+	MessageThreadID   int    `json:"message_thread_id"`
+	Name              string `json:"name"`
+	IconColor         int    `json:"icon_color"` // RGB color of the topic icon
+	IconCustomEmojiID string `json:"icon_custom_emoji_id"`
+}
+
+// Colors of forum topic icons, the only ones allowed by CreateForumTopic
+const (
+	ForumTopicIconBlue   = 0x6FB9F0
+	ForumTopicIconYellow = 0xFFD67E
+	ForumTopicIconViolet = 0xCB86DB
+	ForumTopicIconGreen  = 0x8EEE98
+	ForumTopicIconRose   = 0xFF93B2
+	ForumTopicIconRed    = 0xFB6F5F
+)
+
+// MaxForumTopicNameLength is the maximum length of forum topic name in characters
+const MaxForumTopicNameLength = 128
+
+// CreateForumTopic and EditForumTopic options
+var (
+	// OptIconColor sets color of the topic icon, one of ForumTopicIcon* colors
+	OptIconColor = func(color int) sendOption {
+		return func(v url.Values) {
+			v.Set("icon_color", strconv.Itoa(color))
+		}
+	}
+	// OptIconCustomEmojiID sets custom emoji of the topic icon, see GetForumTopicIconStickers.
+	// Empty ID removes the icon on edit.
+	OptIconCustomEmojiID = func(id string) sendOption {
+		return func(v url.Values) {
+			v.Set("icon_custom_emoji_id", id)
+		}
+	}
+	// OptTopicName sets new name of the topic on edit
+	OptTopicName = func(name string) sendOption {
+		return func(v url.Values) {
+			v.Set("name", name)
+		}
+	}
+)
+
+func checkForumTopicName(name string) error {
+	if n := utf8.RuneCountInString(name); n == 0 || n > MaxForumTopicNameLength {
+		return fmt.Errorf("forum topic name must be 1-%d characters, got %d", MaxForumTopicNameLength, n)
+	}
+	return nil
+}
+
+/*
+CreateForumTopic create a topic in a forum supergroup, the bot must be an administrator
+with can_manage_topics right. Use MessageThreadID of the topic with OptMessageThreadID
+to send messages to it. Available options:
+	- OptIconColor(color int)
+	- OptIconCustomEmojiID(id string)
+*/
+func (c *Client) CreateForumTopic(chatID SendChatID, name string, opts ...sendOption) (*ForumTopic, error) {
+	if err := checkForumTopicName(name); err != nil {
+		return nil, err
+	}
+	req := withChat(chatID, opts...)
+	req.Set("name", name)
+	topic := &ForumTopic{}
+	err := c.doRequest("createForumTopic", req, topic)
+	return topic, err
+}
+
+/*
+EditForumTopic edit name and icon of a topic, the bot must be an administrator with
+can_manage_topics right, unless it is the creator of the topic. Available options:
+	- OptTopicName(name string)
+	- OptIconCustomEmojiID(id string)
+*/
+func (c *Client) EditForumTopic(chatID SendChatID, messageThreadID int, opts ...sendOption) error {
+	req := withChat(chatID, opts...)
+	if name, ok := req["name"]; ok {
+		if err := checkForumTopicName(name[0]); err != nil {
+			return err
+		}
+	}
+	return c.forumTopicRequest("editForumTopic", req, messageThreadID)
+}
+
+// CloseForumTopic close an open topic, new messages can be sent to it only by administrators
+func (c *Client) CloseForumTopic(chatID SendChatID, messageThreadID int) error {
+	return c.forumTopicRequest("closeForumTopic", withChat(chatID), messageThreadID)
+}
+
+// ReopenForumTopic reopen a closed topic
+func (c *Client) ReopenForumTopic(chatID SendChatID, messageThreadID int) error {
+	return c.forumTopicRequest("reopenForumTopic", withChat(chatID), messageThreadID)
+}
+
+// DeleteForumTopic delete a topic along with all its messages,
+// the bot must be an administrator with can_delete_messages right
+func (c *Client) DeleteForumTopic(chatID SendChatID, messageThreadID int) error {
+	return c.forumTopicRequest("deleteForumTopic", withChat(chatID), messageThreadID)
+}
+
+// UnpinAllForumTopicMessages clear the list of pinned messages in a topic
+func (c *Client) UnpinAllForumTopicMessages(chatID SendChatID, messageThreadID int) error {
+	return c.forumTopicRequest("unpinAllForumTopicMessages", withChat(chatID), messageThreadID)
+}
+
+func (c *Client) forumTopicRequest(method string, req url.Values, messageThreadID int) error {
+	req.Set("message_thread_id", strconv.Itoa(messageThreadID))
+	var ok bool
+	return c.doRequest(method, req, &ok)
+}
+
+// GetForumTopicIconStickers returns custom emoji stickers, which can be used as forum topic icons
+func (c *Client) GetForumTopicIconStickers() ([]Sticker, error) {
+	var stickers []Sticker
+	err := c.doRequest("getForumTopicIconStickers", url.Values{}, &stickers)
+	return stickers, err
+}
+
+/*
+LeaveChat leave a group, supergroup or channel.
+Bot receives my_chat_member update after leaving, see Server.HandleBotRemoved.
+Returns ErrBotBlocked if the bot is not a member of the chat anymore,
+it's safe to ignore when the goal is to leave:
+
+	err := client.LeaveChat(tbot.ChatID(chatID))
+	if err != nil && !errors.Is(err, tbot.ErrBotBlocked) {
+		return err
+	}
 */
 func (c *Client) LeaveChat(chatID SendChatID) error {
 	req := withChat(chatID)
@@ -1094,7 +1891,9 @@ func (c *Client) LeaveChat(chatID SendChatID) error {
 }
 
 /*
-GetChat get up to date information about the chat
+GetChat get up to date information about the chat. Description, invite link, pinned message,
+permissions and other details are set only by GetChat, not in chats of updates.
+Use it with ChatName to resolve channel username to numeric ID.
 */
 func (c *Client) GetChat(chatID SendChatID) (*Chat, error) {
 	req := withChat(chatID)
@@ -1103,31 +1902,83 @@ func (c *Client) GetChat(chatID SendChatID) (*Chat, error) {
 	return chat, err
 }
 
-// ChatMember contains information about one member of a chat
+// Chat member statuses
+const (
+	ChatMemberCreator       = "creator"
+	ChatMemberAdministrator = "administrator"
+	ChatMemberMember        = "member"
+	ChatMemberRestricted    = "restricted"
+	ChatMemberLeft          = "left"
+	ChatMemberKicked        = "kicked"
+)
+
+// ChatMember contains information about one member of a chat.
+// Set of fields depends on the status: administrator rights are set for administrators,
+// permissions and IsMember for restricted members, UntilDate for restricted and banned.
 type ChatMember struct {
-	User                  User   `json:"user"`
-	Status                string `json:"status"`
-	CustomTitle           string `json:"custom_title"`
-	UntilDate             int    `json:"until_date"`
-	CanBeEdited           bool   `json:"can_be_edited"`
-	CanChangeInfo         bool   `json:"can_change_info"`
-	CanPostMessages       bool   `json:"can_post_messages"`
-	CanEditMessages       bool   `json:"can_edit_messages"`
-	CanDeleteMessages     bool   `json:"can_delete_messages"`
-	CanInviteUsers        bool   `json:"can_invite_users"`
-	CanRestrictMembers    bool   `json:"can_restrict_members"`
-	CanPinMessages        bool   `json:"can_pin_messages"`
-	CanPromoteMembers     bool   `json:"can_promote_members"`
-	IsMember              bool   `json:"is_member"`
-	CanSendMessages       bool   `json:"can_send_messages"`
-	CanSendMediaMessages  bool   `json:"can_send_media_messages"`
-	CanSendOtherMessages  bool   `json:"can_send_other_messages"`
-	CanAddWebPagePreviews bool   `json:"can_add_web_page_previews"`
-	CanSendPolls          bool   `json:"can_send_polls"`
-}
-
-/*
-GetChatAdministrators get a list of administrators in a chat
+	User        User   `json:"user"`
+	Status      string `json:"status"`
+	CustomTitle string `json:"custom_title"`
+	IsAnonymous bool   `json:"is_anonymous"`
+	UntilDate   int    `json:"until_date"`
+
+	// administrator rights
+	CanBeEdited         bool `json:"can_be_edited"`
+	CanManageChat       bool `json:"can_manage_chat"`
+	CanChangeInfo       bool `json:"can_change_info"`
+	CanPostMessages     bool `json:"can_post_messages"`
+	CanEditMessages     bool `json:"can_edit_messages"`
+	CanDeleteMessages   bool `json:"can_delete_messages"`
+	CanManageVideoChats bool `json:"can_manage_video_chats"`
+	CanInviteUsers      bool `json:"can_invite_users"`
+	CanRestrictMembers  bool `json:"can_restrict_members"`
+	CanPinMessages      bool `json:"can_pin_messages"`
+	CanManageTopics     bool `json:"can_manage_topics"`
+	CanPromoteMembers   bool `json:"can_promote_members"`
+
+	// restricted member permissions
+	IsMember              bool `json:"is_member"`
+	CanSendMessages       bool `json:"can_send_messages"`
+	CanSendAudios         bool `json:"can_send_audios"`
+	CanSendDocuments      bool `json:"can_send_documents"`
+	CanSendPhotos         bool `json:"can_send_photos"`
+	CanSendVideos         bool `json:"can_send_videos"`
+	CanSendVideoNotes     bool `json:"can_send_video_notes"`
+	CanSendVoiceNotes     bool `json:"can_send_voice_notes"`
+	CanSendMediaMessages  bool `json:"can_send_media_messages"` // Deprecated: replaced by granular media permissions
+	CanSendOtherMessages  bool `json:"can_send_other_messages"`
+	CanAddWebPagePreviews bool `json:"can_add_web_page_previews"`
+	CanSendPolls          bool `json:"can_send_polls"`
+}
+
+// IsAdmin reports if the member is the chat creator or an administrator
+func (m *ChatMember) IsAdmin() bool {
+	return m.Status == ChatMemberCreator || m.Status == ChatMemberAdministrator
+}
+
+// IsInChat reports if the user is currently in the chat, possibly with restrictions
+func (m *ChatMember) IsInChat() bool {
+	switch m.Status {
+	case ChatMemberCreator, ChatMemberAdministrator, ChatMemberMember:
+		return true
+	case ChatMemberRestricted:
+		return m.IsMember
+	}
+	return false
+}
+
+/*
+GetChatAdministrators get a list of administrators in a chat, other bots are not included.
+The creator has all rights, but no rights fields are set for it.
+Use it to check if a user may use admin commands:
+
+	admins, err := client.GetChatAdministrators(tbot.ChatID(m.Chat.ID))
+	...
+	for _, admin := range admins {
+		if admin.User.ID == m.From.ID && (admin.Status == tbot.ChatMemberCreator || admin.CanRestrictMembers) {
+			// allow /ban
+		}
+	}
 */
 func (c *Client) GetChatAdministrators(chatID SendChatID) ([]*ChatMember, error) {
 	req := withChat(chatID)
@@ -1137,17 +1988,36 @@ func (c *Client) GetChatAdministrators(chatID SendChatID) ([]*ChatMember, error)
 }
 
 /*
-GetChatMembersCount returns the number of members in chat
+GetChatMemberCount returns the number of members in chat
 */
-func (c *Client) GetChatMembersCount(chatID SendChatID) (int, error) {
+func (c *Client) GetChatMemberCount(chatID SendChatID) (int, error) {
 	req := withChat(chatID)
 	var count int
-	err := c.doRequest("getChatMembersCount", req, &count)
+	err := c.doRequest("getChatMemberCount", req, &count)
 	return count, err
 }
 
 /*
-GetChatMember get information about a member of a chat
+GetChatMembersCount returns the number of members in chat
+
+Deprecated: use GetChatMemberCount
+*/
+func (c *Client) GetChatMembersCount(chatID SendChatID) (int, error) {
+	return c.GetChatMemberCount(chatID)
+}
+
+/*
+GetChatMember get information about a member of a chat. Check Status to find out
+if the user is still in the chat, e.g. for subscription checks:
+
+	member, err := client.GetChatMember(tbot.ChatName("@mychannel"), userID)
+	...
+	if !member.IsInChat() {
+		// ask to subscribe
+	}
+
+UntilDate is set for restricted and banned members, 0 means forever.
+The bot must be an administrator to get members of channels.
 */
 func (c *Client) GetChatMember(chatID SendChatID, userID int64) (*ChatMember, error) {
 	req := withChat(chatID)
@@ -1201,9 +2071,9 @@ var (
 /*
 AnswerCallbackQuery send answer to callback query sent from inline keyboard. Available options:
 	- OptText(text string)
-	- OptShowAlert
-	- OptURL(url string)
-	- OptCacheTime(d time.Duration)
+	- OptShowAlert - show an alert instead of a notification at the top of the chat screen
+	- OptURL(url string) - URL to be opened by the client, e.g. game URL for callback game buttons
+	- OptCacheTime(d time.Duration) - how long the answer may be cached client-side, rounded down to seconds
 */
 func (c *Client) AnswerCallbackQuery(callbackQueryID string, opts ...sendOption) error {
 	req := url.Values{}
@@ -1218,40 +2088,371 @@ func (c *Client) AnswerCallbackQuery(callbackQueryID string, opts ...sendOption)
 // BotCommand represents a bot command.
 type BotCommand struct {
 	Command     string `json:"command"`     // Text of the command, 1-32 characters. Can contain only lowercase English letters, digits and underscores.
-	Description string `json:"description"` // Description of the command, 3-256 characters.
+	Description string `json:"description"` // Description of the command, 1-256 characters.
+}
+
+// BotCommandScope is a set of chats where bot commands are shown, see OptCommandScope.
+// Telegram shows commands of the most narrow scope matching the chat and the user.
+type BotCommandScope interface {
+	commandScope() botCommandScope
+}
+
+type botCommandScope struct {
+	Type   string `json:"type"`
+	ChatID int64  `json:"chat_id,omitempty"`
+	UserID int64  `json:"user_id,omitempty"`
+}
+
+// BotCommandScopeDefault is used when no commands of a narrower scope are set
+type BotCommandScopeDefault struct{}
+
+// BotCommandScopeAllPrivateChats covers all private chats
+type BotCommandScopeAllPrivateChats struct{}
+
+// BotCommandScopeAllGroupChats covers all group and supergroup chats
+type BotCommandScopeAllGroupChats struct{}
+
+// BotCommandScopeAllChatAdministrators covers administrators of all group and supergroup chats
+type BotCommandScopeAllChatAdministrators struct{}
+
+// BotCommandScopeChat covers the chat
+type BotCommandScopeChat struct {
+	ChatID int64
+}
+
+// BotCommandScopeChatAdministrators covers administrators of the group or supergroup chat
+type BotCommandScopeChatAdministrators struct {
+	ChatID int64
+}
+
+// BotCommandScopeChatMember covers the member of the group or supergroup chat
+type BotCommandScopeChatMember struct {
+	ChatID int64
+	UserID int64
+}
+
+func (BotCommandScopeDefault) commandScope() botCommandScope {
+	return botCommandScope{Type: "default"}
+}
+
+func (BotCommandScopeAllPrivateChats) commandScope() botCommandScope {
+	return botCommandScope{Type: "all_private_chats"}
+}
+
+func (BotCommandScopeAllGroupChats) commandScope() botCommandScope {
+	return botCommandScope{Type: "all_group_chats"}
+}
+
+func (BotCommandScopeAllChatAdministrators) commandScope() botCommandScope {
+	return botCommandScope{Type: "all_chat_administrators"}
 }
 
+func (s BotCommandScopeChat) commandScope() botCommandScope {
+	return botCommandScope{Type: "chat", ChatID: s.ChatID}
+}
+
+func (s BotCommandScopeChatAdministrators) commandScope() botCommandScope {
+	return botCommandScope{Type: "chat_administrators", ChatID: s.ChatID}
+}
+
+func (s BotCommandScopeChatMember) commandScope() botCommandScope {
+	return botCommandScope{Type: "chat_member", ChatID: s.ChatID, UserID: s.UserID}
+}
+
+// SetMyCommands, GetMyCommands and DeleteMyCommands options
+var (
+	// OptCommandScope sets scope of commands, defaults to BotCommandScopeDefault
+	OptCommandScope = func(scope BotCommandScope) sendOption {
+		return func(v url.Values) {
+			v.Set("scope", structString(scope.commandScope()))
+		}
+	}
+	// OptLanguageCode sets two-letter ISO 639-1 language code of users the commands are shown to,
+	// commands without language code are shown to users of all other languages
+	OptLanguageCode = func(code string) sendOption {
+		return func(v url.Values) {
+			v.Set("language_code", code)
+		}
+	}
+)
+
 /*
-GetMyCommands get the current list of bot commands.
+GetMyCommands get the current list of bot commands for the scope and language. Available options:
+	- OptCommandScope(scope BotCommandScope)
+	- OptLanguageCode(code string)
 */
-func (c *Client) GetMyCommands() (*[]BotCommand, error) {
+func (c *Client) GetMyCommands(opts ...sendOption) (*[]BotCommand, error) {
+	req := url.Values{}
+	for _, opt := range opts {
+		opt(req)
+	}
 	botCommands := &[]BotCommand{}
-	err := c.doRequest("getMyCommands", url.Values{}, botCommands)
+	err := c.doRequest("getMyCommands", req, botCommands)
 	return botCommands, err
 }
 
 /*
-SetMyCommands set the list of bot commands.
+SetMyCommands set the list of bot commands for the scope and language. Available options:
+	- OptCommandScope(scope BotCommandScope)
+	- OptLanguageCode(code string)
+
+Show admin commands only to administrators of the group:
+
+	err := client.SetMyCommands(adminCommands, tbot.OptCommandScope(tbot.BotCommandScopeChatAdministrators{ChatID: chatID}))
 */
-func (c *Client) SetMyCommands(commands []BotCommand) error {
+func (c *Client) SetMyCommands(commands []BotCommand, opts ...sendOption) error {
+	for _, command := range commands {
+		if err := checkBotCommand(command); err != nil {
+			return err
+		}
+	}
 	req := url.Values{}
+	for _, opt := range opts {
+		opt(req)
+	}
 	cmd, _ := json.Marshal(commands)
 	req.Set("commands", string(cmd))
 	var set bool
 	return c.doRequest("setMyCommands", req, &set)
 }
 
+/*
+DeleteMyCommands delete the list of bot commands for the scope and language,
+commands of a wider scope are shown instead. Available options:
+	- OptCommandScope(scope BotCommandScope)
+	- OptLanguageCode(code string)
+*/
+func (c *Client) DeleteMyCommands(opts ...sendOption) error {
+	req := url.Values{}
+	for _, opt := range opts {
+		opt(req)
+	}
+	var deleted bool
+	return c.doRequest("deleteMyCommands", req, &deleted)
+}
+
+// MenuButton is the bot's menu button in a private chat:
+// MenuButtonCommands, MenuButtonWebApp or MenuButtonDefault
+type MenuButton interface {
+	menuButton()
+}
+
+// MenuButtonCommands opens the list of bot commands
+type MenuButtonCommands struct{}
+
+// MenuButtonWebApp launches a Web App
+type MenuButtonWebApp struct {
+	Text   string     `json:"text"`
+	WebApp WebAppInfo `json:"web_app"`
+}
+
+// MenuButtonDefault means no specific menu button is set, the default button is used
+type MenuButtonDefault struct{}
+
+func (MenuButtonCommands) menuButton() {}
+func (MenuButtonWebApp) menuButton()   {}
+func (MenuButtonDefault) menuButton()  {}
+
+// MarshalJSON encodes button with "commands" type
+func (b MenuButtonCommands) MarshalJSON() ([]byte, error) {
+	return []byte(`{"type":"commands"}`), nil
+}
+
+// MarshalJSON encodes button with "web_app" type
+func (b MenuButtonWebApp) MarshalJSON() ([]byte, error) {
+	type button MenuButtonWebApp
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		button
+	}{Type: "web_app", button: button(b)})
+}
+
+// MarshalJSON encodes button with "default" type
+func (b MenuButtonDefault) MarshalJSON() ([]byte, error) {
+	return []byte(`{"type":"default"}`), nil
+}
+
+func decodeMenuButton(data []byte) (MenuButton, error) {
+	var b struct {
+		Type   string     `json:"type"`
+		Text   string     `json:"text"`
+		WebApp WebAppInfo `json:"web_app"`
+	}
+	err := json.Unmarshal(data, &b)
+	if err != nil {
+		return nil, err
+	}
+	switch b.Type {
+	case "commands":
+		return MenuButtonCommands{}, nil
+	case "web_app":
+		return MenuButtonWebApp{Text: b.Text, WebApp: b.WebApp}, nil
+	case "default":
+		return MenuButtonDefault{}, nil
+	}
+	return nil, fmt.Errorf("unknown menu button type: %q", b.Type)
+}
+
+// SetChatMenuButton and GetChatMenuButton options
+var (
+	// OptMenuButton sets the menu button, defaults to MenuButtonDefault
+	OptMenuButton = func(button MenuButton) sendOption {
+		return func(v url.Values) {
+			v.Set("menu_button", structString(button))
+		}
+	}
+	// OptMenuChatID sets private chat with the menu button, by default the default button of the bot is used
+	OptMenuChatID = func(chatID int64) sendOption {
+		return func(v url.Values) {
+			v.Set("chat_id", strconv.FormatInt(chatID, 10))
+		}
+	}
+)
+
+/*
+SetChatMenuButton change the bot's menu button in a private chat or the default menu button.
+Available options:
+	- OptMenuButton(button MenuButton)
+	- OptMenuChatID(chatID int64)
+
+Open the store Web App from the menu of the user:
+
+	err := client.SetChatMenuButton(
+		tbot.OptMenuChatID(chatID),
+		tbot.OptMenuButton(tbot.MenuButtonWebApp{Text: "Open store", WebApp: tbot.WebAppInfo{URL: storeURL}}),
+	)
+*/
+func (c *Client) SetChatMenuButton(opts ...sendOption) error {
+	req := url.Values{}
+	for _, opt := range opts {
+		opt(req)
+	}
+	var set bool
+	return c.doRequest("setChatMenuButton", req, &set)
+}
+
+/*
+GetChatMenuButton get the bot's menu button in a private chat or the default menu button.
+Available options:
+	- OptMenuChatID(chatID int64)
+*/
+func (c *Client) GetChatMenuButton(opts ...sendOption) (MenuButton, error) {
+	req := url.Values{}
+	for _, opt := range opts {
+		opt(req)
+	}
+	var button json.RawMessage
+	err := c.doRequest("getChatMenuButton", req, &button)
+	if err != nil {
+		return nil, err
+	}
+	return decodeMenuButton(button)
+}
+
+// Bot profile length limits in characters
+const (
+	MaxBotNameLength             = 64
+	MaxBotDescriptionLength      = 512
+	MaxBotShortDescriptionLength = 120
+)
+
+// setMyText sets bot profile field, empty value is sent to clear the field
+func (c *Client) setMyText(method, field, value string, maxLength int, languageCode string) error {
+	if n := utf8.RuneCountInString(value); n > maxLength {
+		return fmt.Errorf("%s is %d characters long, max is %d", strings.Replace(field, "_", " ", -1), n, maxLength)
+	}
+	req := url.Values{}
+	req.Set(field, value)
+	if languageCode != "" {
+		req.Set("language_code", languageCode)
+	}
+	var set bool
+	return c.doRequest(method, req, &set)
+}
+
+func (c *Client) getMyText(method, field, languageCode string) (string, error) {
+	req := url.Values{}
+	if languageCode != "" {
+		req.Set("language_code", languageCode)
+	}
+	var result map[string]string
+	err := c.doRequest(method, req, &result)
+	return result[field], err
+}
+
+// SetMyName change the bot's name for users with languageCode (two-letter ISO 639-1 code),
+// or for all users without a dedicated name if languageCode is empty. Empty name removes it.
+func (c *Client) SetMyName(name, languageCode string) error {
+	return c.setMyText("setMyName", "name", name, MaxBotNameLength, languageCode)
+}
+
+// GetMyName get the bot's name for languageCode, empty languageCode for the default name
+func (c *Client) GetMyName(languageCode string) (string, error) {
+	return c.getMyText("getMyName", "name", languageCode)
+}
+
+// SetMyDescription change the bot's description shown in the empty chat with the bot,
+// see SetMyName for languageCode. Empty description removes it.
+func (c *Client) SetMyDescription(description, languageCode string) error {
+	return c.setMyText("setMyDescription", "description", description, MaxBotDescriptionLength, languageCode)
+}
+
+// GetMyDescription get the bot's description for languageCode, empty languageCode for the default description
+func (c *Client) GetMyDescription(languageCode string) (string, error) {
+	return c.getMyText("getMyDescription", "description", languageCode)
+}
+
+// SetMyShortDescription change the bot's short description shown on the profile page and
+// sent together with the link when users share the bot, see SetMyName for languageCode.
+// Empty short description removes it.
+func (c *Client) SetMyShortDescription(shortDescription, languageCode string) error {
+	return c.setMyText("setMyShortDescription", "short_description", shortDescription, MaxBotShortDescriptionLength, languageCode)
+}
+
+// GetMyShortDescription get the bot's short description for languageCode,
+// empty languageCode for the default short description
+func (c *Client) GetMyShortDescription(languageCode string) (string, error) {
+	return c.getMyText("getMyShortDescription", "short_description", languageCode)
+}
+
+// Bot command length limits
+const (
+	MaxBotCommandLength            = 32
+	MaxBotCommandDescriptionLength = 256
+)
+
+func checkBotCommand(command BotCommand) error {
+	if len(command.Command) == 0 || len(command.Command) > MaxBotCommandLength {
+		return fmt.Errorf("command %q must be 1-%d characters long", command.Command, MaxBotCommandLength)
+	}
+	for _, r := range command.Command {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '_') {
+			return fmt.Errorf("command %q can contain only lowercase English letters, digits and underscores", command.Command)
+		}
+	}
+	if n := utf8.RuneCountInString(command.Description); n == 0 || n > MaxBotCommandDescriptionLength {
+		return fmt.Errorf("description of command %q must be 1-%d characters long", command.Command, MaxBotCommandDescriptionLength)
+	}
+	return nil
+}
+
 /*
 EditMessageText edit text and game messages sent by the bot. Available options:
 	- OptParseModeHTML
 	- OptParseModeMarkdown
+	- OptEntities(entities []*MessageEntity)
 	- OptDisableWebPagePreview
+	- OptLinkPreviewOptions(options LinkPreviewOptions)
 	- OptInlineKeyboardMarkup(markup *InlineKeyboardMarkup)
+Returns ErrMessageNotModified if the new text and markup are the same as the current ones.
 */
 func (c *Client) EditMessageText(chatID SendChatID, messageID int, text string, opts ...sendOption) (*Message, error) {
 	req := withChat(chatID, opts...)
 	req.Set("message_id", strconv.Itoa(messageID))
 	req.Set("text", text)
+	if err := checkLinkPreview(req); err != nil {
+		return nil, err
+	}
 	msg := &Message{}
 	err := c.doRequest("editMessageText", req, msg)
 	return msg, err
@@ -1261,8 +2462,11 @@ func (c *Client) EditMessageText(chatID SendChatID, messageID int, text string,
 EditInlineMessageText edit text and game messages sent via the bot (for inline bots). Available options:
 	- OptParseModeHTML
 	- OptParseModeMarkdown
+	- OptEntities(entities []*MessageEntity)
 	- OptDisableWebPagePreview
+	- OptLinkPreviewOptions(options LinkPreviewOptions)
 	- OptInlineKeyboardMarkup(markup *InlineKeyboardMarkup)
+Returns ErrMessageNotModified if the new text and markup are the same as the current ones.
 */
 func (c *Client) EditInlineMessageText(inlineMessageID, text string, opts ...sendOption) error {
 	req := url.Values{}
@@ -1271,14 +2475,19 @@ func (c *Client) EditInlineMessageText(inlineMessageID, text string, opts ...sen
 	for _, opt := range opts {
 		opt(req)
 	}
+	if err := checkLinkPreview(req); err != nil {
+		return err
+	}
 	var edited bool
 	return c.doRequest("editMessageText", req, &edited)
 }
 
 /*
-EditMessageCaption edit message caption sent by the bot. Available options:
+EditMessageCaption edit message caption sent by the bot.
+Empty caption removes the current one. Available options:
 	- OptParseModeHTML
 	- OptParseModeMarkdown
+	- OptCaptionEntities(entities []*MessageEntity)
 	- OptInlineKeyboardMarkup(markup *InlineKeyboardMarkup)
 */
 func (c *Client) EditMessageCaption(chatID SendChatID, messageID int, caption string, opts ...sendOption) (*Message, error) {
@@ -1291,9 +2500,11 @@ func (c *Client) EditMessageCaption(chatID SendChatID, messageID int, caption st
 }
 
 /*
-EditInlineMessageCaption edit message caption sent via the bot (for inline bots). Available options:
+EditInlineMessageCaption edit message caption sent via the bot (for inline bots).
+Empty caption removes the current one. Available options:
 	- OptParseModeHTML
 	- OptParseModeMarkdown
+	- OptCaptionEntities(entities []*MessageEntity)
 	- OptInlineKeyboardMarkup(markup *InlineKeyboardMarkup)
 */
 func (c *Client) EditInlineMessageCaption(inlineMessageID, caption string, opts ...sendOption) error {
@@ -1334,7 +2545,9 @@ func (c *Client) EditInlineMessageReplyMarkup(inlineMessageID string, opts ...se
 }
 
 /*
-DeleteMessage delete a message, including service messages
+DeleteMessage delete a message, including service messages. Messages can be deleted only
+if they were sent less than 48 hours ago. Returns ErrMessageNotFound for already deleted messages,
+ErrMessageCantBeDeleted for too old messages and ErrNotEnoughRights if the bot is not an administrator.
 */
 func (c *Client) DeleteMessage(chatID SendChatID, messageID int) error {
 	req := withChat(chatID)
@@ -1344,7 +2557,47 @@ func (c *Client) DeleteMessage(chatID SendChatID, messageID int) error {
 }
 
 /*
-SendStickerFile send .webp file sticker. Available options:
+DeleteMessages delete 1-100 messages in the chat at once. Messages that can't be found
+or can't be deleted are skipped. Errors are the same as for DeleteMessage,
+invalid number of messages is rejected with MessageBatchError before the request.
+*/
+func (c *Client) DeleteMessages(chatID SendChatID, messageIDs []int) error {
+	if err := checkMessageBatch(messageIDs, false); err != nil {
+		return err
+	}
+	req := withChat(chatID)
+	req.Set("message_ids", structString(messageIDs))
+	var deleted bool
+	return c.doRequest("deleteMessages", req, &deleted)
+}
+
+// DeleteBusinessMessages delete 1-100 messages on behalf of the business account,
+// unlike DeleteMessages all messages must be from the same chat
+func (c *Client) DeleteBusinessMessages(businessConnectionID string, messageIDs []int) error {
+	if err := checkMessageBatch(messageIDs, false); err != nil {
+		return err
+	}
+	req := url.Values{}
+	req.Set("business_connection_id", businessConnectionID)
+	req.Set("message_ids", structString(messageIDs))
+	var deleted bool
+	return c.doRequest("deleteBusinessMessages", req, &deleted)
+}
+
+// SendSticker and SendDice options
+var (
+	// OptEmoji sets emoji associated with just uploaded sticker or emoji of the dice
+	OptEmoji = func(emoji string) sendOption {
+		return func(r url.Values) {
+			r.Set("emoji", emoji)
+		}
+	}
+)
+
+/*
+SendSticker sends sticker to chat. Pass file_id or http(s) URL of .webp sticker as a string,
+or *InputFile to upload it. Sent message has Sticker field set. Available options:
+	- OptEmoji(emoji string)
 	- OptDisableNotification
 	- OptReplyToMessageID(id int)
 	- OptInlineKeyboardMarkup(markup *InlineKeyboardMarkup)
@@ -1354,15 +2607,20 @@ SendStickerFile send .webp file sticker. Available options:
 	- OptForceReply
 	- OptForceReplySelective
 */
-func (c *Client) SendStickerFile(chatID SendChatID, filename string, opts ...sendOption) (*Message, error) {
+func (c *Client) SendSticker(chatID SendChatID, sticker interface{}, opts ...sendOption) (*Message, error) {
 	req := withChat(chatID, opts...)
+	files, err := setInputFile(req, "sticker", sticker)
+	if err != nil {
+		return nil, err
+	}
 	msg := &Message{}
-	err := c.doRequestWithFiles("sendSticker", req, msg, inputFile{field: "sticker", name: filename})
+	err = c.doRequestWithFiles("sendSticker", req, msg, files...)
 	return msg, err
 }
 
 /*
-SendSticker send previously uploaded sticker. Available options:
+SendStickerFile send .webp file sticker. Available options:
+	- OptEmoji(emoji string)
 	- OptDisableNotification
 	- OptReplyToMessageID(id int)
 	- OptInlineKeyboardMarkup(markup *InlineKeyboardMarkup)
@@ -1372,12 +2630,32 @@ SendSticker send previously uploaded sticker. Available options:
 	- OptForceReply
 	- OptForceReplySelective
 */
-func (c *Client) SendSticker(chatID SendChatID, fileID string, opts ...sendOption) (*Message, error) {
+func (c *Client) SendStickerFile(chatID SendChatID, filename string, opts ...sendOption) (*Message, error) {
+	return c.SendSticker(chatID, InputFilePath(filename), opts...)
+}
+
+// SetMessageReaction options
+var (
+	// OptBigReaction sets the reaction with a big animation
+	OptBigReaction = func(v url.Values) {
+		v.Set("is_big", "true")
+	}
+)
+
+/*
+SetMessageReaction changes reactions of the bot on a message, pass empty reactions to remove them.
+Bots can't use paid reactions. Available options:
+	- OptBigReaction
+*/
+func (c *Client) SetMessageReaction(chatID SendChatID, messageID int, reactions []ReactionType, opts ...sendOption) error {
 	req := withChat(chatID, opts...)
-	req.Set("sticker", fileID)
-	msg := &Message{}
-	err := c.doRequest("sendSticker", req, msg)
-	return msg, err
+	req.Set("message_id", strconv.Itoa(messageID))
+	if reactions == nil {
+		reactions = []ReactionType{}
+	}
+	req.Set("reaction", structString(reactions))
+	var set bool
+	return c.doRequest("setMessageReaction", req, &set)
 }
 
 // StickerSet represents sticker set
@@ -1385,6 +2663,7 @@ type StickerSet struct {
 	Name          string     `json:"name"`
 	Title         string     `json:"title"`
 	IsAnimated    bool       `json:"is_animated"`
+	IsVideo       bool       `json:"is_video"`
 	ContainsMasks bool       `json:"contains_masks"`
 	Stickers      []Sticker  `json:"stickers"`
 	Thumb         *PhotoSize `json:"thumb"`
@@ -1426,13 +2705,29 @@ var (
 	OptAnimatedSticker = func(v url.Values) {
 		v.Set("tgs_sticker", "true")
 	}
+	// OptVideoSticker marks uploaded sticker file as WEBM video sticker
+	OptVideoSticker = func(v url.Values) {
+		v.Set("webm_sticker", "true")
+	}
 )
 
+// stickerFile returns sticker upload in the field set by OptAnimatedSticker or OptVideoSticker, PNG by default
+func stickerFile(req url.Values, filename string) inputFile {
+	for _, field := range []string{"tgs_sticker", "webm_sticker"} {
+		if req.Get(field) != "" {
+			req.Del(field)
+			return inputFile{field: field, name: filename}
+		}
+	}
+	return inputFile{field: "png_sticker", name: filename}
+}
+
 /*
 CreateNewStickerSetFile creates new sticker set with sticker file. Available options:
 	- OptContainsMasks
 	- OptMaskPosition(pos *MaskPosition)
 	- OptAnimatedSticker
+	- OptVideoSticker
 */
 func (c *Client) CreateNewStickerSetFile(userID int, name, title, stickerFilename, emojis string, opts ...sendOption) error {
 	req := url.Values{}
@@ -1443,15 +2738,8 @@ func (c *Client) CreateNewStickerSetFile(userID int, name, title, stickerFilenam
 	for _, opt := range opts {
 		opt(req)
 	}
-	stickerFile := inputFile{name: stickerFilename}
-	if len(req.Get("tgs_sticker")) > 0 {
-		stickerFile.field = "tgs_sticker"
-		req.Del("tgs_sticker")
-	} else {
-		stickerFile.field = "png_sticker"
-	}
 	var created bool
-	return c.doRequestWithFiles("createNewStickerSet", req, &created, stickerFile)
+	return c.doRequestWithFiles("createNewStickerSet", req, &created, stickerFile(req, stickerFilename))
 }
 
 /*
@@ -1477,6 +2765,7 @@ func (c *Client) CreateNewStickerSet(userID int, name, title, fileID, emojis str
 AddStickerToSetFile add a new sticker file to a set created by the bot. Available options:
 	- OptMaskPosition(pos *MaskPosition)
 	- OptAnimatedSticker
+	- OptVideoSticker
 */
 func (c *Client) AddStickerToSetFile(userID int, name, filename, emojis string, opts ...sendOption) error {
 	req := url.Values{}
@@ -1486,15 +2775,8 @@ func (c *Client) AddStickerToSetFile(userID int, name, filename, emojis string,
 	for _, opt := range opts {
 		opt(req)
 	}
-	stickerFile := inputFile{name: filename}
-	if len(req.Get("tgs_sticker")) > 0 {
-		stickerFile.field = "tgs_sticker"
-		req.Del("tgs_sticker")
-	} else {
-		stickerFile.field = "png_sticker"
-	}
 	var added bool
-	return c.doRequestWithFiles("addStickerToSet", req, &added, stickerFile)
+	return c.doRequestWithFiles("addStickerToSet", req, &added, stickerFile(req, filename))
 }
 
 /*
@@ -1573,8 +2855,8 @@ var (
 // InputTextMessageContent represents the content of a text message to be sent as the result of an inline query
 type InputTextMessageContent struct {
 	MessageText           string `json:"message_text"`
-	ParseMode             string `json:"parse_mode"`
-	DisableWebPagePreview bool   `json:"disable_web_page_preview"`
+	ParseMode             string `json:"parse_mode,omitempty"`
+	DisableWebPagePreview bool   `json:"disable_web_page_preview,omitempty"`
 }
 
 func (InputTextMessageContent) inputMessageContent() {}
@@ -1583,7 +2865,7 @@ func (InputTextMessageContent) inputMessageContent() {}
 type InputLocationMessageContent struct {
 	Latitude   float64 `json:"latitude"`
 	Longitude  float64 `json:"longitude"`
-	LivePeriod int     `json:"live_period"`
+	LivePeriod int     `json:"live_period,omitempty"`
 }
 
 func (InputLocationMessageContent) inputMessageContent() {}
@@ -1594,8 +2876,8 @@ type InputVenueMessageContent struct {
 	Longitude      float64 `json:"longitude"`
 	Title          string  `json:"title"`
 	Address        string  `json:"address"`
-	FoursquareID   string  `json:"foursquare_id"`
-	FoursquareType string  `json:"foursquare_type"`
+	FoursquareID   string  `json:"foursquare_id,omitempty"`
+	FoursquareType string  `json:"foursquare_type,omitempty"`
 }
 
 func (InputVenueMessageContent) inputMessageContent() {}
@@ -1604,15 +2886,16 @@ func (InputVenueMessageContent) inputMessageContent() {}
 type InputContactMessageContent struct {
 	PhoneNumber string `json:"phone_number"`
 	FirstName   string `json:"first_name"`
-	LastName    string `json:"last_name"`
-	VCard       string `json:"vcard"`
+	LastName    string `json:"last_name,omitempty"`
+	VCard       string `json:"vcard,omitempty"`
 }
 
 func (InputContactMessageContent) inputMessageContent() {}
 
-// InlineQueryResult represents one result of an inline query
+// InlineQueryResult represents one result of an inline query.
+// Type field is set on encoding, it can be left empty.
 type InlineQueryResult interface {
-	inlineQueryResult()
+	inlineQueryResult() string
 }
 
 var (
@@ -1653,7 +2936,16 @@ type InlineQueryResultArticle struct {
 	ThumbHeight         int                   `json:"thumb_height,omitempty"`
 }
 
-func (InlineQueryResultArticle) inlineQueryResult() {}
+func (InlineQueryResultArticle) inlineQueryResult() string { return "article" }
+
+// MarshalJSON encodes result with "article" type unless Type is set
+func (r InlineQueryResultArticle) MarshalJSON() ([]byte, error) {
+	type result InlineQueryResultArticle
+	if r.Type == "" {
+		r.Type = r.inlineQueryResult()
+	}
+	return json.Marshal(result(r))
+}
 
 // InlineQueryResultPhoto represents a link to a photo
 type InlineQueryResultPhoto struct {
@@ -1668,10 +2960,19 @@ type InlineQueryResultPhoto struct {
 	Caption             string                `json:"caption,omitempty"`
 	ParseMode           string                `json:"parse_mode,omitempty"`
 	ReplyMarkup         *InlineKeyboardMarkup `json:"reply_markup,omitempty"`
-	InputMessageContent *InputMessageContent  `json:"input_message_content,omitempty"`
+	InputMessageContent InputMessageContent   `json:"input_message_content,omitempty"`
 }
 
-func (InlineQueryResultPhoto) inlineQueryResult() {}
+func (InlineQueryResultPhoto) inlineQueryResult() string { return "photo" }
+
+// MarshalJSON encodes result with "photo" type unless Type is set
+func (r InlineQueryResultPhoto) MarshalJSON() ([]byte, error) {
+	type result InlineQueryResultPhoto
+	if r.Type == "" {
+		r.Type = r.inlineQueryResult()
+	}
+	return json.Marshal(result(r))
+}
 
 // InlineQueryResultGif represents a link to an animated GIF file
 type InlineQueryResultGif struct {
@@ -1686,10 +2987,19 @@ type InlineQueryResultGif struct {
 	Caption             string                `json:"caption,omitempty"`
 	ParseMode           string                `json:"parse_mode,omitempty"`
 	ReplyMarkup         *InlineKeyboardMarkup `json:"reply_markup,omitempty"`
-	InputMessageContent *InputMessageContent  `json:"input_message_content,omitempty"`
+	InputMessageContent InputMessageContent   `json:"input_message_content,omitempty"`
 }
 
-func (InlineQueryResultGif) inlineQueryResult() {}
+func (InlineQueryResultGif) inlineQueryResult() string { return "gif" }
+
+// MarshalJSON encodes result with "gif" type unless Type is set
+func (r InlineQueryResultGif) MarshalJSON() ([]byte, error) {
+	type result InlineQueryResultGif
+	if r.Type == "" {
+		r.Type = r.inlineQueryResult()
+	}
+	return json.Marshal(result(r))
+}
 
 // InlineQueryResultMpeg4Gif represents a link to a video animation (H.264/MPEG-4 AVC video without sound)
 type InlineQueryResultMpeg4Gif struct {
@@ -1704,10 +3014,19 @@ type InlineQueryResultMpeg4Gif struct {
 	Caption             string                `json:"caption,omitempty"`
 	ParseMode           string                `json:"parse_mode,omitempty"`
 	ReplyMarkup         *InlineKeyboardMarkup `json:"reply_markup,omitempty"`
-	InputMessageContent *InputMessageContent  `json:"input_message_content,omitempty"`
+	InputMessageContent InputMessageContent   `json:"input_message_content,omitempty"`
 }
 
-func (InlineQueryResultMpeg4Gif) inlineQueryResult() {}
+func (InlineQueryResultMpeg4Gif) inlineQueryResult() string { return "mpeg4_gif" }
+
+// MarshalJSON encodes result with "mpeg4_gif" type unless Type is set
+func (r InlineQueryResultMpeg4Gif) MarshalJSON() ([]byte, error) {
+	type result InlineQueryResultMpeg4Gif
+	if r.Type == "" {
+		r.Type = r.inlineQueryResult()
+	}
+	return json.Marshal(result(r))
+}
 
 // InlineQueryResultVideo represents a link to a page containing an embedded video player or a video file
 type InlineQueryResultVideo struct {
@@ -1724,10 +3043,19 @@ type InlineQueryResultVideo struct {
 	VideoDuration       int                   `json:"video_duration,omitempty"`
 	Description         string                `json:"description,omitempty"`
 	ReplyMarkup         *InlineKeyboardMarkup `json:"reply_markup,omitempty"`
-	InputMessageContent *InputMessageContent  `json:"input_message_content,omitempty"`
+	InputMessageContent InputMessageContent   `json:"input_message_content,omitempty"`
 }
 
-func (InlineQueryResultVideo) inlineQueryResult() {}
+func (InlineQueryResultVideo) inlineQueryResult() string { return "video" }
+
+// MarshalJSON encodes result with "video" type unless Type is set
+func (r InlineQueryResultVideo) MarshalJSON() ([]byte, error) {
+	type result InlineQueryResultVideo
+	if r.Type == "" {
+		r.Type = r.inlineQueryResult()
+	}
+	return json.Marshal(result(r))
+}
 
 // InlineQueryResultAudio represents a link to an mp3 audio file
 type InlineQueryResultAudio struct {
@@ -1740,10 +3068,19 @@ type InlineQueryResultAudio struct {
 	Performer           string                `json:"performer,omitempty"`
 	AudioDuration       int                   `json:"audio_duration,omitempty"`
 	ReplyMarkup         *InlineKeyboardMarkup `json:"reply_markup,omitempty"`
-	InputMessageContent *InputMessageContent  `json:"input_message_content,omitempty"`
+	InputMessageContent InputMessageContent   `json:"input_message_content,omitempty"`
 }
 
-func (InlineQueryResultAudio) inlineQueryResult() {}
+func (InlineQueryResultAudio) inlineQueryResult() string { return "audio" }
+
+// MarshalJSON encodes result with "audio" type unless Type is set
+func (r InlineQueryResultAudio) MarshalJSON() ([]byte, error) {
+	type result InlineQueryResultAudio
+	if r.Type == "" {
+		r.Type = r.inlineQueryResult()
+	}
+	return json.Marshal(result(r))
+}
 
 // InlineQueryResultVoice represents a link to a voice recording in an .ogg container encoded with OPUS
 type InlineQueryResultVoice struct {
@@ -1756,29 +3093,47 @@ type InlineQueryResultVoice struct {
 	Performer           string                `json:"performer,omitempty"`
 	VoiceDuration       int                   `json:"voice_duration,omitempty"`
 	ReplyMarkup         *InlineKeyboardMarkup `json:"reply_markup,omitempty"`
-	InputMessageContent *InputMessageContent  `json:"input_message_content,omitempty"`
+	InputMessageContent InputMessageContent   `json:"input_message_content,omitempty"`
 }
 
-func (InlineQueryResultVoice) inlineQueryResult() {}
+func (InlineQueryResultVoice) inlineQueryResult() string { return "voice" }
+
+// MarshalJSON encodes result with "voice" type unless Type is set
+func (r InlineQueryResultVoice) MarshalJSON() ([]byte, error) {
+	type result InlineQueryResultVoice
+	if r.Type == "" {
+		r.Type = r.inlineQueryResult()
+	}
+	return json.Marshal(result(r))
+}
 
 // InlineQueryResultDocument represents a link to a file
 type InlineQueryResultDocument struct {
 	Type                string                `json:"type"`
 	ID                  string                `json:"id"`
 	Title               string                `json:"title"`
-	Caption             string                `json:"caption"`
-	ParseMode           string                `json:"parse_mode"`
+	Caption             string                `json:"caption,omitempty"`
+	ParseMode           string                `json:"parse_mode,omitempty"`
 	DocumentURL         string                `json:"document_url"`
 	MimeType            string                `json:"mime_type"`
 	Description         string                `json:"description,omitempty"`
 	ReplyMarkup         *InlineKeyboardMarkup `json:"reply_markup,omitempty"`
-	InputMessageContent *InputMessageContent  `json:"input_message_content,omitempty"`
+	InputMessageContent InputMessageContent   `json:"input_message_content,omitempty"`
 	ThumbURL            string                `json:"thumb_url,omitempty"`
 	ThumbWidth          int                   `json:"thumb_width,omitempty"`
 	ThumbHeight         int                   `json:"thumb_height,omitempty"`
 }
 
-func (InlineQueryResultDocument) inlineQueryResult() {}
+func (InlineQueryResultDocument) inlineQueryResult() string { return "document" }
+
+// MarshalJSON encodes result with "document" type unless Type is set
+func (r InlineQueryResultDocument) MarshalJSON() ([]byte, error) {
+	type result InlineQueryResultDocument
+	if r.Type == "" {
+		r.Type = r.inlineQueryResult()
+	}
+	return json.Marshal(result(r))
+}
 
 // InlineQueryResultLocation represents a location on a map
 type InlineQueryResultLocation struct {
@@ -1789,13 +3144,22 @@ type InlineQueryResultLocation struct {
 	Title               string                `json:"title"`
 	LivePeriod          int                   `json:"live_period,omitempty"`
 	ReplyMarkup         *InlineKeyboardMarkup `json:"reply_markup,omitempty"`
-	InputMessageContent *InputMessageContent  `json:"input_message_content,omitempty"`
+	InputMessageContent InputMessageContent   `json:"input_message_content,omitempty"`
 	ThumbURL            string                `json:"thumb_url,omitempty"`
 	ThumbWidth          int                   `json:"thumb_width,omitempty"`
 	ThumbHeight         int                   `json:"thumb_height,omitempty"`
 }
 
-func (InlineQueryResultLocation) inlineQueryResult() {}
+func (InlineQueryResultLocation) inlineQueryResult() string { return "location" }
+
+// MarshalJSON encodes result with "location" type unless Type is set
+func (r InlineQueryResultLocation) MarshalJSON() ([]byte, error) {
+	type result InlineQueryResultLocation
+	if r.Type == "" {
+		r.Type = r.inlineQueryResult()
+	}
+	return json.Marshal(result(r))
+}
 
 // InlineQueryResultVenue represents a venue
 type InlineQueryResultVenue struct {
@@ -1808,13 +3172,22 @@ type InlineQueryResultVenue struct {
 	FoursquareID        string                `json:"foursquare_id,omitempty"`
 	FoursquareType      string                `json:"foursquare_type,omitempty"`
 	ReplyMarkup         *InlineKeyboardMarkup `json:"reply_markup,omitempty"`
-	InputMessageContent *InputMessageContent  `json:"input_message_content,omitempty"`
+	InputMessageContent InputMessageContent   `json:"input_message_content,omitempty"`
 	ThumbURL            string                `json:"thumb_url,omitempty"`
 	ThumbWidth          int                   `json:"thumb_width,omitempty"`
 	ThumbHeight         int                   `json:"thumb_height,omitempty"`
 }
 
-func (InlineQueryResultVenue) inlineQueryResult() {}
+func (InlineQueryResultVenue) inlineQueryResult() string { return "venue" }
+
+// MarshalJSON encodes result with "venue" type unless Type is set
+func (r InlineQueryResultVenue) MarshalJSON() ([]byte, error) {
+	type result InlineQueryResultVenue
+	if r.Type == "" {
+		r.Type = r.inlineQueryResult()
+	}
+	return json.Marshal(result(r))
+}
 
 // InlineQueryResultContact represents a contact with a phone number
 type InlineQueryResultContact struct {
@@ -1825,13 +3198,22 @@ type InlineQueryResultContact struct {
 	LastName            string                `json:"last_name,omitempty"`
 	VCard               string                `json:"vcard,omitempty"`
 	ReplyMarkup         *InlineKeyboardMarkup `json:"reply_markup,omitempty"`
-	InputMessageContent *InputMessageContent  `json:"input_message_content,omitempty"`
+	InputMessageContent InputMessageContent   `json:"input_message_content,omitempty"`
 	ThumbURL            string                `json:"thumb_url,omitempty"`
 	ThumbWidth          int                   `json:"thumb_width,omitempty"`
 	ThumbHeight         int                   `json:"thumb_height,omitempty"`
 }
 
-func (InlineQueryResultContact) inlineQueryResult() {}
+func (InlineQueryResultContact) inlineQueryResult() string { return "contact" }
+
+// MarshalJSON encodes result with "contact" type unless Type is set
+func (r InlineQueryResultContact) MarshalJSON() ([]byte, error) {
+	type result InlineQueryResultContact
+	if r.Type == "" {
+		r.Type = r.inlineQueryResult()
+	}
+	return json.Marshal(result(r))
+}
 
 // InlineQueryResultGame represents a Game
 type InlineQueryResultGame struct {
@@ -1841,7 +3223,16 @@ type InlineQueryResultGame struct {
 	ReplyMarkup   *InlineKeyboardMarkup `json:"reply_markup,omitempty"`
 }
 
-func (InlineQueryResultGame) inlineQueryResult() {}
+func (InlineQueryResultGame) inlineQueryResult() string { return "game" }
+
+// MarshalJSON encodes result with "game" type unless Type is set
+func (r InlineQueryResultGame) MarshalJSON() ([]byte, error) {
+	type result InlineQueryResultGame
+	if r.Type == "" {
+		r.Type = r.inlineQueryResult()
+	}
+	return json.Marshal(result(r))
+}
 
 // InlineQueryResultCachedPhoto represents a link to a photo stored on the Telegram servers
 type InlineQueryResultCachedPhoto struct {
@@ -1853,10 +3244,19 @@ type InlineQueryResultCachedPhoto struct {
 	Caption             string                `json:"caption,omitempty"`
 	ParseMode           string                `json:"parse_mode,omitempty"`
 	ReplyMarkup         *InlineKeyboardMarkup `json:"reply_markup,omitempty"`
-	InputMessageContent *InputMessageContent  `json:"input_message_content,omitempty"`
+	InputMessageContent InputMessageContent   `json:"input_message_content,omitempty"`
 }
 
-func (InlineQueryResultCachedPhoto) inlineQueryResult() {}
+func (InlineQueryResultCachedPhoto) inlineQueryResult() string { return "photo" }
+
+// MarshalJSON encodes result with "photo" type unless Type is set
+func (r InlineQueryResultCachedPhoto) MarshalJSON() ([]byte, error) {
+	type result InlineQueryResultCachedPhoto
+	if r.Type == "" {
+		r.Type = r.inlineQueryResult()
+	}
+	return json.Marshal(result(r))
+}
 
 // InlineQueryResultCachedGif represents a link to an animated GIF file stored on the Telegram servers
 type InlineQueryResultCachedGif struct {
@@ -1867,10 +3267,19 @@ type InlineQueryResultCachedGif struct {
 	Caption             string                `json:"caption,omitempty"`
 	ParseMode           string                `json:"parse_mode,omitempty"`
 	ReplyMarkup         *InlineKeyboardMarkup `json:"reply_markup,omitempty"`
-	InputMessageContent *InputMessageContent  `json:"input_message_content,omitempty"`
+	InputMessageContent InputMessageContent   `json:"input_message_content,omitempty"`
 }
 
-func (InlineQueryResultCachedGif) inlineQueryResult() {}
+func (InlineQueryResultCachedGif) inlineQueryResult() string { return "gif" }
+
+// MarshalJSON encodes result with "gif" type unless Type is set
+func (r InlineQueryResultCachedGif) MarshalJSON() ([]byte, error) {
+	type result InlineQueryResultCachedGif
+	if r.Type == "" {
+		r.Type = r.inlineQueryResult()
+	}
+	return json.Marshal(result(r))
+}
 
 // InlineQueryResultCachedMpeg4Gif represents a link to a video animation (H.264/MPEG-4 AVC video without sound)
 // stored on the Telegram servers
@@ -1882,10 +3291,19 @@ type InlineQueryResultCachedMpeg4Gif struct {
 	Caption             string                `json:"caption,omitempty"`
 	ParseMode           string                `json:"parse_mode,omitempty"`
 	ReplyMarkup         *InlineKeyboardMarkup `json:"reply_markup,omitempty"`
-	InputMessageContent *InputMessageContent  `json:"input_message_content,omitempty"`
+	InputMessageContent InputMessageContent   `json:"input_message_content,omitempty"`
 }
 
-func (InlineQueryResultCachedMpeg4Gif) inlineQueryResult() {}
+func (InlineQueryResultCachedMpeg4Gif) inlineQueryResult() string { return "mpeg4_gif" }
+
+// MarshalJSON encodes result with "mpeg4_gif" type unless Type is set
+func (r InlineQueryResultCachedMpeg4Gif) MarshalJSON() ([]byte, error) {
+	type result InlineQueryResultCachedMpeg4Gif
+	if r.Type == "" {
+		r.Type = r.inlineQueryResult()
+	}
+	return json.Marshal(result(r))
+}
 
 // InlineQueryResultCachedSticker represents a link to a sticker stored on the Telegram servers
 type InlineQueryResultCachedSticker struct {
@@ -1893,10 +3311,19 @@ type InlineQueryResultCachedSticker struct {
 	ID                  string                `json:"id"`
 	StickerFileID       string                `json:"sticker_file_id"`
 	ReplyMarkup         *InlineKeyboardMarkup `json:"reply_markup,omitempty"`
-	InputMessageContent *InputMessageContent  `json:"input_message_content,omitempty"`
+	InputMessageContent InputMessageContent   `json:"input_message_content,omitempty"`
 }
 
-func (InlineQueryResultCachedSticker) inlineQueryResult() {}
+func (InlineQueryResultCachedSticker) inlineQueryResult() string { return "sticker" }
+
+// MarshalJSON encodes result with "sticker" type unless Type is set
+func (r InlineQueryResultCachedSticker) MarshalJSON() ([]byte, error) {
+	type result InlineQueryResultCachedSticker
+	if r.Type == "" {
+		r.Type = r.inlineQueryResult()
+	}
+	return json.Marshal(result(r))
+}
 
 // InlineQueryResultCachedDocument represents a link to a file
 type InlineQueryResultCachedDocument struct {
@@ -1908,10 +3335,19 @@ type InlineQueryResultCachedDocument struct {
 	Caption             string                `json:"caption,omitempty"`
 	ParseMode           string                `json:"parse_mode,omitempty"`
 	ReplyMarkup         *InlineKeyboardMarkup `json:"reply_markup,omitempty"`
-	InputMessageContent *InputMessageContent  `json:"input_message_content,omitempty"`
+	InputMessageContent InputMessageContent   `json:"input_message_content,omitempty"`
 }
 
-func (InlineQueryResultCachedDocument) inlineQueryResult() {}
+func (InlineQueryResultCachedDocument) inlineQueryResult() string { return "document" }
+
+// MarshalJSON encodes result with "document" type unless Type is set
+func (r InlineQueryResultCachedDocument) MarshalJSON() ([]byte, error) {
+	type result InlineQueryResultCachedDocument
+	if r.Type == "" {
+		r.Type = r.inlineQueryResult()
+	}
+	return json.Marshal(result(r))
+}
 
 // InlineQueryResultCachedVideo represents a link to a video file stored on the Telegram servers
 type InlineQueryResultCachedVideo struct {
@@ -1923,10 +3359,19 @@ type InlineQueryResultCachedVideo struct {
 	Caption             string                `json:"caption,omitempty"`
 	ParseMode           string                `json:"parse_mode,omitempty"`
 	ReplyMarkup         *InlineKeyboardMarkup `json:"reply_markup,omitempty"`
-	InputMessageContent *InputMessageContent  `json:"input_message_content,omitempty"`
+	InputMessageContent InputMessageContent   `json:"input_message_content,omitempty"`
 }
 
-func (InlineQueryResultCachedVideo) inlineQueryResult() {}
+func (InlineQueryResultCachedVideo) inlineQueryResult() string { return "video" }
+
+// MarshalJSON encodes result with "video" type unless Type is set
+func (r InlineQueryResultCachedVideo) MarshalJSON() ([]byte, error) {
+	type result InlineQueryResultCachedVideo
+	if r.Type == "" {
+		r.Type = r.inlineQueryResult()
+	}
+	return json.Marshal(result(r))
+}
 
 // InlineQueryResultCachedVoice represents a link to a voice recording in an .ogg container encoded with OPUS
 type InlineQueryResultCachedVoice struct {
@@ -1937,10 +3382,19 @@ type InlineQueryResultCachedVoice struct {
 	Caption             string                `json:"caption,omitempty"`
 	ParseMode           string                `json:"parse_mode,omitempty"`
 	ReplyMarkup         *InlineKeyboardMarkup `json:"reply_markup,omitempty"`
-	InputMessageContent *InputMessageContent  `json:"input_message_content,omitempty"`
+	InputMessageContent InputMessageContent   `json:"input_message_content,omitempty"`
 }
 
-func (InlineQueryResultCachedVoice) inlineQueryResult() {}
+func (InlineQueryResultCachedVoice) inlineQueryResult() string { return "voice" }
+
+// MarshalJSON encodes result with "voice" type unless Type is set
+func (r InlineQueryResultCachedVoice) MarshalJSON() ([]byte, error) {
+	type result InlineQueryResultCachedVoice
+	if r.Type == "" {
+		r.Type = r.inlineQueryResult()
+	}
+	return json.Marshal(result(r))
+}
 
 // InlineQueryResultCachedAudio represents a link to an mp3 audio file
 type InlineQueryResultCachedAudio struct {
@@ -1950,10 +3404,19 @@ type InlineQueryResultCachedAudio struct {
 	Caption             string                `json:"caption,omitempty"`
 	ParseMode           string                `json:"parse_mode,omitempty"`
 	ReplyMarkup         *InlineKeyboardMarkup `json:"reply_markup,omitempty"`
-	InputMessageContent *InputMessageContent  `json:"input_message_content,omitempty"`
+	InputMessageContent InputMessageContent   `json:"input_message_content,omitempty"`
 }
 
-func (InlineQueryResultCachedAudio) inlineQueryResult() {}
+func (InlineQueryResultCachedAudio) inlineQueryResult() string { return "audio" }
+
+// MarshalJSON encodes result with "audio" type unless Type is set
+func (r InlineQueryResultCachedAudio) MarshalJSON() ([]byte, error) {
+	type result InlineQueryResultCachedAudio
+	if r.Type == "" {
+		r.Type = r.inlineQueryResult()
+	}
+	return json.Marshal(result(r))
+}
 
 // AnswerInlineQuery options
 var (
@@ -1977,18 +3440,28 @@ var (
 	}
 )
 
+// MaxInlineQueryResults is the maximum number of results in the answer to an inline query
+const MaxInlineQueryResults = 50
+
 /*
-AnswerInlineQuery send answer to an inline query. No more than 50 results per query are allowed. Available Options:
-	- OptCacheTime(d *time.Duration)
+AnswerInlineQuery send answer to an inline query. No more than 50 results per query are allowed.
+Type of the results is set automatically. Available Options:
+	- OptCacheTime(d time.Duration)
 	- OptIsPersonal
 	- OptNextOffset(offset string)
 	- OptSwitchPmText(text string)
 	- OptSwitchPmParameter(param string)
 */
 func (c *Client) AnswerInlineQuery(inlineQueryID string, results []InlineQueryResult, opts ...sendOption) error {
+	if len(results) > MaxInlineQueryResults {
+		return fmt.Errorf("number of inline query results must be at most %d, got %d", MaxInlineQueryResults, len(results))
+	}
 	req := url.Values{}
 	req.Set("inline_query_id", inlineQueryID)
-	res, _ := json.Marshal(results)
+	res, err := json.Marshal(results)
+	if err != nil {
+		return fmt.Errorf("unable to encode inline query results: %v", err)
+	}
 	req.Set("results", string(res))
 	for _, opt := range opts {
 		opt(req)
@@ -1997,6 +3470,26 @@ func (c *Client) AnswerInlineQuery(inlineQueryID string, results []InlineQueryRe
 	return c.doRequest("answerInlineQuery", req, &answered)
 }
 
+// SentWebAppMessage describes an inline message sent by a Web App on behalf of a user
+type SentWebAppMessage struct {
+	InlineMessageID string `json:"inline_message_id"` // set if the message has inline keyboard
+}
+
+// AnswerWebAppQuery send the result of interaction with a Web App to the chat the query came from.
+// Type of the result is set automatically, see AnswerInlineQuery.
+func (c *Client) AnswerWebAppQuery(webAppQueryID string, result InlineQueryResult) (*SentWebAppMessage, error) {
+	res, err := json.Marshal(result)
+	if err != nil {
+		return nil, fmt.Errorf("unable to encode web app query result: %v", err)
+	}
+	req := url.Values{}
+	req.Set("web_app_query_id", webAppQueryID)
+	req.Set("result", string(res))
+	msg := &SentWebAppMessage{}
+	err = c.doRequest("answerWebAppQuery", req, msg)
+	return msg, err
+}
+
 // LabeledPrice represents a portion of the price for goods or services
 type LabeledPrice struct {
 	Label  string `json:"label"`
@@ -2037,10 +3530,33 @@ var (
 	OptSendPhoneNumberToProvider = func(v url.Values) { v.Set("send_phone_number_to_provider", "true") }
 	OptSendEmailToProvider       = func(v url.Values) { v.Set("send_email_to_provider", "true") }
 	OptIsFlexible                = func(v url.Values) { v.Set("is_flexible", "true") }
+	// OptMaxTipAmount sets maximum accepted amount for tips in the smallest units of the currency
+	OptMaxTipAmount = func(amount int) sendOption {
+		return func(v url.Values) {
+			v.Set("max_tip_amount", strconv.Itoa(amount))
+		}
+	}
+	// OptSuggestedTipAmounts sets up to 4 increasing suggested amounts of tips in the smallest units of the currency
+	OptSuggestedTipAmounts = func(amounts []int) sendOption {
+		return func(v url.Values) {
+			v.Set("suggested_tip_amounts", structString(amounts))
+		}
+	}
+	// OptStartParameter sets deep-linking parameter, forwarded copies of the invoice
+	// will have Pay button leading to the bot with this start parameter
+	OptStartParameter = func(param string) sendOption {
+		return func(v url.Values) {
+			v.Set("start_parameter", param)
+		}
+	}
 )
 
 /*
-SendInvoice send invoices. Available Options:
+SendInvoice sends invoice for payment. Amounts of prices are in the smallest units of the currency.
+Available Options:
+	- OptMaxTipAmount(amount int)
+	- OptSuggestedTipAmounts(amounts []int)
+	- OptStartParameter(param string)
 	- OptProviderData(data string)
 	- OptPhotoURL(u string)
 	- OptPhotoSize(size int)
@@ -2057,23 +3573,53 @@ SendInvoice send invoices. Available Options:
 	- OptReplyToMessageID(id int)
 	- OptInlineKeyboardMarkup(markup *InlineKeyboardMarkup)
 */
-func (c *Client) SendInvoice(chatID, payload, providerToken string, invoice *Invoice, prices []LabeledPrice, opts ...sendOption) (*Message, error) {
+func (c *Client) SendInvoice(chatID SendChatID, title, description, payload, providerToken, currency string,
+	prices []LabeledPrice, opts ...sendOption) (*Message, error) {
+	req := withChat(chatID, opts...)
+	req.Set("title", title)
+	req.Set("description", description)
+	req.Set("payload", payload)
+	req.Set("provider_token", providerToken)
+	req.Set("currency", currency)
+	req.Set("prices", structString(prices))
+	msg := &Message{}
+	err := c.doRequest("sendInvoice", req, msg)
+	return msg, err
+}
+
+/*
+CreateInvoiceLink creates a link for an invoice, e.g. to use it on a website or in inline button URL.
+Amounts of prices are in the smallest units of the currency. Available Options:
+	- OptMaxTipAmount(amount int)
+	- OptSuggestedTipAmounts(amounts []int)
+	- OptProviderData(data string)
+	- OptPhotoURL(u string)
+	- OptPhotoSize(size int)
+	- OptPhotoWidth(width int)
+	- OptPhotoHeight(height int)
+	- OptNeedName
+	- OptNeedPhoneNumber
+	- OptNeedEmail
+	- OptNeedShippingAddress
+	- OptSendPhoneNumberToProvider
+	- OptSendEmailToProvider
+	- OptIsFlexible
+*/
+func (c *Client) CreateInvoiceLink(title, description, payload, providerToken, currency string,
+	prices []LabeledPrice, opts ...sendOption) (string, error) {
 	req := url.Values{}
-	req.Set("chat_id", chatID)
-	req.Set("title", invoice.Title)
-	req.Set("description", invoice.Description)
+	req.Set("title", title)
+	req.Set("description", description)
 	req.Set("payload", payload)
 	req.Set("provider_token", providerToken)
-	req.Set("start_parameter", invoice.StartParameter)
-	req.Set("currency", invoice.Currency)
-	pr, _ := json.Marshal(prices)
-	req.Set("prices", string(pr))
+	req.Set("currency", currency)
+	req.Set("prices", structString(prices))
 	for _, opt := range opts {
 		opt(req)
 	}
-	msg := &Message{}
-	err := c.doRequest("sendInvoice", req, msg)
-	return msg, err
+	var link string
+	err := c.doRequest("createInvoiceLink", req, &link)
+	return link, err
 }
 
 // ShippingOption represents one shipping option
@@ -2099,7 +3645,10 @@ var (
 )
 
 /*
-AnswerShippingQuery reply to shipping queries. Available options:
+AnswerShippingQuery reply to shipping queries, it should be done within 10 seconds.
+Pass ok with OptShippingOptions if delivery to the address is possible,
+otherwise pass not ok with OptErrorMessage explaining the reason to the user.
+Error is returned without request for other combinations. Available options:
 	- OptShippingOptions(options []ShippingOption)
 	- OptErrorMessage(msg string)
 */
@@ -2110,12 +3659,22 @@ func (c *Client) AnswerShippingQuery(shippingQueryID string, ok bool, opts ...se
 	for _, opt := range opts {
 		opt(req)
 	}
+	hasOptions, hasError := req.Get("shipping_options") != "", req.Get("error_message") != ""
+	if ok && (!hasOptions || hasError) {
+		return fmt.Errorf("successful shipping answer requires shipping options and no error message")
+	}
+	if !ok && (!hasError || hasOptions) {
+		return fmt.Errorf("failed shipping answer requires error message and no shipping options")
+	}
 	var answered bool
 	return c.doRequest("answerShippingQuery", req, &answered)
 }
 
 /*
-AnswerPreCheckoutQuery respond to pre-checkout queries. Available options:
+AnswerPreCheckoutQuery respond to pre-checkout queries, it should be done within 10 seconds.
+Pass ok to proceed with the order, or not ok with OptErrorMessage explaining
+the reason to the user, error is returned without request if the message is missing.
+Available options:
 	- OptErrorMessage(msg string)
 */
 func (c *Client) AnswerPreCheckoutQuery(preCheckoutQueryID string, ok bool, opts ...sendOption) error {
@@ -2125,6 +3684,9 @@ func (c *Client) AnswerPreCheckoutQuery(preCheckoutQueryID string, ok bool, opts
 	for _, opt := range opts {
 		opt(req)
 	}
+	if !ok && req.Get("error_message") == "" {
+		return fmt.Errorf("declined pre-checkout query requires error message")
+	}
 	var answered bool
 	return c.doRequest("answerPreCheckoutQuery", req, &answered)
 }
@@ -2217,18 +3779,30 @@ func (c *Client) SetPassportDataErrors(userID int, errors []PassportElementError
 }
 
 /*
-SendGame send a game. Available options:
+SendGame send a game. Pressing the game button sends callback query with GameShortName,
+answer it with OptURL of the game to launch it:
+
+	bot.HandleCallback(func(cq *tbot.CallbackQuery) {
+		if cq.GameShortName != "" {
+			client.AnswerCallbackQuery(cq.ID, tbot.OptURL("https://example.com/games/"+cq.GameShortName))
+		}
+	})
+
+Custom keyboard set with OptInlineKeyboardMarkup must start with the game button:
+
+	markup := &tbot.InlineKeyboardMarkup{InlineKeyboard: [][]tbot.InlineKeyboardButton{
+		{{Text: "Play tetris", CallbackGame: &tbot.CallbackGame{}}},
+		{{Text: "Rules", URL: "https://example.com/games/tetris/rules"}},
+	}}
+
+Available options:
 	- OptDisableNotification
 	- OptReplyToMessageID(id int)
 	- OptInlineKeyboardMarkup(markup *InlineKeyboardMarkup)
 */
-func (c *Client) SendGame(chatID, gameShortName string, opts ...sendOption) (*Message, error) {
-	req := url.Values{}
-	req.Set("chat_id", chatID)
+func (c *Client) SendGame(chatID SendChatID, gameShortName string, opts ...sendOption) (*Message, error) {
+	req := withChat(chatID, opts...)
 	req.Set("game_short_name", gameShortName)
-	for _, opt := range opts {
-		opt(req)
-	}
 	msg := &Message{}
 	err := c.doRequest("sendGame", req, msg)
 	return msg, err
@@ -2236,48 +3810,68 @@ func (c *Client) SendGame(chatID, gameShortName string, opts ...sendOption) (*Me
 
 // SetGameScore options
 var (
+	// OptForce allows to decrease the score, e.g. to fix mistakes or ban cheaters
 	OptForce = func(v url.Values) {
 		v.Set("force", "true")
 	}
+	// OptDisableEditMessage keeps the game message without the scoreboard update
 	OptDisableEditMessage = func(v url.Values) {
 		v.Set("disable_edit_message", "true")
 	}
 )
 
-/*
-SetGameScore set the score of the specified user in a game. Available options:
-	- OptForce
-	- OptDisableEditMessage
-*/
-func (c *Client) SetGameScore(chatID string, messageID, userID, score int, opts ...sendOption) (*Message, error) {
+// Game message options of SetGameScore and GetGameHighScores, exactly one of them is required
+var (
+	// OptGameMessage sets the game message sent to the chat by the bot
+	OptGameMessage = func(chatID SendChatID, messageID int) sendOption {
+		return func(v url.Values) {
+			v.Set("chat_id", chatID.asChatID())
+			v.Set("message_id", strconv.Itoa(messageID))
+		}
+	}
+	// OptInlineMessageID sets the game message sent via the bot in inline mode
+	OptInlineMessageID = func(inlineMessageID string) sendOption {
+		return func(v url.Values) {
+			v.Set("inline_message_id", inlineMessageID)
+		}
+	}
+)
+
+func gameRequest(userID int64, opts []sendOption) (url.Values, error) {
 	req := url.Values{}
-	req.Set("chat_id", chatID)
-	req.Set("message_id", fmt.Sprint(messageID))
-	req.Set("user_id", fmt.Sprint(userID))
-	req.Set("score", fmt.Sprint(score))
 	for _, opt := range opts {
 		opt(req)
 	}
-	msg := &Message{}
-	err := c.doRequest("setGameScore", req, msg)
-	return msg, err
+	req.Set("user_id", fmt.Sprint(userID))
+	if (req.Get("chat_id") != "") == (req.Get("inline_message_id") != "") {
+		return nil, fmt.Errorf("game message is required, use either OptGameMessage or OptInlineMessageID")
+	}
+	return req, nil
 }
 
 /*
-SetInlineGameScore set the score of the specified user in a game (for inline messages). Available options:
+SetGameScore set the score of the specified user in a game. Returns error if the new score
+is not greater than the current one, unless OptForce is set. Returns edited game message,
+or nil for inline messages. Available options:
+	- OptGameMessage(chatID SendChatID, messageID int) or OptInlineMessageID(id string), required
 	- OptForce
 	- OptDisableEditMessage
 */
-func (c *Client) SetInlineGameScore(inlineMessageID string, userID, score int, opts ...sendOption) error {
-	req := url.Values{}
-	req.Set("inline_message_id", inlineMessageID)
-	req.Set("user_id", fmt.Sprint(userID))
+func (c *Client) SetGameScore(userID int64, score int, opts ...sendOption) (*Message, error) {
+	req, err := gameRequest(userID, opts)
+	if err != nil {
+		return nil, err
+	}
 	req.Set("score", fmt.Sprint(score))
-	for _, opt := range opts {
-		opt(req)
+	// result is True for inline messages
+	var result json.RawMessage
+	err = c.doRequest("setGameScore", req, &result)
+	if err != nil || req.Get("inline_message_id") != "" {
+		return nil, err
 	}
-	var set bool
-	return c.doRequest("setGameScore", req, &set)
+	msg := &Message{}
+	err = json.Unmarshal(result, msg)
+	return msg, err
 }
 
 // GameHighScore represents one row of the high scores table for a game
@@ -2288,27 +3882,17 @@ type GameHighScore struct {
 }
 
 /*
-GetGameHighScores get data for high score tables
-*/
-func (c *Client) GetGameHighScores(chatID string, messageID, userID int) ([]*GameHighScore, error) {
-	req := url.Values{}
-	req.Set("chat_id", chatID)
-	req.Set("message_id", fmt.Sprint(messageID))
-	req.Set("user_id", fmt.Sprint(userID))
-	var scores []*GameHighScore
-	err := c.doRequest("getGameHighScores", req, &scores)
-	return scores, err
-}
-
-/*
-GetInlineGameHighScores get data for high score tables
+GetGameHighScores get data for high score tables: scores of the user and several
+of their neighbors in the game. Available options:
+	- OptGameMessage(chatID SendChatID, messageID int) or OptInlineMessageID(id string), required
 */
-func (c *Client) GetInlineGameHighScores(inlineMessageID string, userID int) ([]*GameHighScore, error) {
-	req := url.Values{}
-	req.Set("inline_message_id", inlineMessageID)
-	req.Set("user_id", fmt.Sprint(userID))
+func (c *Client) GetGameHighScores(userID int64, opts ...sendOption) ([]*GameHighScore, error) {
+	req, err := gameRequest(userID, opts)
+	if err != nil {
+		return nil, err
+	}
 	var scores []*GameHighScore
-	err := c.doRequest("getGameHighScores", req, &scores)
+	err = c.doRequest("getGameHighScores", req, &scores)
 	return scores, err
 }
 
@@ -2342,14 +3926,59 @@ var (
 	OptClosedPoll = func(u url.Values) {
 		u.Set("is_closed", "true")
 	}
+	// OptExplanation sets text shown when user chooses an incorrect answer in a quiz, 0-200 characters
+	OptExplanation = func(explanation string) sendOption {
+		return func(u url.Values) {
+			u.Set("explanation", explanation)
+		}
+	}
+	OptExplanationParseModeHTML = func(u url.Values) {
+		u.Set("explanation_parse_mode", "HTML")
+	}
+	OptExplanationParseModeMarkdown = func(u url.Values) {
+		u.Set("explanation_parse_mode", "MarkdownV2")
+	}
+	// OptOpenPeriod sets time the poll will be active after creation, 5-600 seconds
+	OptOpenPeriod = func(period time.Duration) sendOption {
+		return func(u url.Values) {
+			u.Set("open_period", strconv.Itoa(int(period.Seconds())))
+		}
+	}
+	// OptCloseDate sets time when the poll will be automatically closed, 5-600 seconds in the future
+	OptCloseDate = func(date time.Time) sendOption {
+		return func(u url.Values) {
+			u.Set("close_date", strconv.FormatInt(date.Unix(), 10))
+		}
+	}
 )
 
+func checkQuiz(req url.Values, options []string) error {
+	if req.Get("type") != string(PollTypeQuiz) {
+		return nil
+	}
+	if req.Get("correct_option_id") == "" {
+		return fmt.Errorf("quiz requires correct option, use OptCorrectOptionID")
+	}
+	id, err := strconv.Atoi(req.Get("correct_option_id"))
+	if err != nil || id < 0 || id >= len(options) {
+		return fmt.Errorf("correct option id %s is out of range for %d options", req.Get("correct_option_id"), len(options))
+	}
+	return nil
+}
+
 /*
-SendPoll sends native telegram poll. Available Options:
+SendPoll sends native telegram poll. Sent message has Poll field set, its ID identifies
+the poll in later Poll updates. Quiz must have correct option set, error is returned otherwise.
+Available Options:
 	- OptNotAnonymous
 	- OptPollType(pollType PollType)
 	- OptAllowMultipleAnswers
 	- OptCorrectOptionID(id int)
+	- OptExplanation(explanation string)
+	- OptExplanationParseModeHTML
+	- OptExplanationParseModeMarkdown
+	- OptOpenPeriod(period time.Duration)
+	- OptCloseDate(date time.Time)
 	- OptClosedPoll
 	- OptDisableNotification
 	- OptReplyToMessageID(id int)
@@ -2369,13 +3998,30 @@ func (c *Client) SendPoll(chatID SendChatID, question string, options []string,
 	for _, opt := range opts {
 		opt(req)
 	}
+	err := checkQuiz(req, options)
+	if err != nil {
+		return nil, err
+	}
 	msg := &Message{}
-	err := c.doRequest("sendPoll", req, msg)
+	err = c.doRequest("sendPoll", req, msg)
 	return msg, err
 }
 
+// Emoji for SendDice, dice values are 1-6 for DiceEmojiDice, DiceEmojiDarts and DiceEmojiBowling,
+// 1-5 for DiceEmojiBasketball and DiceEmojiFootball, 1-64 for DiceEmojiSlotMachine
+const (
+	DiceEmojiDice        = "🎲"
+	DiceEmojiDarts       = "🎯"
+	DiceEmojiBasketball  = "🏀"
+	DiceEmojiFootball    = "⚽"
+	DiceEmojiBowling     = "🎳"
+	DiceEmojiSlotMachine = "🎰"
+)
+
 /*
-SendDice sends native telegram dice. Available Options:
+SendDice sends native telegram dice with random value, read it from Dice field of the returned message.
+Available Options:
+	- OptEmoji(emoji string), one of DiceEmoji* constants, defaults to DiceEmojiDice
 	- OptDisableNotification
 	- OptReplyToMessageID(id int)
 	- OptInlineKeyboardMarkup(markup *InlineKeyboardMarkup)
@@ -2385,68 +4031,219 @@ SendDice sends native telegram dice. Available Options:
 	- OptForceReply
 	- OptForceReplySelective
 */
-func (c *Client) SendDice(chatID string, emoji string, opts ...sendOption) (*Dice, error) {
-	req := url.Values{}
-	req.Set("chat_id", chatID)
-	req.Set("emoji", emoji)
-	for _, opt := range opts {
-		opt(req)
-	}
-	dice := &Dice{}
-	err := c.doRequest("sendDice", req, dice)
-	return dice, err
+func (c *Client) SendDice(chatID SendChatID, opts ...sendOption) (*Message, error) {
+	req := withChat(chatID, opts...)
+	msg := &Message{}
+	err := c.doRequest("sendDice", req, msg)
+	return msg, err
 }
 
 /*
-StopPoll stops poll. Available Options:
+StopPoll stops poll sent by the bot and returns it with final results. Available Options:
 	- OptInlineKeyboardMarkup(markup *InlineKeyboardMarkup)
 */
-func (c *Client) StopPoll(chatID string, messageID string, opts ...sendOption) (*Poll, error) {
-	req := url.Values{}
-	req.Set("chat_id", chatID)
-	req.Set("message_id", messageID)
-	for _, opt := range opts {
-		opt(req)
-	}
+func (c *Client) StopPoll(chatID SendChatID, messageID int, opts ...sendOption) (*Poll, error) {
+	req := withChat(chatID, opts...)
+	req.Set("message_id", strconv.Itoa(messageID))
 	poll := &Poll{}
 	err := c.doRequest("stopPoll", req, poll)
 	return poll, err
 }
 
+// MaxCustomTitleLength is the maximum length of administrator custom title in characters
+const MaxCustomTitleLength = 16
+
+func checkCustomTitle(title string) error {
+	if n := utf8.RuneCountInString(title); n > MaxCustomTitleLength {
+		return fmt.Errorf("custom title is %d characters long, max is %d", n, MaxCustomTitleLength)
+	}
+	for _, r := range title {
+		if isEmoji(r) {
+			return fmt.Errorf("custom title can't contain emoji, got %q", r)
+		}
+	}
+	return nil
+}
+
+// isEmoji reports if r is a symbol or a part of emoji sequence:
+// zero width joiner, variation selector, skin tone modifier or keycap
+func isEmoji(r rune) bool {
+	return unicode.Is(unicode.So, r) || r == 0x200D || r == 0xFE0F || r == 0x20E3 || (r >= 0x1F3FB && r <= 0x1F3FF)
+}
+
 /*
 SetChatAdministratorCustomTitle set a custom title for an administrator in a supergroup promoted by the bot.
+Title is 0-16 characters, emoji are not allowed. Empty title removes the custom title.
 */
-func (c *Client) SetChatAdministratorCustomTitle(chatID string, userID string, customTitle string) error {
-	req := url.Values{}
-	req.Set("chat_id", chatID)
-	req.Set("user_id", userID)
+func (c *Client) SetChatAdministratorCustomTitle(chatID SendChatID, userID int64, customTitle string) error {
+	if err := checkCustomTitle(customTitle); err != nil {
+		return err
+	}
+	req := withChat(chatID)
+	req.Set("user_id", strconv.FormatInt(userID, 10))
 	req.Set("custom_title", customTitle)
 	var set bool
 	return c.doRequest("setChatAdministratorCustomTitle", req, &set)
 }
 
 // ChatPermissions describes actions that a non-administrator user is allowed to take in a chat.
+// Use NewChatPermissions to build permissions allowing only listed actions.
 type ChatPermissions struct {
-	CanSendMessages       bool `json:"can_send_messages,omitempty"`         // True, if the user is allowed to send text messages, contacts, locations and venues
-	CanSendMediaMessages  bool `json:"can_send_media_messages,omitempty"`   // True, if the user is allowed to send audios, documents, photos, videos, video notes and voice notes, implies can_send_messages
-	CanSendPolls          bool `json:"can_send_polls,omitempty"`            // True, if the user is allowed to send polls, implies can_send_messages
-	CanSendOtherMessages  bool `json:"can_send_other_messages,omitempty"`   // True, if the user is allowed to send animations, games, stickers and use inline bots, implies can_send_media_messages
-	CanAddWebPagePreviews bool `json:"can_add_web_page_previews,omitempty"` // True, if the user is allowed to add web page previews to their messages, implies can_send_media_messages
-	CanChangeInfo         bool `json:"can_change_info,omitempty"`           // True, if the user is allowed to change the chat title, photo and other settings. Ignored in public supergroups
-	CanInviteUsers        bool `json:"can_invite_users,omitempty"`          // True, if the user is allowed to invite new users to the chat
-	CanPinMessages        bool `json:"can_pin_messages,omitempty"`          // True, if the user is allowed to pin messages. Ignored in public supergroups
+	CanSendMessages       bool `json:"can_send_messages"`         // True, if the user is allowed to send text messages, contacts, locations and venues
+	CanSendAudios         bool `json:"can_send_audios"`           // True, if the user is allowed to send audios
+	CanSendDocuments      bool `json:"can_send_documents"`        // True, if the user is allowed to send documents
+	CanSendPhotos         bool `json:"can_send_photos"`           // True, if the user is allowed to send photos
+	CanSendVideos         bool `json:"can_send_videos"`           // True, if the user is allowed to send videos
+	CanSendVideoNotes     bool `json:"can_send_video_notes"`      // True, if the user is allowed to send video notes
+	CanSendVoiceNotes     bool `json:"can_send_voice_notes"`      // True, if the user is allowed to send voice notes
+	CanSendPolls          bool `json:"can_send_polls"`            // True, if the user is allowed to send polls
+	CanSendOtherMessages  bool `json:"can_send_other_messages"`   // True, if the user is allowed to send animations, games, stickers and use inline bots
+	CanAddWebPagePreviews bool `json:"can_add_web_page_previews"` // True, if the user is allowed to add web page previews to their messages
+	CanChangeInfo         bool `json:"can_change_info"`           // True, if the user is allowed to change the chat title, photo and other settings. Ignored in public supergroups
+	CanInviteUsers        bool `json:"can_invite_users"`          // True, if the user is allowed to invite new users to the chat
+	CanPinMessages        bool `json:"can_pin_messages"`          // True, if the user is allowed to pin messages. Ignored in public supergroups
+	CanManageTopics       bool `json:"can_manage_topics"`         // True, if the user is allowed to create forum topics
+
+	// Deprecated: replaced by granular CanSend* media permissions
+	CanSendMediaMessages bool `json:"can_send_media_messages,omitempty"`
+}
+
+/*
+NewChatPermissions returns permissions with all actions disallowed,
+use Allow* methods to allow them. For example, allow only text and photos:
+	tbot.NewChatPermissions().AllowSendMessages().AllowSendPhotos()
+*/
+func NewChatPermissions() *ChatPermissions {
+	return &ChatPermissions{}
+}
+
+/*
+MutePermissions returns permissions disallowing to send anything, e.g. mute a user for 10 minutes:
+
+	client.RestrictChatMember(chatID, userID, tbot.MutePermissions(), tbot.OptUntilDate(time.Now().Add(10*time.Minute)))
+*/
+func MutePermissions() *ChatPermissions {
+	return NewChatPermissions()
+}
+
+// AllowSendMessages allows to send text messages, contacts, locations and venues
+func (p *ChatPermissions) AllowSendMessages() *ChatPermissions {
+	p.CanSendMessages = true
+	return p
+}
+
+// AllowSendAudios allows to send audios
+func (p *ChatPermissions) AllowSendAudios() *ChatPermissions {
+	p.CanSendAudios = true
+	return p
+}
+
+// AllowSendDocuments allows to send documents
+func (p *ChatPermissions) AllowSendDocuments() *ChatPermissions {
+	p.CanSendDocuments = true
+	return p
+}
+
+// AllowSendPhotos allows to send photos
+func (p *ChatPermissions) AllowSendPhotos() *ChatPermissions {
+	p.CanSendPhotos = true
+	return p
 }
 
+// AllowSendVideos allows to send videos
+func (p *ChatPermissions) AllowSendVideos() *ChatPermissions {
+	p.CanSendVideos = true
+	return p
+}
+
+// AllowSendVideoNotes allows to send video notes
+func (p *ChatPermissions) AllowSendVideoNotes() *ChatPermissions {
+	p.CanSendVideoNotes = true
+	return p
+}
+
+// AllowSendVoiceNotes allows to send voice notes
+func (p *ChatPermissions) AllowSendVoiceNotes() *ChatPermissions {
+	p.CanSendVoiceNotes = true
+	return p
+}
+
+// AllowSendMedia allows to send audios, documents, photos, videos, video notes and voice notes
+func (p *ChatPermissions) AllowSendMedia() *ChatPermissions {
+	return p.AllowSendAudios().AllowSendDocuments().AllowSendPhotos().
+		AllowSendVideos().AllowSendVideoNotes().AllowSendVoiceNotes()
+}
+
+// AllowSendPolls allows to send polls
+func (p *ChatPermissions) AllowSendPolls() *ChatPermissions {
+	p.CanSendPolls = true
+	return p
+}
+
+// AllowSendOtherMessages allows to send animations, games, stickers and use inline bots
+func (p *ChatPermissions) AllowSendOtherMessages() *ChatPermissions {
+	p.CanSendOtherMessages = true
+	return p
+}
+
+// AllowAddWebPagePreviews allows to add web page previews to messages
+func (p *ChatPermissions) AllowAddWebPagePreviews() *ChatPermissions {
+	p.CanAddWebPagePreviews = true
+	return p
+}
+
+// AllowChangeInfo allows to change the chat title, photo and other settings
+func (p *ChatPermissions) AllowChangeInfo() *ChatPermissions {
+	p.CanChangeInfo = true
+	return p
+}
+
+// AllowInviteUsers allows to invite new users to the chat
+func (p *ChatPermissions) AllowInviteUsers() *ChatPermissions {
+	p.CanInviteUsers = true
+	return p
+}
+
+// AllowPinMessages allows to pin messages
+func (p *ChatPermissions) AllowPinMessages() *ChatPermissions {
+	p.CanPinMessages = true
+	return p
+}
+
+// AllowManageTopics allows to create forum topics
+func (p *ChatPermissions) AllowManageTopics() *ChatPermissions {
+	p.CanManageTopics = true
+	return p
+}
+
+// SetChatPermissions and RestrictChatMember options
+var (
+	// OptUseIndependentChatPermissions makes granular media permissions independent,
+	// otherwise can_send_other_messages and can_add_web_page_previews imply media permissions,
+	// and media permissions imply can_send_messages
+	OptUseIndependentChatPermissions = func(v url.Values) {
+		v.Set("use_independent_chat_permissions", "true")
+	}
+)
+
 /*
 SetChatPermissions set default chat permissions for all members.
 The bot must be an administrator in the group or a supergroup
 for this to work and must have the can_restrict_members admin rights.
+For example, make the group read-only and open it again:
+
+	client.SetChatPermissions(chatID, tbot.MutePermissions())
+	client.SetChatPermissions(chatID, tbot.NewChatPermissions().AllowSendMessages().AllowSendMedia())
+
+Available options:
+	- OptUseIndependentChatPermissions
 */
-func (c *Client) SetChatPermissions(chatID string, permissions *ChatPermissions) error {
-	req := url.Values{}
-	req.Set("chat_id", chatID)
-	marshalledPermissions, _ := json.Marshal(permissions)
-	req.Set("permissions", string(marshalledPermissions))
+func (c *Client) SetChatPermissions(chatID SendChatID, permissions *ChatPermissions, opts ...sendOption) error {
+	if permissions == nil {
+		return fmt.Errorf("permissions are required")
+	}
+	req := withChat(chatID, opts...)
+	req.Set("permissions", structString(permissions))
 	var set bool
 	return c.doRequest("setChatPermissions", req, &set)
 }
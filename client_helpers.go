@@ -1,8 +1,390 @@
 package tbot
 
-import "fmt"
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
 
 // FileURL returns file URL ready for download
 func (c *Client) FileURL(file *File) string {
 	return fmt.Sprintf("%s/file/bot%s/%s", c.baseURL, c.token, file.FilePath)
 }
+
+/*
+DownloadFile downloads file by fileID and streams it to w.
+File path is resolved with GetFile, server option WithFileCache allows to skip
+getFile request for files downloaded recently. Errors of getFile are APIError,
+HTTP errors of the download are FileDownloadError:
+
+	var buf bytes.Buffer
+	err := client.DownloadFile(m.Document.FileID, &buf)
+	var downloadErr *tbot.FileDownloadError
+	if errors.As(err, &downloadErr) {
+		// file server responded with downloadErr.StatusCode
+	}
+*/
+func (c *Client) DownloadFile(fileID string, w io.Writer) error {
+	if c.fileCache != nil {
+		if path, ok := c.fileCache.get(fileID); ok {
+			err := c.DownloadFilePath(path, w)
+			var downloadErr *FileDownloadError
+			if !errors.As(err, &downloadErr) ||
+				downloadErr.StatusCode != http.StatusBadRequest && downloadErr.StatusCode != http.StatusNotFound {
+				return err
+			}
+			// file path is expired, resolve it again
+			c.fileCache.remove(fileID)
+		}
+	}
+	file, err := c.GetFile(fileID)
+	if err != nil {
+		return err
+	}
+	if c.fileCache != nil {
+		c.fileCache.put(fileID, file.FilePath)
+	}
+	return c.DownloadFilePath(file.FilePath, w)
+}
+
+// DownloadFilePath streams file with path returned by GetFile to w.
+// Download is cancelled with the client context, see WithContext.
+func (c *Client) DownloadFilePath(filePath string, w io.Writer) error {
+	req, err := http.NewRequest(http.MethodGet, c.FileURL(&File{FilePath: filePath}), nil)
+	if err != nil {
+		return fmt.Errorf("unable to create request: %v", err)
+	}
+	resp, err := c.httpClient.Do(req.WithContext(c.context()))
+	if err != nil {
+		return fmt.Errorf("unable to download file: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return &FileDownloadError{StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+	_, err = io.Copy(w, resp.Body)
+	if err != nil {
+		return fmt.Errorf("unable to download file: %v", err)
+	}
+	return nil
+}
+
+// chat action expires in 5 seconds, resend it a bit earlier
+var chatActionInterval = 4 * time.Second
+
+/*
+WithChatAction sends chat action and keeps it alive while fn is running,
+resending it every 4 seconds. Returns error returned by fn,
+errors of sending the action are only logged. Available options:
+	- OptMessageThreadID(id int)
+*/
+func (c *Client) WithChatAction(chatID SendChatID, action chatAction, fn func() error, opts ...sendOption) error {
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(chatActionInterval)
+		defer ticker.Stop()
+		for {
+			err := c.SendChatAction(chatID, action, opts...)
+			if err != nil {
+				c.logger.Errorf("unable to send chat action: %v", err)
+			}
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	err := fn()
+	close(stop)
+	<-done
+	return err
+}
+
+/*
+Deduplicator suppresses retries of requests which may have been delivered.
+Telegram has no idempotency keys, so a send that timed out may still be posted,
+and a naive retry posts the message twice. Wrap sends with Do using a key
+identifying the message, e.g. the update ID it replies to:
+
+	dedup := tbot.NewDeduplicator(time.Minute)
+	err := dedup.Do(strconv.Itoa(update.UpdateID), func() error {
+		_, err := client.SendMessage(chatID, "Done")
+		return err
+	})
+
+Retrying Do with the same key is safe, fn is not called again if it succeeded or
+failed with ErrAmbiguousDelivery within the window. Such deliveries are at most once.
+*/
+type Deduplicator struct {
+	window time.Duration
+	now    func() time.Time
+
+	mu       sync.Mutex
+	sent     map[string]time.Time
+	inFlight map[string]chan struct{} // closed when the call with the key returns
+}
+
+// NewDeduplicator creates Deduplicator remembering keys for the window
+func NewDeduplicator(window time.Duration) *Deduplicator {
+	return &Deduplicator{
+		window:   window,
+		now:      time.Now,
+		sent:     make(map[string]time.Time),
+		inFlight: make(map[string]chan struct{}),
+	}
+}
+
+// Do calls fn unless the call with the same key may have succeeded within the window.
+// Suppressed calls return nil. Concurrent calls with the same key wait for the running one.
+func (d *Deduplicator) Do(key string, fn func() error) error {
+	d.mu.Lock()
+	for {
+		now := d.now()
+		for k, t := range d.sent {
+			if now.Sub(t) >= d.window {
+				delete(d.sent, k)
+			}
+		}
+		if _, ok := d.sent[key]; ok {
+			d.mu.Unlock()
+			return nil
+		}
+		done, ok := d.inFlight[key]
+		if !ok {
+			break
+		}
+		d.mu.Unlock()
+		<-done
+		d.mu.Lock()
+	}
+	done := make(chan struct{})
+	d.inFlight[key] = done
+	d.mu.Unlock()
+
+	err := fn()
+	d.mu.Lock()
+	delete(d.inFlight, key)
+	if err == nil || errors.Is(err, ErrAmbiguousDelivery) {
+		d.sent[key] = d.now()
+	}
+	close(done)
+	d.mu.Unlock()
+	return err
+}
+
+// DefaultBroadcastInterval is the default interval between Broadcast messages,
+// Telegram allows about 30 messages per second to different chats
+const DefaultBroadcastInterval = time.Second / 25
+
+// WithBroadcastInterval returns copy of the client sending Broadcast messages with given interval,
+// e.g. to share the limit between several broadcasts running at the same time.
+// Non-positive interval means DefaultBroadcastInterval.
+func (c *Client) WithBroadcastInterval(interval time.Duration) *Client {
+	client := *c
+	client.broadcastInterval = interval
+	return &client
+}
+
+// retryAfterUnit is the unit of APIError.RetryAfter
+var retryAfterUnit = time.Second
+
+// broadcastRetries is the number of retries of a message hit by flood control
+const broadcastRetries = 3
+
+// BroadcastChatResult is a result of sending broadcast message to the chat
+type BroadcastChatResult struct {
+	ChatID  ChatID
+	Message *Message // sent message, nil on error
+	Err     error
+}
+
+// BroadcastResult contains results of Broadcast in the order of chat IDs
+type BroadcastResult struct {
+	Results []BroadcastChatResult
+}
+
+// Sent returns number of chats the message was sent to
+func (r *BroadcastResult) Sent() int {
+	sent := 0
+	for _, res := range r.Results {
+		if res.Err == nil {
+			sent++
+		}
+	}
+	return sent
+}
+
+// Unreachable returns chats which blocked the bot or were not found,
+// they should be removed from the recipients
+func (r *BroadcastResult) Unreachable() []ChatID {
+	var chats []ChatID
+	for _, res := range r.Results {
+		if errors.Is(res.Err, ErrBotBlocked) || errors.Is(res.Err, ErrChatNotFound) {
+			chats = append(chats, res.ChatID)
+		}
+	}
+	return chats
+}
+
+/*
+Broadcast sends the message to every chat, keeping below Telegram limits. Messages hit by
+flood control are retried after the requested delay, other errors are recorded in the result
+and the broadcast goes on. Error is returned only if the client context is done, use WithContext
+to cancel the broadcast. Result contains chats processed before that, including the chat the message
+was being sent to, its error is ErrAmbiguousDelivery if the request was in flight.
+Messages are sent with DefaultBroadcastInterval, see WithBroadcastInterval. Options are the same as for SendMessage.
+*/
+func (c *Client) Broadcast(chatIDs []ChatID, text string, opts ...sendOption) (*BroadcastResult, error) {
+	ctx := c.context()
+	interval := c.broadcastInterval
+	if interval <= 0 {
+		interval = DefaultBroadcastInterval
+	}
+	result := &BroadcastResult{Results: make([]BroadcastChatResult, 0, len(chatIDs))}
+	for i, chatID := range chatIDs {
+		if i > 0 {
+			if err := wait(ctx, interval); err != nil {
+				return result, err
+			}
+		}
+		msg, err := c.SendMessage(chatID, text, opts...)
+		for retry := 0; retry < broadcastRetries; retry++ {
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) || apiErr.RetryAfter == 0 {
+				break
+			}
+			if wait(ctx, time.Duration(apiErr.RetryAfter)*retryAfterUnit) != nil {
+				break
+			}
+			msg, err = c.SendMessage(chatID, text, opts...)
+		}
+		res := BroadcastChatResult{ChatID: chatID, Err: err}
+		if err == nil {
+			res.Message = msg
+		}
+		result.Results = append(result.Results, res)
+		if ctx.Err() != nil {
+			return result, ctx.Err()
+		}
+	}
+	return result, nil
+}
+
+// wait sleeps for the duration or until the context is done
+func wait(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// maxProfilePhotosLimit is the maximum number of photos returned by getUserProfilePhotos
+const maxProfilePhotosLimit = 100
+
+// GetAllUserProfilePhotos returns all user's profile pictures, newest first,
+// requesting them with GetUserProfilePhotos by pages of 100 photos.
+func (c *Client) GetAllUserProfilePhotos(userID int64) ([][]PhotoSize, error) {
+	var all [][]PhotoSize
+	for {
+		photos, err := c.GetUserProfilePhotos(userID, OptOffset(len(all)), OptLimit(maxProfilePhotosLimit))
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, photos.Photos...)
+		// photos can be deleted while paging, stop on empty page
+		if len(photos.Photos) == 0 || len(all) >= photos.TotalCount {
+			return all, nil
+		}
+	}
+}
+
+/*
+SendLongMessage sends text of any length as several messages of at most MaxMessageLength characters,
+in order. Text is split on line breaks if possible, then on spaces, formatting of OptParseModeHTML,
+OptParseModeMarkdown or OptEntities is never split. Options are applied to every message, except
+that reply is sent with the first message and reply markup with the last one.
+Returns ErrMessageTooLong before sending if a formatted part of the text doesn't fit in a message.
+On error returns messages sent before it.
+*/
+func (c *Client) SendLongMessage(chatID SendChatID, text string, opts ...sendOption) ([]*Message, error) {
+	req := withChat(chatID, opts...)
+	if err := checkLinkPreview(req); err != nil {
+		return nil, err
+	}
+	var entities []*MessageEntity
+	if e := req.Get("entities"); e != "" {
+		if err := json.Unmarshal([]byte(e), &entities); err != nil {
+			return nil, fmt.Errorf("unable to decode message entities: %v", err)
+		}
+	}
+	safe, err := splitPoints(text, req.Get("parse_mode"), entities)
+	if err != nil {
+		return nil, err
+	}
+	chunks, err := splitText(text, safe, MaxMessageLength)
+	if err != nil {
+		return nil, err
+	}
+	markup := req.Get("reply_markup")
+	req.Del("reply_markup")
+	var messages []*Message
+	for i, chunk := range chunks {
+		req.Set("text", text[chunk.start:chunk.end])
+		if entities != nil {
+			req.Set("entities", structString(chunkEntities(text, entities, chunk)))
+		}
+		if i > 0 {
+			req.Del("reply_to_message_id")
+			req.Del("reply_parameters")
+		}
+		if i == len(chunks)-1 && markup != "" {
+			req.Set("reply_markup", markup)
+		}
+		msg := &Message{}
+		if err := c.doRequest("sendMessage", req, msg); err != nil {
+			return messages, err
+		}
+		messages = append(messages, msg)
+	}
+	return messages, nil
+}
+
+// errNoClient is returned by Message.Reply for messages not received by Server
+var errNoClient = errors.New("message is not bound to a client, use Client.SendMessage")
+
+// Reply sends text message to the chat of the message, to the same topic for forum messages.
+// It works for messages passed to Server handlers, Client.SendMessage should be used otherwise.
+func (m *Message) Reply(text string, opts ...sendOption) (*Message, error) {
+	if m.client == nil {
+		return nil, errNoClient
+	}
+	if m.BusinessConnectionID != "" {
+		opts = append([]sendOption{OptBusinessConnectionID(m.BusinessConnectionID)}, opts...)
+	}
+	if m.IsTopicMessage {
+		opts = append([]sendOption{OptMessageThreadID(m.MessageThreadID)}, opts...)
+	}
+	return m.client.SendMessage(ChatID(m.Chat.ID), text, opts...)
+}
+
+// ReplyWithMarkdown sends text message with MarkdownV2 formatting to the chat of the message
+func (m *Message) ReplyWithMarkdown(text string, opts ...sendOption) (*Message, error) {
+	return m.Reply(text, append([]sendOption{OptParseModeMarkdown}, opts...)...)
+}
+
+// ReplyTo sends text message to the chat of the message as a reply to it
+func (m *Message) ReplyTo(text string, opts ...sendOption) (*Message, error) {
+	return m.Reply(text, append([]sendOption{OptReplyToMessageID(m.MessageID)}, opts...)...)
+}
@@ -0,0 +1,262 @@
+package tbot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWithChatAction(t *testing.T) {
+	defer func(interval time.Duration) { chatActionInterval = interval }(chatActionInterval)
+	chatActionInterval = 20 * time.Millisecond
+
+	httpClient, methods := testTransport(t)
+	c := NewClient("TOKEN", httpClient, "https://api.telegram.org")
+	start := time.Now()
+	err := c.WithChatAction(ChatID(123), ActionUploadVideo, func() error {
+		time.Sleep(110 * time.Millisecond)
+		return fmt.Errorf("failed")
+	})
+	if err == nil || err.Error() != "failed" {
+		t.Fatalf("expected error of fn, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 110*time.Millisecond {
+		t.Fatalf("returned before fn is done: %v", elapsed)
+	}
+	sent := len(methods)
+	// initial action and resends at 20, 40, 60, 80 and 100ms, one more tick may come before fn is done
+	if sent < 3 || sent > 7 {
+		t.Fatalf("expected about 6 actions, got %d", sent)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if len(methods) != sent {
+		t.Fatalf("action should not be sent after fn is done")
+	}
+	for i := 0; i < sent; i++ {
+		if method := <-methods; method != "sendChatAction" {
+			t.Fatalf("unexpected method %s", method)
+		}
+	}
+}
+
+func TestDeduplicatorWindow(t *testing.T) {
+	now := time.Now()
+	d := NewDeduplicator(time.Minute)
+	d.now = func() time.Time { return now }
+	calls := 0
+	fn := func(err error) func() error {
+		return func() error {
+			calls++
+			return err
+		}
+	}
+	if err := d.Do("key", fn(fmt.Errorf("bad request"))); err == nil {
+		t.Fatalf("expected error of fn")
+	}
+	// failed request is not delivered and can be retried
+	if err := d.Do("key", fn(nil)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := d.Do("key", fn(nil)); err != nil || calls != 2 {
+		t.Fatalf("expected suppressed call, got %d calls, error %v", calls, err)
+	}
+	now = now.Add(time.Minute)
+	if err := d.Do("key", fn(nil)); err != nil || calls != 3 {
+		t.Fatalf("expected call after the window, got %d calls, error %v", calls, err)
+	}
+	if len(d.sent) != 1 {
+		t.Fatalf("expired keys are not removed: %v", d.sent)
+	}
+}
+
+func TestBroadcast(t *testing.T) {
+	defer func(unit time.Duration) { retryAfterUnit = unit }(retryAfterUnit)
+	retryAfterUnit = time.Millisecond
+
+	var mu sync.Mutex
+	attempts := map[string]int{}
+	transport := func(r *http.Request) (*http.Response, error) {
+		r.ParseForm()
+		chatID := r.PostForm.Get("chat_id")
+		mu.Lock()
+		attempts[chatID]++
+		attempt := attempts[chatID]
+		mu.Unlock()
+		switch {
+		case chatID == "2":
+			return jsonResponse(`{"ok": false, "error_code": 403, "description": "Forbidden: bot was blocked by the user"}`), nil
+		case chatID == "3" && attempt == 1:
+			return jsonResponse(`{"ok": false, "error_code": 429, "description": "Too Many Requests: retry after 5", "parameters": {"retry_after": 5}}`), nil
+		case chatID == "4":
+			return jsonResponse(`{"ok": false, "error_code": 400, "description": "Bad Request: chat not found"}`), nil
+		}
+		return jsonResponse(`{"ok": true, "result": {"message_id": 1, "chat": {"id": ` + chatID + `}}}`), nil
+	}
+	c := NewClient("TOKEN", &http.Client{Transport: roundTripFunc(transport)}, "https://api.telegram.org").
+		WithBroadcastInterval(time.Millisecond)
+
+	result, err := c.Broadcast([]ChatID{1, 2, 3, 4}, "news")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Results) != 4 || result.Sent() != 2 {
+		t.Fatalf("unexpected results: %+v", result.Results)
+	}
+	if res := result.Results[2]; res.Err != nil || res.Message == nil || res.Message.Chat.ID != 3 {
+		t.Fatalf("flood wait is not retried: %+v", res)
+	}
+	if !reflect.DeepEqual(result.Unreachable(), []ChatID{2, 4}) {
+		t.Fatalf("unexpected unreachable chats: %v", result.Unreachable())
+	}
+	expected := map[string]int{"1": 1, "2": 1, "3": 2, "4": 1}
+	if !reflect.DeepEqual(attempts, expected) {
+		t.Fatalf("expected attempts %v, got %v", expected, attempts)
+	}
+}
+
+func TestBroadcastCancel(t *testing.T) {
+	httpClient, methods := testTransport(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	c := NewClient("TOKEN", httpClient, "https://api.telegram.org").WithContext(ctx).WithBroadcastInterval(time.Hour)
+	time.AfterFunc(20*time.Millisecond, cancel)
+	result, err := c.Broadcast([]ChatID{1, 2, 3}, "news")
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected cancelled broadcast, got %v", err)
+	}
+	if len(result.Results) != 1 || len(methods) != 1 {
+		t.Fatalf("expected one sent message, got %+v", result.Results)
+	}
+}
+
+func TestBroadcastCancelInFlight(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	transport := func(r *http.Request) (*http.Response, error) {
+		r.ParseForm()
+		if r.PostForm.Get("chat_id") == "2" {
+			cancel()
+			<-r.Context().Done()
+			return nil, r.Context().Err()
+		}
+		return jsonResponse(`{"ok": true, "result": {"message_id": 1}}`), nil
+	}
+	c := NewClient("TOKEN", &http.Client{Transport: roundTripFunc(transport)}, "https://api.telegram.org").
+		WithContext(ctx).WithBroadcastInterval(time.Millisecond)
+	result, err := c.Broadcast([]ChatID{1, 2, 3}, "news")
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected cancelled broadcast, got %v", err)
+	}
+	if len(result.Results) != 2 || result.Results[1].ChatID != 2 || !errors.Is(result.Results[1].Err, ErrAmbiguousDelivery) {
+		t.Fatalf("expected ambiguous result of the second chat, got %+v", result.Results)
+	}
+}
+
+func TestDownloadFileErrors(t *testing.T) {
+	var urls []string
+	transport := func(r *http.Request) (*http.Response, error) {
+		urls = append(urls, r.URL.String())
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/getFile"):
+			r.ParseForm()
+			if r.PostForm.Get("file_id") == "unknown" {
+				return &http.Response{
+					StatusCode: http.StatusBadRequest,
+					Body:       ioutil.NopCloser(strings.NewReader(`{"ok": false, "error_code": 400, "description": "Bad Request: invalid file_id"}`)),
+				}, nil
+			}
+			return jsonResponse(`{"ok": true, "result": {"file_id": "abc", "file_path": "documents/file_1.pdf"}}`), nil
+		}
+		return &http.Response{StatusCode: http.StatusNotFound, Status: "404 Not Found", Body: http.NoBody}, nil
+	}
+	c := NewClient("TOKEN", &http.Client{Transport: roundTripFunc(transport)}, "https://api.example.com")
+
+	err := c.DownloadFile("unknown", ioutil.Discard)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected APIError, got %v", err)
+	}
+	err = c.DownloadFile("abc", ioutil.Discard)
+	var downloadErr *FileDownloadError
+	if !errors.As(err, &downloadErr) || downloadErr.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected FileDownloadError, got %v", err)
+	}
+	if errors.As(err, &apiErr) {
+		t.Fatalf("download error should not be APIError")
+	}
+	if last := urls[len(urls)-1]; last != "https://api.example.com/file/botTOKEN/documents/file_1.pdf" {
+		t.Fatalf("unexpected download url: %s", last)
+	}
+}
+
+func TestDownloadFileStream(t *testing.T) {
+	body, bodyWriter := io.Pipe()
+	transport := func(r *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Status: "200 OK", Body: body}, nil
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	c := NewClient("TOKEN", &http.Client{Transport: roundTripFunc(transport)}, "https://api.example.com").WithContext(ctx)
+
+	w := &chunkWriter{chunks: make(chan string, 1)}
+	done := make(chan error)
+	go func() {
+		done <- c.DownloadFilePath("documents/file_1.pdf", w)
+	}()
+	bodyWriter.Write([]byte("first chunk"))
+	// chunk is written before the body is complete
+	if chunk := <-w.chunks; chunk != "first chunk" {
+		t.Fatalf("unexpected chunk: %s", chunk)
+	}
+	cancel()
+	bodyWriter.CloseWithError(ctx.Err())
+	if err := <-done; err == nil {
+		t.Fatalf("expected error for cancelled download")
+	}
+}
+
+type chunkWriter struct {
+	chunks chan string
+}
+
+func (w *chunkWriter) Write(p []byte) (int, error) {
+	w.chunks <- string(p)
+	return len(p), nil
+}
+
+func TestGetAllUserProfilePhotos(t *testing.T) {
+	const total = 250
+	var offsets []string
+	transport := func(r *http.Request) (*http.Response, error) {
+		r.ParseForm()
+		offsets = append(offsets, r.PostForm.Get("offset"))
+		offset, _ := strconv.Atoi(r.PostForm.Get("offset"))
+		limit, _ := strconv.Atoi(r.PostForm.Get("limit"))
+		if limit > 100 {
+			return jsonResponse(`{"ok": false, "error_code": 400, "description": "Bad Request: limit is too big"}`), nil
+		}
+		var photos []string
+		for i := offset; i < total && i < offset+limit; i++ {
+			photos = append(photos, fmt.Sprintf(`[{"file_id": "photo_%d"}]`, i))
+		}
+		return jsonResponse(fmt.Sprintf(`{"ok": true, "result": {"total_count": %d, "photos": [%s]}}`,
+			total, strings.Join(photos, ","))), nil
+	}
+	c := NewClient("TOKEN", &http.Client{Transport: roundTripFunc(transport)}, "https://api.telegram.org")
+	photos, err := c.GetAllUserProfilePhotos(7)
+	if err != nil {
+		t.Fatalf("error on getUserProfilePhotos: %v", err)
+	}
+	if len(photos) != total || photos[0][0].FileID != "photo_0" || photos[total-1][0].FileID != "photo_249" {
+		t.Fatalf("unexpected photos: %d", len(photos))
+	}
+	if !reflect.DeepEqual(offsets, []string{"0", "100", "200"}) {
+		t.Fatalf("unexpected offsets: %v", offsets)
+	}
+}
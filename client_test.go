@@ -1,10 +1,23 @@
 package tbot_test
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/yanzay/tbot/v2"
 )
@@ -77,6 +90,459 @@ func TestSendMessageWithOptions(t *testing.T) {
 	}
 }
 
+func TestSendMessageLinkPreviewOptions(t *testing.T) {
+	c, requests, stop := testRecorder(t, `
+		{
+			"ok": true,
+			"result": {"message_id": 1, "text": "https://example.com"}
+		}
+	`)
+	defer stop()
+	opts := tbot.LinkPreviewOptions{URL: "https://example.com/preview", PreferSmallMedia: true}
+	_, err := c.SendMessage(tbot.ChatID(123), "https://example.com", tbot.OptLinkPreviewOptions(opts))
+	if err != nil {
+		t.Fatalf("error on sendMessage: %v", err)
+	}
+	req := <-requests
+	expected := `{"url":"https://example.com/preview","prefer_small_media":true}`
+	if req.params.Get("link_preview_options") != expected {
+		t.Fatalf("unexpected link_preview_options: %s", req.params.Get("link_preview_options"))
+	}
+
+	_, err = c.EditMessageText(tbot.ChatID(123), 1, "https://example.com",
+		tbot.OptLinkPreviewOptions(tbot.LinkPreviewOptions{IsDisabled: true}))
+	if err != nil {
+		t.Fatalf("error on editMessageText: %v", err)
+	}
+	req = <-requests
+	if req.params.Get("link_preview_options") != `{"is_disabled":true}` {
+		t.Fatalf("unexpected link_preview_options: %s", req.params.Get("link_preview_options"))
+	}
+}
+
+func TestEditMessageText(t *testing.T) {
+	c, requests, stop := testRecorder(t, `{"ok": true, "result": {"message_id": 7, "text": "Done"}}`)
+	defer stop()
+	entities := []*tbot.MessageEntity{{Type: "bold", Offset: 0, Length: 4}}
+	msg, err := c.EditMessageText(tbot.ChatID(123), 7, "Done", tbot.OptEntities(entities),
+		tbot.OptInlineKeyboardMarkup(&tbot.InlineKeyboardMarkup{}))
+	if err != nil {
+		t.Fatalf("error on editMessageText: %v", err)
+	}
+	if msg.MessageID != 7 || msg.Text != "Done" {
+		t.Fatalf("unexpected message: %+v", msg)
+	}
+	req := <-requests
+	if req.method != "editMessageText" || req.params.Get("chat_id") != "123" || req.params.Get("message_id") != "7" {
+		t.Fatalf("unexpected request %s: %v", req.method, req.params)
+	}
+	if req.params.Get("entities") != `[{"type":"bold","offset":0,"length":4}]` || req.params.Get("reply_markup") == "" {
+		t.Fatalf("unexpected params: %v", req.params)
+	}
+
+	c, requests, stop2 := testRecorder(t, `{"ok": true, "result": true}`)
+	defer stop2()
+	err = c.EditInlineMessageText("inline-1", "Done", tbot.OptParseModeHTML)
+	if err != nil {
+		t.Fatalf("error on editMessageText: %v", err)
+	}
+	req = <-requests
+	if req.params.Get("inline_message_id") != "inline-1" || req.params.Get("chat_id") != "" || req.params.Get("parse_mode") != "HTML" {
+		t.Fatalf("unexpected params: %v", req.params)
+	}
+}
+
+func TestEditMessageCaption(t *testing.T) {
+	c, requests, stop := testRecorder(t, `{"ok": true, "result": {"message_id": 7, "caption": "Page 2"}}`)
+	defer stop()
+	entities := []*tbot.MessageEntity{{Type: "italic", Offset: 0, Length: 4}}
+	markup := tbot.NewPaginator([]tbot.InlineKeyboardButton{{Text: "a"}, {Text: "b"}}, 1, "page").Markup(1)
+	msg, err := c.EditMessageCaption(tbot.ChatID(123), 7, "Page 2", tbot.OptCaptionEntities(entities), tbot.OptInlineKeyboardMarkup(markup))
+	if err != nil {
+		t.Fatalf("error on editMessageCaption: %v", err)
+	}
+	if msg.Caption != "Page 2" {
+		t.Fatalf("unexpected message: %+v", msg)
+	}
+	req := <-requests
+	if req.method != "editMessageCaption" || req.params.Get("message_id") != "7" || req.params.Get("caption") != "Page 2" {
+		t.Fatalf("unexpected request %s: %v", req.method, req.params)
+	}
+	if req.params.Get("caption_entities") != `[{"type":"italic","offset":0,"length":4}]` || req.params.Get("reply_markup") == "" {
+		t.Fatalf("unexpected params: %v", req.params)
+	}
+
+	c, requests, stop2 := testRecorder(t, `{"ok": true, "result": true}`)
+	defer stop2()
+	err = c.EditInlineMessageCaption("inline-1", "")
+	if err != nil {
+		t.Fatalf("error on editMessageCaption: %v", err)
+	}
+	req = <-requests
+	if req.params.Get("inline_message_id") != "inline-1" {
+		t.Fatalf("unexpected params: %v", req.params)
+	}
+	// empty caption must be sent to clear the current one
+	if caption, ok := req.params["caption"]; !ok || len(caption) != 1 || caption[0] != "" {
+		t.Fatalf("expected empty caption, got %v", req.params)
+	}
+}
+
+func TestEditMessageMedia(t *testing.T) {
+	c, requests, stop := testRecorder(t, `{"ok": true, "result": {"message_id": 7}}`)
+	defer stop()
+	markup := &tbot.InlineKeyboardMarkup{InlineKeyboard: [][]tbot.InlineKeyboardButton{{{Text: "▶", CallbackData: "page:1"}}}}
+	_, err := c.EditMessageMedia(tbot.ChatID(123), 7, tbot.InputMediaPhoto{
+		File:    tbot.InputFileReader("cat.jpg", strings.NewReader("jpeg data")),
+		Caption: "Cat",
+	}, tbot.OptInlineKeyboardMarkup(markup))
+	if err != nil {
+		t.Fatalf("error on editMessageMedia: %v", err)
+	}
+	req := <-requests
+	if req.method != "editMessageMedia" || !req.multipart || req.params.Get("message_id") != "7" || req.params.Get("reply_markup") == "" {
+		t.Fatalf("unexpected request %s: %v", req.method, req.params)
+	}
+	if req.params.Get("media") != `{"type":"photo","media":"attach://media_file","caption":"Cat"}` {
+		t.Fatalf("unexpected media: %s", req.params.Get("media"))
+	}
+	if req.files["media_file"] != "jpeg data" || req.filenames["media_file"] != "cat.jpg" {
+		t.Fatalf("unexpected files: %v", req.filenames)
+	}
+
+	_, err = c.EditMessageMedia(tbot.ChatID(123), 7, tbot.InputMediaPhoto{Media: "photo-file-id"})
+	if err != nil {
+		t.Fatalf("error on editMessageMedia: %v", err)
+	}
+	req = <-requests
+	if req.multipart || req.params.Get("media") != `{"type":"photo","media":"photo-file-id"}` {
+		t.Fatalf("unexpected request: %v", req.params)
+	}
+}
+
+func TestEditInlineMessageMedia(t *testing.T) {
+	c, requests, stop := testRecorder(t, `{"ok": true, "result": true}`)
+	defer stop()
+	err := c.EditInlineMessageMedia("inline-1", tbot.InputMediaDocument{Media: "doc-file-id"})
+	if err != nil {
+		t.Fatalf("error on editMessageMedia: %v", err)
+	}
+	req := <-requests
+	if req.params.Get("inline_message_id") != "inline-1" || req.params.Get("media") != `{"type":"document","media":"doc-file-id"}` {
+		t.Fatalf("unexpected request: %v", req.params)
+	}
+	err = c.EditInlineMessageMedia("inline-1", tbot.InputMediaPhoto{File: tbot.InputFilePath("cat.jpg")})
+	if err == nil {
+		t.Fatalf("expected error for inline media upload")
+	}
+	if len(requests) != 0 {
+		t.Fatalf("request should not be sent")
+	}
+}
+
+func TestSendMediaGroupUpload(t *testing.T) {
+	c, requests, stop := testRecorder(t, `{"ok": true, "result": [{"message_id": 1}, {"message_id": 2}]}`)
+	defer stop()
+	msgs, err := c.SendMediaGroup(tbot.ChatID(123), []tbot.InputMedia{
+		tbot.InputMediaPhoto{Media: "photo-file-id"},
+		tbot.InputMediaVideo{File: tbot.InputFileReader("dog.mp4", strings.NewReader("mp4 data"))},
+	}, tbot.OptDisableNotification)
+	if err != nil {
+		t.Fatalf("error on sendMediaGroup: %v", err)
+	}
+	if len(msgs) != 2 {
+		t.Fatalf("unexpected messages: %v", msgs)
+	}
+	req := <-requests
+	expected := `[{"type":"photo","media":"photo-file-id"},{"type":"video","media":"attach://media1"}]`
+	if !req.multipart || req.params.Get("media") != expected || req.params.Get("disable_notification") != "true" {
+		t.Fatalf("unexpected request: %v", req.params)
+	}
+	if req.files["media1"] != "mp4 data" {
+		t.Fatalf("unexpected files: %v", req.filenames)
+	}
+}
+
+func TestEditMessageTextNotModified(t *testing.T) {
+	c, stop := testClientStatus(t, http.StatusBadRequest, `{"ok": false, "error_code": 400,
+		"description": "Bad Request: message is not modified: specified new message content and reply markup are exactly the same as a current content and reply markup of the message"}`)
+	defer stop()
+	_, err := c.EditMessageText(tbot.ChatID(123), 7, "Done")
+	if !errors.Is(err, tbot.ErrMessageNotModified) {
+		t.Fatalf("expected ErrMessageNotModified, got %v", err)
+	}
+	err = c.EditInlineMessageText("inline-1", "Done")
+	if !errors.Is(err, tbot.ErrMessageNotModified) {
+		t.Fatalf("expected ErrMessageNotModified, got %v", err)
+	}
+}
+
+func TestSendMessageChatAddressing(t *testing.T) {
+	c, requests, stop := testRecorder(t, `{"ok": true, "result": {"message_id": 1}}`)
+	defer stop()
+	tt := []struct {
+		chatID   tbot.SendChatID
+		expected string
+	}{
+		{chatID: tbot.ChatID(-1001234567890), expected: "-1001234567890"},
+		{chatID: tbot.ChatName("@mychannel"), expected: "@mychannel"},
+		{chatID: tbot.ChatName("mychannel"), expected: "@mychannel"},
+		{chatID: tbot.ChatName("-1001234567890"), expected: "-1001234567890"},
+		{chatID: tbot.ChatName("123"), expected: "123"},
+	}
+	for _, tc := range tt {
+		_, err := c.SendMessage(tc.chatID, "hi")
+		if err != nil {
+			t.Fatalf("error on sendMessage: %v", err)
+		}
+		req := <-requests
+		if req.params.Get("chat_id") != tc.expected {
+			t.Fatalf("expected chat_id %s, got %s", tc.expected, req.params.Get("chat_id"))
+		}
+	}
+}
+
+func TestSendGameChatID(t *testing.T) {
+	c, requests, stop := testRecorder(t, `{"ok": true, "result": {"message_id": 1}}`)
+	defer stop()
+	_, err := c.SendGame(tbot.ChatID(123), "tetris")
+	if err != nil {
+		t.Fatalf("error on sendGame: %v", err)
+	}
+	req := <-requests
+	if req.params.Get("chat_id") != "123" || req.params.Get("game_short_name") != "tetris" {
+		t.Fatalf("unexpected request: %v", req.params)
+	}
+}
+
+func TestSendGameKeyboard(t *testing.T) {
+	c, requests, stop := testRecorder(t, `{"ok": true, "result": {"message_id": 1}}`)
+	defer stop()
+	markup := &tbot.InlineKeyboardMarkup{InlineKeyboard: [][]tbot.InlineKeyboardButton{
+		{{Text: "Play", CallbackGame: &tbot.CallbackGame{}}},
+	}}
+	if _, err := c.SendGame(tbot.ChatID(123), "tetris", tbot.OptInlineKeyboardMarkup(markup)); err != nil {
+		t.Fatalf("error on sendGame: %v", err)
+	}
+	req := <-requests
+	if req.params.Get("reply_markup") != `{"inline_keyboard":[[{"text":"Play","callback_game":{}}]]}` {
+		t.Fatalf("unexpected reply markup: %s", req.params.Get("reply_markup"))
+	}
+
+	cq := &tbot.CallbackQuery{}
+	err := json.Unmarshal([]byte(`{"id": "cq-1", "from": {"id": 5}, "inline_message_id": "inline-1", "game_short_name": "tetris"}`), cq)
+	if err != nil {
+		t.Fatalf("unable to decode callback query: %v", err)
+	}
+	if cq.GameShortName != "tetris" {
+		t.Fatalf("unexpected game short name: %q", cq.GameShortName)
+	}
+}
+
+func TestBusinessConnectionID(t *testing.T) {
+	c, requests, stop := testRecorder(t, `{"ok": true, "result": {"message_id": 1}}`)
+	defer stop()
+	_, err := c.SendMessage(tbot.ChatID(7), "hello", tbot.OptBusinessConnectionID("conn-1"))
+	if err != nil {
+		t.Fatalf("error on sendMessage: %v", err)
+	}
+	if req := <-requests; req.params.Get("business_connection_id") != "conn-1" {
+		t.Fatalf("unexpected request %s: %v", req.method, req.params)
+	}
+	_, err = c.EditMessageText(tbot.ChatID(7), 1, "hello again", tbot.OptBusinessConnectionID("conn-1"))
+	if err != nil {
+		t.Fatalf("error on editMessageText: %v", err)
+	}
+	if req := <-requests; req.method != "editMessageText" || req.params.Get("business_connection_id") != "conn-1" {
+		t.Fatalf("unexpected request %s: %v", req.method, req.params)
+	}
+	c, requests, stop2 := testRecorder(t, `{"ok": true, "result": true}`)
+	defer stop2()
+	if err := c.DeleteBusinessMessages("conn-1", []int{1, 2}); err != nil {
+		t.Fatalf("error on deleteBusinessMessages: %v", err)
+	}
+	if req := <-requests; req.method != "deleteBusinessMessages" || req.params.Get("business_connection_id") != "conn-1" ||
+		req.params.Get("message_ids") != "[1,2]" {
+		t.Fatalf("unexpected request %s: %v", req.method, req.params)
+	}
+}
+
+func TestSetGameScore(t *testing.T) {
+	c, requests, stop := testRecorder(t, `{"ok": true, "result": {"message_id": 1, "game": {"title": "Tetris"}}}`)
+	defer stop()
+	msg, err := c.SetGameScore(5, 100, tbot.OptGameMessage(tbot.ChatID(123), 1), tbot.OptForce)
+	if err != nil {
+		t.Fatalf("error on setGameScore: %v", err)
+	}
+	if msg.Game == nil || msg.Game.Title != "Tetris" {
+		t.Fatalf("unexpected message: %+v", msg)
+	}
+	req := <-requests
+	if req.method != "setGameScore" || req.params.Get("chat_id") != "123" || req.params.Get("message_id") != "1" ||
+		req.params.Get("user_id") != "5" || req.params.Get("score") != "100" || req.params.Get("force") != "true" {
+		t.Fatalf("unexpected request %s: %v", req.method, req.params)
+	}
+
+	c, requests, stop2 := testRecorder(t, `{"ok": true, "result": true}`)
+	defer stop2()
+	msg, err = c.SetGameScore(5, 100, tbot.OptInlineMessageID("inline-1"), tbot.OptDisableEditMessage)
+	if err != nil || msg != nil {
+		t.Fatalf("unexpected result of inline setGameScore: %+v, %v", msg, err)
+	}
+	req = <-requests
+	if req.params.Get("inline_message_id") != "inline-1" || req.params.Get("chat_id") != "" ||
+		req.params.Get("disable_edit_message") != "true" || req.params.Get("force") != "" {
+		t.Fatalf("unexpected request %s: %v", req.method, req.params)
+	}
+
+	c, requests, stop3 := testRecorder(t, `{"ok": true, "result": [{"position": 1, "user": {"id": 5}, "score": 100}]}`)
+	defer stop3()
+	scores, err := c.GetGameHighScores(5, tbot.OptGameMessage(tbot.ChatID(123), 1))
+	if err != nil {
+		t.Fatalf("error on getGameHighScores: %v", err)
+	}
+	if len(scores) != 1 || scores[0].Position != 1 || scores[0].User.ID != 5 || scores[0].Score != 100 {
+		t.Fatalf("unexpected scores: %v", scores)
+	}
+	req = <-requests
+	if req.method != "getGameHighScores" || req.params.Get("chat_id") != "123" || req.params.Get("message_id") != "1" ||
+		req.params.Get("user_id") != "5" {
+		t.Fatalf("unexpected request %s: %v", req.method, req.params)
+	}
+}
+
+func TestGameMessageRequired(t *testing.T) {
+	c, requests, stop := testRecorder(t, `{"ok": true, "result": true}`)
+	defer stop()
+	if _, err := c.SetGameScore(5, 100); err == nil {
+		t.Fatalf("expected error without game message")
+	}
+	if _, err := c.GetGameHighScores(5, tbot.OptGameMessage(tbot.ChatID(123), 1), tbot.OptInlineMessageID("inline-1")); err == nil {
+		t.Fatalf("expected error for both game messages")
+	}
+	if len(requests) != 0 {
+		t.Fatalf("request should not be sent")
+	}
+}
+
+func TestSendMessageLinkPreviewConflict(t *testing.T) {
+	c, requests, stop := testRecorder(t, `{"ok": true, "result": {}}`)
+	defer stop()
+	_, err := c.SendMessage(tbot.ChatID(123), "https://example.com", tbot.OptDisableWebPagePreview,
+		tbot.OptLinkPreviewOptions(tbot.LinkPreviewOptions{IsDisabled: true}))
+	if err == nil {
+		t.Fatalf("expected error for conflicting link preview options")
+	}
+	if len(requests) != 0 {
+		t.Fatalf("request should not be sent")
+	}
+}
+
+func TestSendMessageTooLong(t *testing.T) {
+	c, requests, stop := testRecorder(t, `{"ok": true, "result": {}}`)
+	defer stop()
+	_, err := c.SendMessage(tbot.ChatID(123), strings.Repeat("a", tbot.MaxMessageLength+1))
+	if !errors.Is(err, tbot.ErrMessageTooLong) {
+		t.Fatalf("expected ErrMessageTooLong, got %v", err)
+	}
+	// emoji take 2 UTF-16 code units
+	_, err = c.SendMessage(tbot.ChatID(123), strings.Repeat("😀", tbot.MaxMessageLength/2+1))
+	if !errors.Is(err, tbot.ErrMessageTooLong) {
+		t.Fatalf("expected ErrMessageTooLong, got %v", err)
+	}
+	if len(requests) != 0 {
+		t.Fatalf("request should not be sent")
+	}
+	if _, err = c.SendMessage(tbot.ChatID(123), strings.Repeat("a", tbot.MaxMessageLength)); err != nil {
+		t.Fatalf("error on sendMessage: %v", err)
+	}
+
+	c, stop2 := testClientStatus(t, http.StatusBadRequest, `{"ok": false, "error_code": 400, "description": "Bad Request: message is too long"}`)
+	defer stop2()
+	_, err = c.SendMessage(tbot.ChatID(123), strings.Repeat("<b>a</b>", 1000), tbot.OptParseModeHTML)
+	if !errors.Is(err, tbot.ErrMessageTooLong) {
+		t.Fatalf("expected ErrMessageTooLong, got %v", err)
+	}
+}
+
+func TestSendLongMessage(t *testing.T) {
+	c, requests, stop := testRecorder(t, `{"ok": true, "result": {"message_id": 1}}`)
+	defer stop()
+	first := strings.Repeat("a", tbot.MaxMessageLength-10)
+	second := "<b>bold text</b> " + strings.Repeat("b", 20)
+	msgs, err := c.SendLongMessage(tbot.ChatID(123), first+" "+second, tbot.OptParseModeHTML,
+		tbot.OptReplyToMessageID(7), tbot.OptInlineKeyboardMarkup(&tbot.InlineKeyboardMarkup{}))
+	if err != nil {
+		t.Fatalf("error on SendLongMessage: %v", err)
+	}
+	if len(msgs) != 2 {
+		t.Fatalf("expected 2 messages, got %d", len(msgs))
+	}
+	req := <-requests
+	if req.params.Get("text") != first || req.params.Get("parse_mode") != "HTML" ||
+		req.params.Get("reply_to_message_id") != "7" || req.params.Get("reply_markup") != "" {
+		t.Fatalf("unexpected first request: %v", req.params)
+	}
+	req = <-requests
+	if req.params.Get("text") != second || req.params.Get("parse_mode") != "HTML" ||
+		req.params.Get("reply_to_message_id") != "" || req.params.Get("reply_markup") == "" {
+		t.Fatalf("unexpected second request: %v", req.params)
+	}
+
+	_, err = c.SendLongMessage(tbot.ChatID(123), "<b>"+strings.Repeat("a", tbot.MaxMessageLength)+"</b>", tbot.OptParseModeHTML)
+	if !errors.Is(err, tbot.ErrMessageTooLong) {
+		t.Fatalf("expected ErrMessageTooLong, got %v", err)
+	}
+	if len(requests) != 0 {
+		t.Fatalf("request should not be sent")
+	}
+}
+
+func TestForwardAndCopyMessages(t *testing.T) {
+	c, requests, stop := testRecorder(t, `{"ok": true, "result": [{"message_id": 11}, {"message_id": 12}]}`)
+	defer stop()
+	ids, err := c.ForwardMessages(tbot.ChatID(321), tbot.ChatName("@source"), []int{1, 2}, tbot.OptDisableNotification)
+	if err != nil {
+		t.Fatalf("error on forwardMessages: %v", err)
+	}
+	if !reflect.DeepEqual(ids, []tbot.MessageID{{MessageID: 11}, {MessageID: 12}}) {
+		t.Fatalf("unexpected message IDs: %v", ids)
+	}
+	req := <-requests
+	if req.method != "forwardMessages" || req.params.Get("chat_id") != "321" || req.params.Get("from_chat_id") != "@source" ||
+		req.params.Get("message_ids") != "[1,2]" || req.params.Get("disable_notification") != "true" {
+		t.Fatalf("unexpected request %s: %v", req.method, req.params)
+	}
+
+	ids, err = c.CopyMessages(tbot.ChatID(321), tbot.ChatID(123), []int{5, 9}, tbot.OptRemoveCaption)
+	if err != nil {
+		t.Fatalf("error on copyMessages: %v", err)
+	}
+	if !reflect.DeepEqual(ids, []tbot.MessageID{{MessageID: 11}, {MessageID: 12}}) {
+		t.Fatalf("unexpected message IDs: %v", ids)
+	}
+	req = <-requests
+	if req.method != "copyMessages" || req.params.Get("from_chat_id") != "123" ||
+		req.params.Get("message_ids") != "[5,9]" || req.params.Get("remove_caption") != "true" {
+		t.Fatalf("unexpected request %s: %v", req.method, req.params)
+	}
+
+	tooMany := make([]int, tbot.MaxMessageBatch+1)
+	for i := range tooMany {
+		tooMany[i] = i + 1
+	}
+	for _, messageIDs := range [][]int{nil, tooMany, {2, 1}, {1, 1}} {
+		_, err := c.CopyMessages(tbot.ChatID(321), tbot.ChatID(123), messageIDs)
+		var batchErr *tbot.MessageBatchError
+		if !errors.As(err, &batchErr) || batchErr.Unsorted != (len(messageIDs) == 2) {
+			t.Fatalf("unexpected error for message IDs %v: %v", messageIDs, err)
+		}
+	}
+	if len(requests) != 0 {
+		t.Fatalf("invalid batches should not be sent")
+	}
+}
+
 func TestForwardMessage(t *testing.T) {
 	c := testClient(t, `
 		{
@@ -110,6 +576,42 @@ func TestSendAudio(t *testing.T) {
 	}
 }
 
+func TestSendAudioOptions(t *testing.T) {
+	c, requests, stop := testRecorder(t, `
+		{
+			"ok": true,
+			"result": {
+				"message_id": 321,
+				"audio": {"file_id": "audio", "duration": 180, "performer": "tbot", "title": "Episode 1"}
+			}
+		}
+	`)
+	defer stop()
+	entities := []*tbot.MessageEntity{{Type: "bold", Offset: 0, Length: 4}}
+	msg, err := c.SendAudio(tbot.ChatID(123), "https://example.com/ep1.mp3",
+		tbot.OptPerformer("tbot"), tbot.OptTitle("Episode 1"), tbot.OptDuration(180),
+		tbot.OptCaption("Episode 1"), tbot.OptCaptionEntities(entities))
+	if err != nil {
+		t.Fatalf("error on sendAudio: %v", err)
+	}
+	if msg.Audio == nil || msg.Audio.FileID != "audio" || msg.Audio.Duration != 180 {
+		t.Fatalf("unexpected audio: %+v", msg.Audio)
+	}
+	req := <-requests
+	expected := map[string]string{
+		"audio":            "https://example.com/ep1.mp3",
+		"performer":        "tbot",
+		"title":            "Episode 1",
+		"duration":         "180",
+		"caption_entities": `[{"type":"bold","offset":0,"length":4}]`,
+	}
+	for k, v := range expected {
+		if req.params.Get(k) != v {
+			t.Fatalf("unexpected %s: %q, expected %q", k, req.params.Get(k), v)
+		}
+	}
+}
+
 func TestSendAudioFile(t *testing.T) {
 	c := testClient(t, `
 		{
@@ -158,31 +660,2284 @@ func TestSendPhotoFile(t *testing.T) {
 	}
 }
 
-func TestSendDice(t *testing.T) {
-	c := testClient(t, `
+func TestSendPhotoURL(t *testing.T) {
+	c, requests, stop := testRecorder(t, `
 		{
 			"ok": true,
 			"result": {
-				"emoji": "🎲",
-				"value": 6
+				"message_id": 321,
+				"photo": [
+					{"file_id": "small", "width": 90, "height": 90},
+					{"file_id": "big", "width": 800, "height": 800}
+				]
 			}
 		}
 	`)
-	msg, err := c.SendDice("123", "🎲")
+	defer stop()
+	msg, err := c.SendPhoto(tbot.ChatID(123), "https://example.com/image.png", tbot.OptCaption("hi"))
 	if err != nil {
-		t.Fatalf("error on sendDice: %v", err)
+		t.Fatalf("error on sendPhoto: %v", err)
+	}
+	if len(msg.Photo) != 2 || msg.Photo[1].FileID != "big" {
+		t.Fatalf("unexpected photo sizes: %v", msg.Photo)
+	}
+	req := <-requests
+	if req.method != "sendPhoto" || req.multipart {
+		t.Fatalf("unexpected request: %s, multipart: %v", req.method, req.multipart)
+	}
+	if req.params.Get("photo") != "https://example.com/image.png" {
+		t.Fatalf("unexpected photo param: %s", req.params.Get("photo"))
 	}
-	if msg.Value == 0 {
-		t.Fatalf("empty dice value")
+	if req.params.Get("caption") != "hi" {
+		t.Fatalf("unexpected caption: %s", req.params.Get("caption"))
 	}
 }
 
-func testClient(t *testing.T, resp string) *tbot.Client {
-	t.Helper()
-	handler := func(w http.ResponseWriter, r *http.Request) {
-		fmt.Fprintf(w, resp)
+func TestSendPhotoReader(t *testing.T) {
+	c, requests, stop := testRecorder(t, `
+		{
+			"ok": true,
+			"result": {"message_id": 321, "photo": [{"file_id": "uploaded"}]}
+		}
+	`)
+	defer stop()
+	photo := tbot.InputFileReader("image.png", strings.NewReader("png data"))
+	msg, err := c.SendPhoto(tbot.ChatID(123), photo, tbot.OptCaption("hi"))
+	if err != nil {
+		t.Fatalf("error on sendPhoto: %v", err)
 	}
-	httpServer := httptest.NewServer(http.HandlerFunc(handler))
-	httpClient := httpServer.Client()
-	return tbot.NewClient(token, httpClient, httpServer.URL)
+	if len(msg.Photo) != 1 || msg.Photo[0].FileID != "uploaded" {
+		t.Fatalf("unexpected photo sizes: %v", msg.Photo)
+	}
+	req := <-requests
+	if !req.multipart {
+		t.Fatalf("expected multipart request")
+	}
+	if req.files["photo"] != "png data" || req.filenames["photo"] != "image.png" {
+		t.Fatalf("unexpected photo file: %s %q", req.filenames["photo"], req.files["photo"])
+	}
+	if req.params.Get("chat_id") != "123" || req.params.Get("caption") != "hi" {
+		t.Fatalf("unexpected params: %v", req.params)
+	}
+}
+
+func TestSendDocumentReader(t *testing.T) {
+	c, requests, stop := testRecorder(t, `
+		{
+			"ok": true,
+			"result": {"message_id": 321, "document": {"file_id": "doc", "file_name": "report.csv"}}
+		}
+	`)
+	defer stop()
+	pr, pw := io.Pipe()
+	go func() {
+		for i := 0; i < 3; i++ {
+			fmt.Fprintf(pw, "row,%d\n", i)
+		}
+		pw.Close()
+	}()
+	doc := tbot.InputFileReader("report.csv", pr)
+	msg, err := c.SendDocument(tbot.ChatID(123), doc, tbot.OptThumb("client_test.go"), tbot.OptCaption("report"))
+	if err != nil {
+		t.Fatalf("error on sendDocument: %v", err)
+	}
+	if msg.Document == nil || msg.Document.FileID != "doc" {
+		t.Fatalf("unexpected document: %v", msg.Document)
+	}
+	req := <-requests
+	if req.files["document"] != "row,0\nrow,1\nrow,2\n" || req.filenames["document"] != "report.csv" {
+		t.Fatalf("unexpected document file: %s %q", req.filenames["document"], req.files["document"])
+	}
+	thumb := strings.TrimPrefix(req.params.Get("thumb"), "attach://")
+	if thumb == req.params.Get("thumb") || req.files[thumb] == "" {
+		t.Fatalf("thumbnail is not attached: %v", req.params.Get("thumb"))
+	}
+}
+
+func TestSendDocumentAPIError(t *testing.T) {
+	c, stop := testClientStatus(t, http.StatusRequestEntityTooLarge, `
+		{
+			"ok": false,
+			"error_code": 413,
+			"description": "Request Entity Too Large"
+		}
+	`)
+	defer stop()
+	_, err := c.SendDocument(tbot.ChatID(123), tbot.InputFileReader("big.bin", strings.NewReader("data")))
+	apiErr, ok := err.(*tbot.APIError)
+	if !ok {
+		t.Fatalf("expected *tbot.APIError, got %T: %v", err, err)
+	}
+	if apiErr.Code != 413 || apiErr.Method != "sendDocument" || apiErr.Description != "Request Entity Too Large" {
+		t.Fatalf("unexpected error: %+v", apiErr)
+	}
+}
+
+func TestSendVideoWithThumb(t *testing.T) {
+	c, requests, stop := testRecorder(t, `
+		{
+			"ok": true,
+			"result": {"message_id": 321, "video": {"file_id": "video", "width": 640, "height": 480}}
+		}
+	`)
+	defer stop()
+	video := tbot.InputFileReader("video.mp4", strings.NewReader("mp4 data"))
+	msg, err := c.SendVideo(tbot.ChatID(123), video, tbot.OptThumb("client_test.go"),
+		tbot.OptWidth(640), tbot.OptHeight(480), tbot.OptSupportsStreaming, tbot.OptHasSpoiler)
+	if err != nil {
+		t.Fatalf("error on sendVideo: %v", err)
+	}
+	if msg.Video == nil || msg.Video.FileID != "video" {
+		t.Fatalf("unexpected video: %+v", msg.Video)
+	}
+	req := <-requests
+	if req.files["video"] != "mp4 data" {
+		t.Fatalf("unexpected video contents: %q", req.files["video"])
+	}
+	thumb := req.params.Get("thumb")
+	if !strings.HasPrefix(thumb, "attach://") {
+		t.Fatalf("thumbnail should be referenced with attach://, got %q", thumb)
+	}
+	part := strings.TrimPrefix(thumb, "attach://")
+	if req.filenames[part] != "client_test.go" || req.files[part] == "" {
+		t.Fatalf("thumbnail part %q is not attached", part)
+	}
+	if req.params.Get("has_spoiler") != "true" || req.params.Get("supports_streaming") != "true" {
+		t.Fatalf("unexpected params: %v", req.params)
+	}
+}
+
+func TestSendVoiceUpload(t *testing.T) {
+	c, requests, stop := testRecorder(t, `
+		{
+			"ok": true,
+			"result": {"message_id": 321, "voice": {"file_id": "voice", "duration": 3, "mime_type": "audio/ogg"}}
+		}
+	`)
+	defer stop()
+	voice := tbot.InputFileReader("speech.ogg", strings.NewReader("ogg data"))
+	msg, err := c.SendVoice(tbot.ChatID(123), voice, tbot.OptDuration(3), tbot.OptCaption("tts"))
+	if err != nil {
+		t.Fatalf("error on sendVoice: %v", err)
+	}
+	if msg.Voice == nil || msg.Voice.FileID != "voice" || msg.Voice.Duration != 3 {
+		t.Fatalf("unexpected voice: %+v", msg.Voice)
+	}
+	req := <-requests
+	if req.method != "sendVoice" || req.files["voice"] != "ogg data" || req.filenames["voice"] != "speech.ogg" {
+		t.Fatalf("unexpected voice upload: %s %s %q", req.method, req.filenames["voice"], req.files["voice"])
+	}
+	if req.params.Get("duration") != "3" || req.params.Get("caption") != "tts" {
+		t.Fatalf("unexpected params: %v", req.params)
+	}
+}
+
+func TestSendVoiceError(t *testing.T) {
+	c, stop := testClientStatus(t, http.StatusBadRequest, `
+		{
+			"ok": false,
+			"error_code": 400,
+			"description": "Bad Request: VOICE_MESSAGES_FORBIDDEN"
+		}
+	`)
+	defer stop()
+	_, err := c.SendVoice(tbot.ChatID(123), "voice")
+	if err == nil || err.Error() != "Bad Request: VOICE_MESSAGES_FORBIDDEN" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestSendVideoNote(t *testing.T) {
+	c, requests, stop := testRecorder(t, `
+		{
+			"ok": true,
+			"result": {"message_id": 321, "video_note": {"file_id": "note", "length": 240, "duration": 5}}
+		}
+	`)
+	defer stop()
+	msg, err := c.SendVideoNote(tbot.ChatID(123), "note", tbot.OptLength(240), tbot.OptDuration(5))
+	if err != nil {
+		t.Fatalf("error on sendVideoNote: %v", err)
+	}
+	if msg.VideoNote == nil || msg.VideoNote.Length != 240 {
+		t.Fatalf("unexpected video note: %+v", msg.VideoNote)
+	}
+	req := <-requests
+	if req.params.Get("video_note") != "note" || req.params.Get("length") != "240" {
+		t.Fatalf("unexpected params: %v", req.params)
+	}
+
+	_, err = c.SendVideoNote(tbot.ChatID(123), "https://example.com/note.mp4")
+	if err == nil || !strings.Contains(err.Error(), "URL") {
+		t.Fatalf("expected URL error, got %v", err)
+	}
+	if len(requests) != 0 {
+		t.Fatalf("request with URL should not be sent")
+	}
+}
+
+func TestAnswerCallbackQueryOptions(t *testing.T) {
+	c, requests, stop := testRecorder(t, `{"ok": true, "result": true}`)
+	defer stop()
+	err := c.AnswerCallbackQuery("cq", tbot.OptText("Are you sure?"), tbot.OptShowAlert,
+		tbot.OptURL("https://t.me/mybot?game=tetris"), tbot.OptCacheTime(90*time.Second))
+	if err != nil {
+		t.Fatalf("error on answerCallbackQuery: %v", err)
+	}
+	req := <-requests
+	expected := map[string]string{
+		"callback_query_id": "cq",
+		"text":              "Are you sure?",
+		"show_alert":        "true",
+		"url":               "https://t.me/mybot?game=tetris",
+		"cache_time":        "90",
+	}
+	for k, v := range expected {
+		if req.params.Get(k) != v {
+			t.Fatalf("unexpected %s: %q, expected %q", k, req.params.Get(k), v)
+		}
+	}
+}
+
+func TestSendAnimation(t *testing.T) {
+	c, requests, stop := testRecorder(t, `
+		{
+			"ok": true,
+			"result": {
+				"message_id": 321,
+				"animation": {"file_id": "gif", "width": 320, "height": 240, "duration": 2},
+				"document": {"file_id": "gif", "file_name": "cat.gif.mp4"}
+			}
+		}
+	`)
+	defer stop()
+	msg, err := c.SendAnimation(tbot.ChatID(123), "https://example.com/cat.gif",
+		tbot.OptHasSpoiler, tbot.OptCaption("cat"))
+	if err != nil {
+		t.Fatalf("error on sendAnimation: %v", err)
+	}
+	if msg.Animation == nil || msg.Animation.Width != 320 || msg.Animation.Duration != 2 {
+		t.Fatalf("unexpected animation: %+v", msg.Animation)
+	}
+	if msg.Document == nil || msg.Document.FileID != "gif" {
+		t.Fatalf("unexpected document: %+v", msg.Document)
+	}
+	req := <-requests
+	if req.multipart || req.params.Get("animation") != "https://example.com/cat.gif" || req.params.Get("has_spoiler") != "true" {
+		t.Fatalf("unexpected request: %v", req.params)
+	}
+}
+
+func TestSendAnimationUpload(t *testing.T) {
+	c, requests, stop := testRecorder(t, `
+		{
+			"ok": true,
+			"result": {"message_id": 321, "animation": {"file_id": "gif"}, "document": {"file_id": "gif"}}
+		}
+	`)
+	defer stop()
+	msg, err := c.SendAnimation(tbot.ChatID(123), tbot.InputFileReader("cat.gif", strings.NewReader("gif data")),
+		tbot.OptWidth(320), tbot.OptHeight(240))
+	if err != nil {
+		t.Fatalf("error on sendAnimation: %v", err)
+	}
+	if msg.Animation == nil || msg.Document == nil {
+		t.Fatalf("animation and document should be set")
+	}
+	req := <-requests
+	if req.files["animation"] != "gif data" || req.params.Get("width") != "320" {
+		t.Fatalf("unexpected request: %v %v", req.params, req.files)
+	}
+}
+
+func TestSendSticker(t *testing.T) {
+	c, requests, stop := testRecorder(t, `
+		{
+			"ok": true,
+			"result": {"message_id": 321, "sticker": {"file_id": "sticker", "emoji": "🐈"}}
+		}
+	`)
+	defer stop()
+	msg, err := c.SendSticker(tbot.ChatID(123), "sticker")
+	if err != nil {
+		t.Fatalf("error on sendSticker: %v", err)
+	}
+	if msg.Sticker == nil || msg.Sticker.Emoji != "🐈" {
+		t.Fatalf("unexpected sticker: %+v", msg.Sticker)
+	}
+	req := <-requests
+	if req.multipart || req.params.Get("sticker") != "sticker" {
+		t.Fatalf("unexpected request: %v", req.params)
+	}
+}
+
+func TestSendStickerUpload(t *testing.T) {
+	c, requests, stop := testRecorder(t, `{"ok": true, "result": {"message_id": 321, "sticker": {"file_id": "sticker"}}}`)
+	defer stop()
+	_, err := c.SendSticker(tbot.ChatID(123), tbot.InputFileReader("cat.webp", strings.NewReader("webp data")),
+		tbot.OptEmoji("🐈"))
+	if err != nil {
+		t.Fatalf("error on sendSticker: %v", err)
+	}
+	req := <-requests
+	if req.files["sticker"] != "webp data" || req.filenames["sticker"] != "cat.webp" || req.params.Get("emoji") != "🐈" {
+		t.Fatalf("unexpected request: %v %v", req.params, req.filenames)
+	}
+}
+
+func TestSendLocation(t *testing.T) {
+	c, requests, stop := testRecorder(t, `
+		{
+			"ok": true,
+			"result": {
+				"message_id": 321,
+				"location": {"latitude": 50.45, "longitude": 30.52, "live_period": 900, "heading": 90}
+			}
+		}
+	`)
+	defer stop()
+	msg, err := c.SendLocation(tbot.ChatID(123), 50.45, 30.52, tbot.OptLivePeriod(900),
+		tbot.OptHorizontalAccuracy(12.5), tbot.OptHeading(90), tbot.OptProximityAlertRadius(100))
+	if err != nil {
+		t.Fatalf("error on sendLocation: %v", err)
+	}
+	if msg.MessageID != 321 || msg.Location == nil || msg.Location.LivePeriod != 900 || msg.Location.Heading != 90 {
+		t.Fatalf("unexpected message: %+v", msg)
+	}
+	req := <-requests
+	expected := url.Values{
+		"chat_id":                {"123"},
+		"latitude":               {"50.45"},
+		"longitude":              {"30.52"},
+		"live_period":            {"900"},
+		"horizontal_accuracy":    {"12.5"},
+		"heading":                {"90"},
+		"proximity_alert_radius": {"100"},
+	}
+	if req.method != "sendLocation" || !reflect.DeepEqual(req.params, expected) {
+		t.Fatalf("unexpected request %s: %v", req.method, req.params)
+	}
+}
+
+func TestSendChatAction(t *testing.T) {
+	c, requests, stop := testRecorder(t, `{"ok": true, "result": true}`)
+	defer stop()
+	actions := map[string]string{
+		"typing":            string(tbot.ActionTyping),
+		"upload_photo":      string(tbot.ActionUploadPhoto),
+		"record_video":      string(tbot.ActionRecordVideo),
+		"upload_video":      string(tbot.ActionUploadVideo),
+		"record_voice":      string(tbot.ActionRecordVoice),
+		"upload_voice":      string(tbot.ActionUploadVoice),
+		"upload_document":   string(tbot.ActionUploadDocument),
+		"choose_sticker":    string(tbot.ActionChooseSticker),
+		"find_location":     string(tbot.ActionFindLocation),
+		"record_video_note": string(tbot.ActionRecordVideoNote),
+		"upload_video_note": string(tbot.ActionUploadVideoNote),
+	}
+	for expected, action := range actions {
+		if action != expected {
+			t.Fatalf("expected action %s, got %s", expected, action)
+		}
+	}
+	err := c.SendChatAction(tbot.ChatID(123), tbot.ActionChooseSticker, tbot.OptMessageThreadID(7))
+	if err != nil {
+		t.Fatalf("error on sendChatAction: %v", err)
+	}
+	req := <-requests
+	if req.params.Get("action") != "choose_sticker" || req.params.Get("message_thread_id") != "7" {
+		t.Fatalf("unexpected request: %v", req.params)
+	}
+}
+
+func TestEditMessageLiveLocation(t *testing.T) {
+	c, requests, stop := testRecorder(t, `
+		{
+			"ok": true,
+			"result": {"message_id": 321, "location": {"latitude": 50.46, "longitude": 30.53, "heading": 180}}
+		}
+	`)
+	defer stop()
+	msg, err := c.EditMessageLiveLocation(tbot.ChatID(123), 321, 50.46, 30.53,
+		tbot.OptHeading(180), tbot.OptProximityAlertRadius(50))
+	if err != nil {
+		t.Fatalf("error on editMessageLiveLocation: %v", err)
+	}
+	if msg.Location == nil || msg.Location.Heading != 180 {
+		t.Fatalf("unexpected location: %+v", msg.Location)
+	}
+	req := <-requests
+	if req.params.Get("message_id") != "321" || req.params.Get("heading") != "180" ||
+		req.params.Get("proximity_alert_radius") != "50" || req.params.Get("latitude") != "50.46" {
+		t.Fatalf("unexpected request: %v", req.params)
+	}
+}
+
+func TestEditInlineMessageLiveLocation(t *testing.T) {
+	c, requests, stop := testRecorder(t, `{"ok": true, "result": true}`)
+	defer stop()
+	err := c.EditInlineMessageLiveLocation("inline", 50.46, 30.53, tbot.OptHeading(90))
+	if err != nil {
+		t.Fatalf("error on editMessageLiveLocation: %v", err)
+	}
+	req := <-requests
+	if req.params.Get("inline_message_id") != "inline" || req.params.Get("chat_id") != "" || req.params.Get("heading") != "90" {
+		t.Fatalf("unexpected request: %v", req.params)
+	}
+	err = c.StopInlineMessageLiveLocation("inline")
+	if err != nil {
+		t.Fatalf("error on stopMessageLiveLocation: %v", err)
+	}
+	req = <-requests
+	if req.method != "stopMessageLiveLocation" || req.params.Get("inline_message_id") != "inline" {
+		t.Fatalf("unexpected request %s: %v", req.method, req.params)
+	}
+}
+
+func TestStopMessageLiveLocation(t *testing.T) {
+	c, requests, stop := testRecorder(t, `{"ok": true, "result": {"message_id": 321, "location": {"latitude": 50.46, "longitude": 30.53}}}`)
+	defer stop()
+	msg, err := c.StopMessageLiveLocation(tbot.ChatID(123), 321)
+	if err != nil {
+		t.Fatalf("error on stopMessageLiveLocation: %v", err)
+	}
+	if msg.MessageID != 321 || msg.Location == nil {
+		t.Fatalf("unexpected message: %+v", msg)
+	}
+	req := <-requests
+	if req.params.Get("chat_id") != "123" || req.params.Get("message_id") != "321" {
+		t.Fatalf("unexpected request: %v", req.params)
+	}
+}
+
+func TestSendVenue(t *testing.T) {
+	c, requests, stop := testRecorder(t, `
+		{
+			"ok": true,
+			"result": {
+				"message_id": 321,
+				"location": {"latitude": 50.45, "longitude": 30.52},
+				"venue": {
+					"location": {"latitude": 50.45, "longitude": 30.52},
+					"title": "Cafe",
+					"address": "Main st. 1",
+					"google_place_id": "place",
+					"google_place_type": "cafe"
+				}
+			}
+		}
+	`)
+	defer stop()
+	msg, err := c.SendVenue(tbot.ChatID(123), 50.45, 30.52, "Cafe", "Main st. 1",
+		tbot.OptGooglePlaceID("place"), tbot.OptGooglePlaceType("cafe"), tbot.OptDisableNotification)
+	if err != nil {
+		t.Fatalf("error on sendVenue: %v", err)
+	}
+	if msg.Venue == nil || msg.Venue.Title != "Cafe" || msg.Venue.GooglePlaceID != "place" || msg.Venue.Location.Latitude != 50.45 {
+		t.Fatalf("unexpected venue: %+v", msg.Venue)
+	}
+	req := <-requests
+	expected := url.Values{
+		"chat_id":              {"123"},
+		"latitude":             {"50.45"},
+		"longitude":            {"30.52"},
+		"title":                {"Cafe"},
+		"address":              {"Main st. 1"},
+		"google_place_id":      {"place"},
+		"google_place_type":    {"cafe"},
+		"disable_notification": {"true"},
+	}
+	if req.method != "sendVenue" || !reflect.DeepEqual(req.params, expected) {
+		t.Fatalf("unexpected request %s: %v", req.method, req.params)
+	}
+}
+
+func TestSendContact(t *testing.T) {
+	c, requests, stop := testRecorder(t, `
+		{
+			"ok": true,
+			"result": {
+				"message_id": 321,
+				"contact": {"phone_number": "+380441234567", "first_name": "Support", "last_name": "Team", "vcard": "BEGIN:VCARD"}
+			}
+		}
+	`)
+	defer stop()
+	msg, err := c.SendContact(tbot.ChatID(123), "+380441234567", "Support",
+		tbot.OptLastName("Team"), tbot.OptVCard("BEGIN:VCARD"), tbot.OptReplyToMessageID(5))
+	if err != nil {
+		t.Fatalf("error on sendContact: %v", err)
+	}
+	if msg.Contact == nil || msg.Contact.LastName != "Team" || msg.Contact.VCard != "BEGIN:VCARD" {
+		t.Fatalf("unexpected contact: %+v", msg.Contact)
+	}
+	req := <-requests
+	expected := url.Values{
+		"chat_id":             {"123"},
+		"phone_number":        {"+380441234567"},
+		"first_name":          {"Support"},
+		"last_name":           {"Team"},
+		"vcard":               {"BEGIN:VCARD"},
+		"reply_to_message_id": {"5"},
+	}
+	if req.method != "sendContact" || !reflect.DeepEqual(req.params, expected) {
+		t.Fatalf("unexpected request %s: %v", req.method, req.params)
+	}
+}
+
+func TestSendPoll(t *testing.T) {
+	c, requests, stop := testRecorder(t, `
+		{
+			"ok": true,
+			"result": {
+				"message_id": 321,
+				"poll": {
+					"id": "poll1",
+					"question": "2+2?",
+					"options": [{"text": "3", "voter_count": 0}, {"text": "4", "voter_count": 0}],
+					"type": "quiz",
+					"correct_option_id": 1,
+					"explanation": "math",
+					"open_period": 60
+				}
+			}
+		}
+	`)
+	defer stop()
+	msg, err := c.SendPoll(tbot.ChatID(123), "2+2?", []string{"3", "4"},
+		tbot.OptPollType(tbot.PollTypeQuiz), tbot.OptCorrectOptionID(1), tbot.OptNotAnonymous,
+		tbot.OptExplanation("<b>math</b>"), tbot.OptExplanationParseModeHTML, tbot.OptOpenPeriod(time.Minute))
+	if err != nil {
+		t.Fatalf("error on sendPoll: %v", err)
+	}
+	if msg.Poll == nil || msg.Poll.ID != "poll1" || msg.Poll.CorrectOptionID != 1 || msg.Poll.OpenPeriod != 60 {
+		t.Fatalf("unexpected poll: %+v", msg.Poll)
+	}
+	req := <-requests
+	expected := url.Values{
+		"chat_id":                {"123"},
+		"question":               {"2+2?"},
+		"options":                {`["3","4"]`},
+		"type":                   {"quiz"},
+		"correct_option_id":      {"1"},
+		"is_anonymous":           {"false"},
+		"explanation":            {"<b>math</b>"},
+		"explanation_parse_mode": {"HTML"},
+		"open_period":            {"60"},
+	}
+	if !reflect.DeepEqual(req.params, expected) {
+		t.Fatalf("unexpected request: %v", req.params)
+	}
+}
+
+func TestSendPollQuizValidation(t *testing.T) {
+	c, requests, stop := testRecorder(t, `{"ok": true, "result": {"message_id": 321}}`)
+	defer stop()
+	options := []string{"3", "4"}
+	quiz := tbot.OptPollType(tbot.PollTypeQuiz)
+	_, err := c.SendPoll(tbot.ChatID(123), "2+2?", options, quiz)
+	if err == nil {
+		t.Fatalf("expected error for quiz without correct option")
+	}
+	for _, id := range []int{2, -1} {
+		_, err = c.SendPoll(tbot.ChatID(123), "2+2?", options, quiz, tbot.OptCorrectOptionID(id))
+		if err == nil {
+			t.Fatalf("expected error for correct option %d", id)
+		}
+	}
+	_, err = c.SendPoll(tbot.ChatID(123), "2+2?", options, tbot.OptCorrectOptionID(5))
+	if err != nil {
+		t.Fatalf("regular poll should not be validated: %v", err)
+	}
+	if len(requests) != 1 {
+		t.Fatalf("invalid quizzes should not be sent")
+	}
+}
+
+func TestStopPoll(t *testing.T) {
+	c, requests, stop := testRecorder(t, `
+		{
+			"ok": true,
+			"result": {"id": "poll1", "question": "2+2?", "is_closed": true, "total_voter_count": 3}
+		}
+	`)
+	defer stop()
+	poll, err := c.StopPoll(tbot.ChatID(123), 321)
+	if err != nil {
+		t.Fatalf("error on stopPoll: %v", err)
+	}
+	if !poll.IsClosed || poll.TotalVoterCount != 3 {
+		t.Fatalf("unexpected poll: %+v", poll)
+	}
+	req := <-requests
+	if req.method != "stopPoll" || req.params.Get("chat_id") != "123" || req.params.Get("message_id") != "321" {
+		t.Fatalf("unexpected request %s: %v", req.method, req.params)
+	}
+}
+
+func TestSendInvoice(t *testing.T) {
+	c, requests, stop := testRecorder(t, `
+		{
+			"ok": true,
+			"result": {
+				"message_id": 321,
+				"invoice": {"title": "Pizza", "description": "Large", "currency": "USD", "total_amount": 1500}
+			}
+		}
+	`)
+	defer stop()
+	prices := []tbot.LabeledPrice{{Label: "Pizza", Amount: 1200}, {Label: "Delivery", Amount: 300}}
+	msg, err := c.SendInvoice(tbot.ChatID(123), "Pizza", "Large", "order-1", "provider", "USD", prices,
+		tbot.OptMaxTipAmount(500), tbot.OptSuggestedTipAmounts([]int{100, 200}), tbot.OptNeedShippingAddress,
+		tbot.OptIsFlexible, tbot.OptStartParameter("pizza"))
+	if err != nil {
+		t.Fatalf("error on sendInvoice: %v", err)
+	}
+	if msg.Invoice == nil || msg.Invoice.TotalAmount != 1500 {
+		t.Fatalf("unexpected invoice: %+v", msg.Invoice)
+	}
+	req := <-requests
+	expected := url.Values{
+		"chat_id":               {"123"},
+		"title":                 {"Pizza"},
+		"description":           {"Large"},
+		"payload":               {"order-1"},
+		"provider_token":        {"provider"},
+		"currency":              {"USD"},
+		"prices":                {`[{"label":"Pizza","amount":1200},{"label":"Delivery","amount":300}]`},
+		"max_tip_amount":        {"500"},
+		"suggested_tip_amounts": {"[100,200]"},
+		"need_shipping_address": {"true"},
+		"is_flexible":           {"true"},
+		"start_parameter":       {"pizza"},
+	}
+	if req.method != "sendInvoice" || !reflect.DeepEqual(req.params, expected) {
+		t.Fatalf("unexpected request %s: %v", req.method, req.params)
+	}
+}
+
+func TestAnswerShippingQuery(t *testing.T) {
+	c, requests, stop := testRecorder(t, `{"ok": true, "result": true}`)
+	defer stop()
+	options := []tbot.ShippingOption{
+		{ID: "courier", Title: "Courier", Prices: []tbot.LabeledPrice{{Label: "Delivery", Amount: 300}}},
+	}
+	err := c.AnswerShippingQuery("query", true, tbot.OptShippingOptions(options))
+	if err != nil {
+		t.Fatalf("error on answerShippingQuery: %v", err)
+	}
+	req := <-requests
+	expected := url.Values{
+		"shipping_query_id": {"query"},
+		"ok":                {"true"},
+		"shipping_options":  {`[{"id":"courier","title":"Courier","prices":[{"label":"Delivery","amount":300}]}]`},
+	}
+	if !reflect.DeepEqual(req.params, expected) {
+		t.Fatalf("unexpected request: %v", req.params)
+	}
+	err = c.AnswerShippingQuery("query", false, tbot.OptErrorMessage("No delivery to Antarctica"))
+	if err != nil {
+		t.Fatalf("error on answerShippingQuery: %v", err)
+	}
+	req = <-requests
+	if req.params.Get("ok") != "false" || req.params.Get("error_message") != "No delivery to Antarctica" {
+		t.Fatalf("unexpected request: %v", req.params)
+	}
+}
+
+func TestAnswerShippingQueryValidation(t *testing.T) {
+	c, requests, stop := testRecorder(t, `{"ok": true, "result": true}`)
+	defer stop()
+	options := tbot.OptShippingOptions([]tbot.ShippingOption{{ID: "courier", Title: "Courier"}})
+	errorMessage := tbot.OptErrorMessage("No delivery")
+	if err := c.AnswerShippingQuery("query", true); err == nil {
+		t.Fatalf("expected error for ok without options")
+	}
+	if err := c.AnswerShippingQuery("query", true, options, errorMessage); err == nil {
+		t.Fatalf("expected error for ok with error message")
+	}
+	if err := c.AnswerShippingQuery("query", false); err == nil {
+		t.Fatalf("expected error for not ok without error message")
+	}
+	if err := c.AnswerShippingQuery("query", false, options, errorMessage); err == nil {
+		t.Fatalf("expected error for not ok with options")
+	}
+	if len(requests) != 0 {
+		t.Fatalf("invalid answers should not be sent")
+	}
+}
+
+func TestSetChatPermissions(t *testing.T) {
+	c, requests, stop := testRecorder(t, `{"ok": true, "result": true}`)
+	defer stop()
+	tt := []struct {
+		name        string
+		permissions *tbot.ChatPermissions
+		expected    string
+	}{
+		{
+			name:        "read-only",
+			permissions: tbot.NewChatPermissions(),
+			expected: `{"can_send_messages":false,"can_send_audios":false,"can_send_documents":false,` +
+				`"can_send_photos":false,"can_send_videos":false,"can_send_video_notes":false,` +
+				`"can_send_voice_notes":false,"can_send_polls":false,"can_send_other_messages":false,` +
+				`"can_add_web_page_previews":false,"can_change_info":false,"can_invite_users":false,` +
+				`"can_pin_messages":false,"can_manage_topics":false}`,
+		},
+		{
+			name:        "media-only",
+			permissions: tbot.NewChatPermissions().AllowSendMedia(),
+			expected: `{"can_send_messages":false,"can_send_audios":true,"can_send_documents":true,` +
+				`"can_send_photos":true,"can_send_videos":true,"can_send_video_notes":true,` +
+				`"can_send_voice_notes":true,"can_send_polls":false,"can_send_other_messages":false,` +
+				`"can_add_web_page_previews":false,"can_change_info":false,"can_invite_users":false,` +
+				`"can_pin_messages":false,"can_manage_topics":false}`,
+		},
+	}
+	for _, tc := range tt {
+		err := c.SetChatPermissions(tbot.ChatID(123), tc.permissions, tbot.OptUseIndependentChatPermissions)
+		if err != nil {
+			t.Fatalf("%s: error on setChatPermissions: %v", tc.name, err)
+		}
+		req := <-requests
+		if req.params.Get("permissions") != tc.expected {
+			t.Fatalf("%s: unexpected permissions: %s", tc.name, req.params.Get("permissions"))
+		}
+		if req.params.Get("chat_id") != "123" || req.params.Get("use_independent_chat_permissions") != "true" {
+			t.Fatalf("%s: unexpected request: %v", tc.name, req.params)
+		}
+	}
+	if err := c.SetChatPermissions(tbot.ChatID(123), nil); err == nil {
+		t.Fatalf("expected error for nil permissions")
+	}
+}
+
+func TestAnswerPreCheckoutQuery(t *testing.T) {
+	c, requests, stop := testRecorder(t, `{"ok": true, "result": true}`)
+	defer stop()
+	err := c.AnswerPreCheckoutQuery("query", true)
+	if err != nil {
+		t.Fatalf("error on answerPreCheckoutQuery: %v", err)
+	}
+	req := <-requests
+	expected := url.Values{"pre_checkout_query_id": {"query"}, "ok": {"true"}}
+	if req.method != "answerPreCheckoutQuery" || !reflect.DeepEqual(req.params, expected) {
+		t.Fatalf("unexpected request %s: %v", req.method, req.params)
+	}
+	err = c.AnswerPreCheckoutQuery("query", false, tbot.OptErrorMessage("Out of stock"))
+	if err != nil {
+		t.Fatalf("error on answerPreCheckoutQuery: %v", err)
+	}
+	req = <-requests
+	expected = url.Values{"pre_checkout_query_id": {"query"}, "ok": {"false"}, "error_message": {"Out of stock"}}
+	if !reflect.DeepEqual(req.params, expected) {
+		t.Fatalf("unexpected request: %v", req.params)
+	}
+	err = c.AnswerPreCheckoutQuery("query", false)
+	if err == nil {
+		t.Fatalf("expected error for decline without error message")
+	}
+	if len(requests) != 0 {
+		t.Fatalf("invalid answer should not be sent")
+	}
+}
+
+func TestGetStickerSet(t *testing.T) {
+	c, requests, stop := testRecorder(t, `
+		{
+			"ok": true,
+			"result": {
+				"name": "cats_by_bot",
+				"title": "Cats",
+				"is_video": true,
+				"stickers": [
+					{"file_id": "cat1", "emoji": "🐈", "set_name": "cats_by_bot", "is_video": true},
+					{"file_id": "cat2", "emoji": "😺", "set_name": "cats_by_bot", "is_video": true}
+				]
+			}
+		}
+	`)
+	defer stop()
+	set, err := c.GetStickerSet("cats_by_bot")
+	if err != nil {
+		t.Fatalf("error on getStickerSet: %v", err)
+	}
+	if !set.IsVideo || len(set.Stickers) != 2 {
+		t.Fatalf("unexpected sticker set: %+v", set)
+	}
+	if sticker := set.Stickers[1]; sticker.Emoji != "😺" || sticker.SetName != "cats_by_bot" || !sticker.IsVideo {
+		t.Fatalf("unexpected sticker: %+v", sticker)
+	}
+	req := <-requests
+	if req.params.Get("name") != "cats_by_bot" {
+		t.Fatalf("unexpected request: %v", req.params)
+	}
+}
+
+func TestAddStickerToSetFile(t *testing.T) {
+	c, requests, stop := testRecorder(t, `{"ok": true, "result": true}`)
+	defer stop()
+	f, err := ioutil.TempFile("", "sticker*.webm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	f.WriteString("webm data")
+	f.Close()
+	err = c.AddStickerToSetFile(1, "cats_by_bot", f.Name(), "🐈", tbot.OptVideoSticker)
+	if err != nil {
+		t.Fatalf("error on addStickerToSet: %v", err)
+	}
+	req := <-requests
+	if req.files["webm_sticker"] != "webm data" || req.params.Get("webm_sticker") != "" || req.params.Get("emojis") != "🐈" {
+		t.Fatalf("unexpected request: %v %v", req.params, req.files)
+	}
+}
+
+func TestCreateInvoiceLink(t *testing.T) {
+	c, requests, stop := testRecorder(t, `{"ok": true, "result": "https://t.me/$invoice"}`)
+	defer stop()
+	prices := []tbot.LabeledPrice{{Label: "Pizza", Amount: 1200}}
+	link, err := c.CreateInvoiceLink("Pizza", "Large", "order-1", "provider", "USD", prices, tbot.OptNeedName)
+	if err != nil {
+		t.Fatalf("error on createInvoiceLink: %v", err)
+	}
+	if link != "https://t.me/$invoice" {
+		t.Fatalf("unexpected link: %s", link)
+	}
+	req := <-requests
+	if req.method != "createInvoiceLink" || req.params.Get("chat_id") != "" || req.params.Get("currency") != "USD" ||
+		req.params.Get("need_name") != "true" || req.params.Get("prices") != `[{"label":"Pizza","amount":1200}]` {
+		t.Fatalf("unexpected request %s: %v", req.method, req.params)
+	}
+}
+
+func TestCreateInvoiceLinkError(t *testing.T) {
+	c, stop := testClientStatus(t, http.StatusBadRequest, `{"ok": false, "error_code": 400, "description": "Bad Request: CURRENCY_INVALID"}`)
+	defer stop()
+	_, err := c.CreateInvoiceLink("Pizza", "Large", "order-1", "provider", "XXX", nil)
+	apiErr, ok := err.(*tbot.APIError)
+	if !ok || apiErr.Code != 400 || apiErr.Method != "createInvoiceLink" {
+		t.Fatalf("expected API error, got %v", err)
+	}
+}
+
+func TestSetMessageReaction(t *testing.T) {
+	c, requests, stop := testRecorder(t, `{"ok": true, "result": true}`)
+	defer stop()
+	err := c.SetMessageReaction(tbot.ChatID(123), 321, []tbot.ReactionType{tbot.ReactionTypeEmoji{Emoji: "👍"}}, tbot.OptBigReaction)
+	if err != nil {
+		t.Fatalf("error on setMessageReaction: %v", err)
+	}
+	req := <-requests
+	expected := url.Values{
+		"chat_id":    {"123"},
+		"message_id": {"321"},
+		"reaction":   {`[{"type":"emoji","emoji":"👍"}]`},
+		"is_big":     {"true"},
+	}
+	if req.method != "setMessageReaction" || !reflect.DeepEqual(req.params, expected) {
+		t.Fatalf("unexpected request %s: %v", req.method, req.params)
+	}
+	err = c.SetMessageReaction(tbot.ChatID(123), 321, nil)
+	if err != nil {
+		t.Fatalf("error on setMessageReaction: %v", err)
+	}
+	req = <-requests
+	if req.params.Get("reaction") != "[]" {
+		t.Fatalf("unexpected reaction: %s", req.params.Get("reaction"))
+	}
+}
+
+func TestForwardMessageToChannel(t *testing.T) {
+	c, requests, stop := testRecorder(t, `
+		{
+			"ok": true,
+			"result": {
+				"message_id": 5,
+				"chat": {"id": -100500, "type": "channel"},
+				"forward_from": {"id": 7, "first_name": "Reporter"},
+				"text": "flagged"
+			}
+		}
+	`)
+	defer stop()
+	msg, err := c.ForwardMessage(tbot.ChatName("@moderators"), tbot.ChatID(123), 321,
+		tbot.OptDisableNotification, tbot.OptProtectContent)
+	if err != nil {
+		t.Fatalf("error on forwardMessage: %v", err)
+	}
+	if msg.ForwardFrom == nil || msg.ForwardFrom.ID != 7 || msg.Text != "flagged" {
+		t.Fatalf("unexpected message: %+v", msg)
+	}
+	req := <-requests
+	expected := url.Values{
+		"chat_id":              {"@moderators"},
+		"from_chat_id":         {"123"},
+		"message_id":           {"321"},
+		"disable_notification": {"true"},
+		"protect_content":      {"true"},
+	}
+	if req.method != "forwardMessage" || !reflect.DeepEqual(req.params, expected) {
+		t.Fatalf("unexpected request %s: %v", req.method, req.params)
+	}
+}
+
+func TestGetUserProfilePhotos(t *testing.T) {
+	c, requests, stop := testRecorder(t, `
+		{
+			"ok": true,
+			"result": {
+				"total_count": 5,
+				"photos": [
+					[
+						{"file_id": "new_s", "file_unique_id": "ns", "width": 160, "height": 160, "file_size": 8000},
+						{"file_id": "new_m", "file_unique_id": "nm", "width": 320, "height": 320, "file_size": 20000},
+						{"file_id": "new_l", "file_unique_id": "nl", "width": 640, "height": 640, "file_size": 60000}
+					],
+					[
+						{"file_id": "old_s", "file_unique_id": "os", "width": 160, "height": 160, "file_size": 7000},
+						{"file_id": "old_l", "file_unique_id": "ol", "width": 640, "height": 640, "file_size": 50000}
+					]
+				]
+			}
+		}
+	`)
+	defer stop()
+	photos, err := c.GetUserProfilePhotos(7, tbot.OptOffset(1), tbot.OptLimit(2))
+	if err != nil {
+		t.Fatalf("error on getUserProfilePhotos: %v", err)
+	}
+	if photos.TotalCount != 5 || len(photos.Photos) != 2 || len(photos.Photos[0]) != 3 || len(photos.Photos[1]) != 2 {
+		t.Fatalf("unexpected photos: %+v", photos)
+	}
+	largest := photos.Photos[0][len(photos.Photos[0])-1]
+	if largest.FileID != "new_l" || largest.Width != 640 || largest.FileSize != 60000 {
+		t.Fatalf("unexpected photo size: %+v", largest)
+	}
+	req := <-requests
+	expected := url.Values{"user_id": {"7"}, "offset": {"1"}, "limit": {"2"}}
+	if !reflect.DeepEqual(req.params, expected) {
+		t.Fatalf("unexpected request: %v", req.params)
+	}
+}
+
+func TestCopyMessage(t *testing.T) {
+	c, requests, stop := testRecorder(t, `{"ok": true, "result": {"message_id": 77}}`)
+	defer stop()
+	id, err := c.CopyMessage(tbot.ChatID(100), tbot.ChatID(123), 321, tbot.OptProtectContent)
+	if err != nil {
+		t.Fatalf("error on copyMessage: %v", err)
+	}
+	if id != 77 {
+		t.Fatalf("unexpected message id: %d", id)
+	}
+	req := <-requests
+	expected := url.Values{
+		"chat_id":         {"100"},
+		"from_chat_id":    {"123"},
+		"message_id":      {"321"},
+		"protect_content": {"true"},
+	}
+	if req.method != "copyMessage" || !reflect.DeepEqual(req.params, expected) {
+		t.Fatalf("unexpected request %s: %v", req.method, req.params)
+	}
+
+	_, err = c.CopyMessage(tbot.ChatID(100), tbot.ChatID(123), 321, tbot.OptCaption("<i>anonymous</i>"), tbot.OptParseModeHTML)
+	if err != nil {
+		t.Fatalf("error on copyMessage: %v", err)
+	}
+	req = <-requests
+	if req.params.Get("caption") != "<i>anonymous</i>" || req.params.Get("parse_mode") != "HTML" {
+		t.Fatalf("unexpected request: %v", req.params)
+	}
+}
+
+func TestChatSettings(t *testing.T) {
+	c, requests, stop := testRecorder(t, `{"ok": true, "result": true}`)
+	defer stop()
+	err := c.SetChatTitle(tbot.ChatID(123), "Gophers")
+	if err != nil {
+		t.Fatalf("error on setChatTitle: %v", err)
+	}
+	req := <-requests
+	if req.method != "setChatTitle" || req.params.Get("chat_id") != "123" || req.params.Get("title") != "Gophers" {
+		t.Fatalf("unexpected request %s: %v", req.method, req.params)
+	}
+	err = c.SetChatDescription(tbot.ChatID(123), "Go discussions")
+	if err != nil {
+		t.Fatalf("error on setChatDescription: %v", err)
+	}
+	req = <-requests
+	if req.method != "setChatDescription" || req.params.Get("description") != "Go discussions" {
+		t.Fatalf("unexpected request %s: %v", req.method, req.params)
+	}
+	err = c.SetChatPhoto(tbot.ChatID(123), tbot.InputFileReader("gopher.png", strings.NewReader("png data")))
+	if err != nil {
+		t.Fatalf("error on setChatPhoto: %v", err)
+	}
+	req = <-requests
+	if req.method != "setChatPhoto" || !req.multipart || req.params.Get("chat_id") != "123" ||
+		req.files["photo"] != "png data" || req.filenames["photo"] != "gopher.png" {
+		t.Fatalf("unexpected request %s: %v %v", req.method, req.params, req.filenames)
+	}
+	err = c.DeleteChatPhoto(tbot.ChatID(123))
+	if err != nil {
+		t.Fatalf("error on deleteChatPhoto: %v", err)
+	}
+	req = <-requests
+	if req.method != "deleteChatPhoto" || req.params.Get("chat_id") != "123" {
+		t.Fatalf("unexpected request %s: %v", req.method, req.params)
+	}
+}
+
+func TestChatSettingsLimits(t *testing.T) {
+	c, requests, stop := testRecorder(t, `{"ok": true, "result": true}`)
+	defer stop()
+	// 128 characters, 256 bytes
+	title := strings.Repeat("й", tbot.MaxChatTitleLength)
+	if err := c.SetChatTitle(tbot.ChatID(123), title); err != nil {
+		t.Fatalf("error on setChatTitle: %v", err)
+	}
+	if req := <-requests; req.params.Get("title") != title {
+		t.Fatalf("unexpected title: %s", req.params.Get("title"))
+	}
+	if err := c.SetChatDescription(tbot.ChatID(123), ""); err != nil {
+		t.Fatalf("error on setChatDescription: %v", err)
+	}
+	if req := <-requests; req.method != "setChatDescription" || req.params["description"] == nil {
+		t.Fatalf("empty description must be sent: %v", req.params)
+	}
+	if err := c.SetChatTitle(tbot.ChatID(123), ""); err == nil {
+		t.Fatalf("expected error for empty title")
+	}
+	if err := c.SetChatTitle(tbot.ChatID(123), title+"й"); err == nil {
+		t.Fatalf("expected error for long title")
+	}
+	if err := c.SetChatDescription(tbot.ChatID(123), strings.Repeat("a", tbot.MaxChatDescriptionLength+1)); err == nil {
+		t.Fatalf("expected error for long description")
+	}
+	if len(requests) != 0 {
+		t.Fatalf("invalid requests should not be sent")
+	}
+}
+
+func TestSetChatPhotoUploadOnly(t *testing.T) {
+	c, requests, stop := testRecorder(t, `{"ok": true, "result": true}`)
+	defer stop()
+	if err := c.SetChatPhoto(tbot.ChatID(123), nil); err == nil {
+		t.Fatalf("expected error for nil photo")
+	}
+	if err := c.SetChatPhoto(tbot.ChatID(123), tbot.InputFilePath("https://example.com/photo.png")); err == nil {
+		t.Fatalf("expected error for URL photo")
+	}
+	if err := c.SetChatPhoto(tbot.ChatID(123), tbot.InputFilePath("AgACAgIAAxkBAAIBZ2")); err == nil {
+		t.Fatalf("expected error for file_id photo")
+	}
+	if len(requests) != 0 {
+		t.Fatalf("invalid requests should not be sent")
+	}
+	f, err := ioutil.TempFile("", "photo*.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	f.WriteString("png data")
+	f.Close()
+	if err := c.SetChatPhoto(tbot.ChatID(123), tbot.InputFilePath(f.Name())); err != nil {
+		t.Fatalf("error on setChatPhoto: %v", err)
+	}
+	if req := <-requests; req.files["photo"] != "png data" {
+		t.Fatalf("unexpected files: %v", req.files)
+	}
+}
+
+func TestChatSettingsNotEnoughRights(t *testing.T) {
+	c, stop := testClientStatus(t, http.StatusBadRequest,
+		`{"ok": false, "error_code": 400, "description": "Bad Request: not enough rights to change chat title"}`)
+	defer stop()
+	err := c.SetChatTitle(tbot.ChatID(123), "Gophers")
+	if !errors.Is(err, tbot.ErrNotEnoughRights) {
+		t.Fatalf("expected ErrNotEnoughRights, got %v", err)
+	}
+	c, stop2 := testClientStatus(t, http.StatusBadRequest, `{"ok": false, "error_code": 400, "description": "Bad Request: CHAT_ADMIN_REQUIRED"}`)
+	defer stop2()
+	err = c.SetChatPhoto(tbot.ChatID(123), tbot.InputFileReader("gopher.png", strings.NewReader("png data")))
+	if !errors.Is(err, tbot.ErrNotEnoughRights) {
+		t.Fatalf("expected ErrNotEnoughRights, got %v", err)
+	}
+	c, stop3 := testClientStatus(t, http.StatusBadRequest, `{"ok": false, "error_code": 400, "description": "Bad Request: chat description is not modified"}`)
+	defer stop3()
+	err = c.SetChatDescription(tbot.ChatID(123), "Go discussions")
+	if err == nil || errors.Is(err, tbot.ErrNotEnoughRights) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestPinChatMessage(t *testing.T) {
+	c, requests, stop := testRecorder(t, `{"ok": true, "result": true}`)
+	defer stop()
+	err := c.PinChatMessage(tbot.ChatID(-100), 10, tbot.OptDisableNotification)
+	if err != nil {
+		t.Fatalf("error on pinChatMessage: %v", err)
+	}
+	req := <-requests
+	if req.method != "pinChatMessage" || req.params.Get("message_id") != "10" || req.params.Get("disable_notification") != "true" {
+		t.Fatalf("unexpected request %s: %v", req.method, req.params)
+	}
+	err = c.UnpinChatMessage(tbot.ChatID(-100), 9)
+	if err != nil {
+		t.Fatalf("error on unpinChatMessage: %v", err)
+	}
+	req = <-requests
+	if req.method != "unpinChatMessage" || req.params.Get("message_id") != "9" {
+		t.Fatalf("unexpected request %s: %v", req.method, req.params)
+	}
+	err = c.UnpinChatMessage(tbot.ChatID(-100), 0)
+	if err != nil {
+		t.Fatalf("error on unpinChatMessage: %v", err)
+	}
+	req = <-requests
+	if _, ok := req.params["message_id"]; ok {
+		t.Fatalf("message_id should not be sent: %v", req.params)
+	}
+	err = c.UnpinAllChatMessages(tbot.ChatName("@news"))
+	if err != nil {
+		t.Fatalf("error on unpinAllChatMessages: %v", err)
+	}
+	req = <-requests
+	if req.method != "unpinAllChatMessages" || req.params.Get("chat_id") != "@news" {
+		t.Fatalf("unexpected request %s: %v", req.method, req.params)
+	}
+}
+
+func TestPinChatMessageNotEnoughRights(t *testing.T) {
+	description := "Bad Request: not enough rights to manage pinned messages in the chat"
+	c, stop := testClientStatus(t, http.StatusBadRequest, `{"ok": false, "error_code": 400, "description": "`+description+`"}`)
+	defer stop()
+	err := c.PinChatMessage(tbot.ChatID(-100), 10)
+	var apiErr *tbot.APIError
+	if !errors.As(err, &apiErr) || apiErr.Method != "pinChatMessage" || apiErr.Description != description {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !errors.Is(err, tbot.ErrNotEnoughRights) {
+		t.Fatalf("expected ErrNotEnoughRights, got %v", err)
+	}
+}
+
+func TestGetChat(t *testing.T) {
+	c, requests, stop := testRecorder(t, `{"ok": true, "result": {
+		"id": -1001234567890,
+		"type": "supergroup",
+		"title": "Gophers",
+		"username": "gophers",
+		"is_forum": true,
+		"description": "Go discussions",
+		"invite_link": "https://t.me/+abc",
+		"pinned_message": {"message_id": 5, "text": "Rules"},
+		"permissions": {"can_send_messages": true},
+		"slow_mode_delay": 30,
+		"linked_chat_id": -1009876543210
+	}}`)
+	defer stop()
+	chat, err := c.GetChat(tbot.ChatName("@gophers"))
+	if err != nil {
+		t.Fatalf("error on getChat: %v", err)
+	}
+	req := <-requests
+	if req.method != "getChat" || req.params.Get("chat_id") != "@gophers" {
+		t.Fatalf("unexpected request %s: %v", req.method, req.params)
+	}
+	if chat.ID != -1001234567890 || !chat.IsForum || chat.Description != "Go discussions" || chat.InviteLink != "https://t.me/+abc" {
+		t.Fatalf("unexpected chat: %+v", chat)
+	}
+	if chat.PinnedMessage == nil || chat.PinnedMessage.Text != "Rules" {
+		t.Fatalf("unexpected pinned message: %+v", chat.PinnedMessage)
+	}
+	if chat.Permissions == nil || !chat.Permissions.CanSendMessages || chat.SlowModeDelay != 30 || chat.LinkedChatID != -1009876543210 {
+		t.Fatalf("unexpected chat settings: %+v", chat)
+	}
+}
+
+func TestGetChatAdministrators(t *testing.T) {
+	c, requests, stop := testRecorder(t, `{"ok": true, "result": [
+		{"user": {"id": 1, "first_name": "Owner"}, "status": "creator", "is_anonymous": false},
+		{"user": {"id": 2, "first_name": "Mod"}, "status": "administrator", "custom_title": "Moderator",
+			"can_be_edited": true, "can_manage_chat": true, "can_delete_messages": true, "can_restrict_members": true}
+	]}`)
+	defer stop()
+	admins, err := c.GetChatAdministrators(tbot.ChatID(-100))
+	if err != nil {
+		t.Fatalf("error on getChatAdministrators: %v", err)
+	}
+	req := <-requests
+	if req.method != "getChatAdministrators" || req.params.Get("chat_id") != "-100" {
+		t.Fatalf("unexpected request %s: %v", req.method, req.params)
+	}
+	if len(admins) != 2 || !admins[0].IsAdmin() || !admins[1].IsAdmin() {
+		t.Fatalf("unexpected admins: %+v", admins)
+	}
+	mod := admins[1]
+	if mod.CustomTitle != "Moderator" || !mod.CanManageChat || !mod.CanDeleteMessages || !mod.CanRestrictMembers || mod.CanPromoteMembers {
+		t.Fatalf("unexpected admin rights: %+v", mod)
+	}
+}
+
+func TestGetChatMember(t *testing.T) {
+	c, requests, stop := testRecorder(t, `{"ok": true, "result": {
+		"user": {"id": 5, "first_name": "Bob"},
+		"status": "restricted",
+		"is_member": true,
+		"can_send_messages": true,
+		"until_date": 1700000000
+	}}`)
+	defer stop()
+	member, err := c.GetChatMember(tbot.ChatName("@mychannel"), 5)
+	if err != nil {
+		t.Fatalf("error on getChatMember: %v", err)
+	}
+	req := <-requests
+	if req.method != "getChatMember" || req.params.Get("chat_id") != "@mychannel" || req.params.Get("user_id") != "5" {
+		t.Fatalf("unexpected request %s: %v", req.method, req.params)
+	}
+	if member.Status != tbot.ChatMemberRestricted || !member.IsInChat() || member.UntilDate != 1700000000 || !member.CanSendMessages {
+		t.Fatalf("unexpected member: %+v", member)
+	}
+}
+
+func TestGetChatMemberCount(t *testing.T) {
+	c, requests, stop := testRecorder(t, `{"ok": true, "result": 42}`)
+	defer stop()
+	count, err := c.GetChatMemberCount(tbot.ChatID(-100))
+	if err != nil {
+		t.Fatalf("error on getChatMemberCount: %v", err)
+	}
+	req := <-requests
+	if req.method != "getChatMemberCount" || count != 42 {
+		t.Fatalf("unexpected count %d from %s", count, req.method)
+	}
+}
+
+func TestChatMemberStatus(t *testing.T) {
+	tt := []struct {
+		member tbot.ChatMember
+		admin  bool
+		inChat bool
+	}{
+		{member: tbot.ChatMember{Status: tbot.ChatMemberCreator}, admin: true, inChat: true},
+		{member: tbot.ChatMember{Status: tbot.ChatMemberAdministrator}, admin: true, inChat: true},
+		{member: tbot.ChatMember{Status: tbot.ChatMemberMember}, inChat: true},
+		{member: tbot.ChatMember{Status: tbot.ChatMemberRestricted, IsMember: true}, inChat: true},
+		{member: tbot.ChatMember{Status: tbot.ChatMemberRestricted}},
+		{member: tbot.ChatMember{Status: tbot.ChatMemberKicked}},
+		{member: tbot.ChatMember{Status: tbot.ChatMemberLeft}},
+	}
+	for _, tc := range tt {
+		if tc.member.IsAdmin() != tc.admin || tc.member.IsInChat() != tc.inChat {
+			t.Fatalf("%s: expected admin %v and in chat %v", tc.member.Status, tc.admin, tc.inChat)
+		}
+	}
+}
+
+func TestRestrictChatMember(t *testing.T) {
+	c, requests, stop := testRecorder(t, `{"ok": true, "result": true}`)
+	defer stop()
+	until := time.Unix(1700000600, 0)
+	err := c.RestrictChatMember(tbot.ChatID(-100), 5, tbot.MutePermissions(), tbot.OptUntilDate(until))
+	if err != nil {
+		t.Fatalf("error on restrictChatMember: %v", err)
+	}
+	req := <-requests
+	if req.method != "restrictChatMember" || req.params.Get("user_id") != "5" || req.params.Get("until_date") != "1700000600" {
+		t.Fatalf("unexpected request %s: %v", req.method, req.params)
+	}
+	var perms map[string]bool
+	err = json.Unmarshal([]byte(req.params.Get("permissions")), &perms)
+	if err != nil {
+		t.Fatalf("unable to decode permissions: %v", err)
+	}
+	for _, name := range []string{"can_send_messages", "can_send_photos", "can_send_voice_notes", "can_send_polls", "can_manage_topics"} {
+		if allowed, ok := perms[name]; !ok || allowed {
+			t.Fatalf("%s must be sent and disallowed: %v", name, perms)
+		}
+	}
+	if err = c.RestrictChatMember(tbot.ChatID(-100), 5, nil); err == nil {
+		t.Fatalf("expected error for nil permissions")
+	}
+}
+
+func TestPromoteChatMember(t *testing.T) {
+	c, requests, stop := testRecorder(t, `{"ok": true, "result": true}`)
+	defer stop()
+	err := c.PromoteChatMember(tbot.ChatID(-100), 5, tbot.OptCanDeleteMessages, tbot.OptCanRestrictMembers, tbot.OptCanPromoteMembers)
+	if err != nil {
+		t.Fatalf("error on promoteChatMember: %v", err)
+	}
+	req := <-requests
+	expected := url.Values{
+		"chat_id":              {"-100"},
+		"user_id":              {"5"},
+		"can_delete_messages":  {"true"},
+		"can_restrict_members": {"true"},
+		"can_promote_members":  {"true"},
+	}
+	if req.method != "promoteChatMember" || !reflect.DeepEqual(req.params, expected) {
+		t.Fatalf("unexpected request %s: %v", req.method, req.params)
+	}
+
+	err = c.PromoteChatMember(tbot.ChatID(-100), 5, tbot.OptPromotions(&tbot.Promotions{CanManageTopics: true}))
+	if err != nil {
+		t.Fatalf("error on promoteChatMember: %v", err)
+	}
+	req = <-requests
+	if req.params.Get("can_manage_topics") != "true" || req.params.Get("can_promote_members") != "false" {
+		t.Fatalf("unexpected params: %v", req.params)
+	}
+
+	err = c.DemoteChatMember(tbot.ChatID(-100), 5)
+	if err != nil {
+		t.Fatalf("error on promoteChatMember: %v", err)
+	}
+	req = <-requests
+	if len(req.params) != 2 {
+		t.Fatalf("demotion should not grant rights: %v", req.params)
+	}
+}
+
+func TestSetChatAdministratorCustomTitle(t *testing.T) {
+	c, requests, stop := testRecorder(t, `{"ok": true, "result": true}`)
+	defer stop()
+	for _, title := range []string{"Moderator", "Модератор недели", ""} {
+		err := c.SetChatAdministratorCustomTitle(tbot.ChatID(-100), 5, title)
+		if err != nil {
+			t.Fatalf("error on setChatAdministratorCustomTitle: %v", err)
+		}
+		req := <-requests
+		if req.method != "setChatAdministratorCustomTitle" || req.params.Get("user_id") != "5" || req.params.Get("custom_title") != title {
+			t.Fatalf("unexpected request %s: %v", req.method, req.params)
+		}
+	}
+	for _, title := range []string{"Moderator of the Week", "Mod 🛡", "Mod ❤️", "Mod #\u20e3", "Mod \U0001F3FD"} {
+		err := c.SetChatAdministratorCustomTitle(tbot.ChatID(-100), 5, title)
+		if err == nil {
+			t.Fatalf("expected error for title %q", title)
+		}
+	}
+	err := c.SetChatAdministratorCustomTitle(tbot.ChatID(-100), 5, "Mod 🛡")
+	if err == nil || !strings.Contains(err.Error(), "emoji") {
+		t.Fatalf("expected emoji error, got %v", err)
+	}
+	if len(requests) != 0 {
+		t.Fatalf("invalid titles should not be sent")
+	}
+}
+
+func TestDeleteWebhook(t *testing.T) {
+	c, requests, stop := testRecorder(t, `{"ok": true, "result": true}`)
+	defer stop()
+	if err := c.DeleteWebhook(true); err != nil {
+		t.Fatalf("error on deleteWebhook: %v", err)
+	}
+	if req := <-requests; req.method != "deleteWebhook" || req.params.Get("drop_pending_updates") != "true" {
+		t.Fatalf("unexpected request %s: %v", req.method, req.params)
+	}
+	if err := c.DeleteWebhook(false); err != nil {
+		t.Fatalf("error on deleteWebhook: %v", err)
+	}
+	if req := <-requests; len(req.params) != 0 {
+		t.Fatalf("unexpected params: %v", req.params)
+	}
+}
+
+func TestLogOutAndClose(t *testing.T) {
+	c, requests, stop := testRecorder(t, `{"ok": true, "result": true}`)
+	defer stop()
+	if err := c.LogOut(); err != nil {
+		t.Fatalf("error on logOut: %v", err)
+	}
+	if req := <-requests; req.method != "logOut" {
+		t.Fatalf("unexpected request %s", req.method)
+	}
+	if err := c.Close(); err != nil {
+		t.Fatalf("error on close: %v", err)
+	}
+	if req := <-requests; req.method != "close" {
+		t.Fatalf("unexpected request %s", req.method)
+	}
+
+	c, stop2 := testClientStatus(t, http.StatusTooManyRequests, `{"ok": false, "error_code": 429,
+		"description": "Too Many Requests: retry after 540", "parameters": {"retry_after": 540}}`)
+	defer stop2()
+	err := c.Close()
+	var apiErr *tbot.APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != 429 || apiErr.RetryAfter != 540 {
+		t.Fatalf("expected APIError with RetryAfter, got %v", err)
+	}
+}
+
+func TestGetWebhookInfo(t *testing.T) {
+	c, requests, stop := testRecorder(t, `{"ok": true, "result": {
+		"url": "https://bot.example.com/hook",
+		"has_custom_certificate": false,
+		"pending_update_count": 12,
+		"ip_address": "203.0.113.5",
+		"last_error_date": 1700000000,
+		"last_error_message": "Wrong response from the webhook: 502 Bad Gateway",
+		"max_connections": 40,
+		"allowed_updates": ["message", "callback_query"]
+	}}`)
+	defer stop()
+	info, err := c.GetWebhookInfo()
+	if err != nil {
+		t.Fatalf("error on getWebhookInfo: %v", err)
+	}
+	if req := <-requests; req.method != "getWebhookInfo" {
+		t.Fatalf("unexpected method: %s", req.method)
+	}
+	expected := &tbot.WebhookInfo{
+		URL:                "https://bot.example.com/hook",
+		PendingUpdateCount: 12,
+		IPAddress:          "203.0.113.5",
+		LastErrorDate:      1700000000,
+		LastErrorMessage:   "Wrong response from the webhook: 502 Bad Gateway",
+		MaxConnections:     40,
+		AllowedUpdates:     []string{"message", "callback_query"},
+	}
+	if !reflect.DeepEqual(info, expected) {
+		t.Fatalf("unexpected webhook info: %+v", info)
+	}
+}
+
+func TestChatInviteLinks(t *testing.T) {
+	c, requests, stop := testRecorder(t, `{"ok": true, "result": {"invite_link": "https://t.me/+abc", "creator": {"id": 42}, "name": "applicant 7", "expire_date": 1700086400, "member_limit": 1}}`)
+	defer stop()
+	expire := time.Unix(1700086400, 0)
+	link, err := c.CreateChatInviteLink(tbot.ChatID(-100), tbot.OptInviteLinkName("applicant 7"),
+		tbot.OptExpireDate(expire), tbot.OptMemberLimit(1))
+	if err != nil {
+		t.Fatalf("error on createChatInviteLink: %v", err)
+	}
+	if link.InviteLink != "https://t.me/+abc" || link.Creator.ID != 42 || link.MemberLimit != 1 || link.ExpireDate != 1700086400 {
+		t.Fatalf("unexpected link: %+v", link)
+	}
+	req := <-requests
+	if req.method != "createChatInviteLink" || req.params.Get("chat_id") != "-100" || req.params.Get("name") != "applicant 7" ||
+		req.params.Get("expire_date") != "1700086400" || req.params.Get("member_limit") != "1" {
+		t.Fatalf("unexpected request %s: %v", req.method, req.params)
+	}
+	_, err = c.EditChatInviteLink(tbot.ChatID(-100), "https://t.me/+abc", tbot.OptCreatesJoinRequest)
+	if err != nil {
+		t.Fatalf("error on editChatInviteLink: %v", err)
+	}
+	req = <-requests
+	if req.method != "editChatInviteLink" || req.params.Get("invite_link") != "https://t.me/+abc" ||
+		req.params.Get("creates_join_request") != "true" {
+		t.Fatalf("unexpected request %s: %v", req.method, req.params)
+	}
+	_, err = c.RevokeChatInviteLink(tbot.ChatID(-100), "https://t.me/+abc")
+	if err != nil {
+		t.Fatalf("error on revokeChatInviteLink: %v", err)
+	}
+	req = <-requests
+	if req.method != "revokeChatInviteLink" || req.params.Get("invite_link") != "https://t.me/+abc" {
+		t.Fatalf("unexpected request %s: %v", req.method, req.params)
+	}
+}
+
+func TestChatJoinRequests(t *testing.T) {
+	c, requests, stop := testRecorder(t, `{"ok": true, "result": true}`)
+	defer stop()
+	if err := c.ApproveChatJoinRequest(tbot.ChatID(-100), 5); err != nil {
+		t.Fatalf("error on approveChatJoinRequest: %v", err)
+	}
+	req := <-requests
+	if req.method != "approveChatJoinRequest" || req.params.Get("chat_id") != "-100" || req.params.Get("user_id") != "5" {
+		t.Fatalf("unexpected request %s: %v", req.method, req.params)
+	}
+	if err := c.DeclineChatJoinRequest(tbot.ChatID(-100), 5); err != nil {
+		t.Fatalf("error on declineChatJoinRequest: %v", err)
+	}
+	req = <-requests
+	if req.method != "declineChatJoinRequest" || req.params.Get("user_id") != "5" {
+		t.Fatalf("unexpected request %s: %v", req.method, req.params)
+	}
+
+	c, stop2 := testClientStatus(t, http.StatusBadRequest, `{"ok": false, "error_code": 400, "description": "Bad Request: USER_ALREADY_PARTICIPANT"}`)
+	defer stop2()
+	err := c.ApproveChatJoinRequest(tbot.ChatID(-100), 5)
+	var apiErr *tbot.APIError
+	if !errors.As(err, &apiErr) || apiErr.Description != "Bad Request: USER_ALREADY_PARTICIPANT" {
+		t.Fatalf("expected APIError with description, got %v", err)
+	}
+}
+
+func TestBanChatSenderChat(t *testing.T) {
+	var msg tbot.Message
+	err := json.Unmarshal([]byte(`{"message_id": 1, "sender_chat": {"id": -1001, "type": "channel", "title": "Spam"}, "chat": {"id": -100}}`), &msg)
+	if err != nil {
+		t.Fatalf("unable to decode message: %v", err)
+	}
+	if msg.SenderChat == nil || msg.SenderChat.ID != -1001 {
+		t.Fatalf("unexpected sender chat: %+v", msg.SenderChat)
+	}
+	c, requests, stop := testRecorder(t, `{"ok": true, "result": true}`)
+	defer stop()
+	if err := c.BanChatSenderChat(tbot.ChatID(-100), msg.SenderChat.ID); err != nil {
+		t.Fatalf("error on banChatSenderChat: %v", err)
+	}
+	req := <-requests
+	if req.method != "banChatSenderChat" || req.params.Get("chat_id") != "-100" || req.params.Get("sender_chat_id") != "-1001" {
+		t.Fatalf("unexpected request %s: %v", req.method, req.params)
+	}
+	if err := c.UnbanChatSenderChat(tbot.ChatID(-100), msg.SenderChat.ID); err != nil {
+		t.Fatalf("error on unbanChatSenderChat: %v", err)
+	}
+	req = <-requests
+	if req.method != "unbanChatSenderChat" || req.params.Get("sender_chat_id") != "-1001" {
+		t.Fatalf("unexpected request %s: %v", req.method, req.params)
+	}
+}
+
+func TestMyCommandsScope(t *testing.T) {
+	c, requests, stop := testRecorder(t, `{"ok": true, "result": true}`)
+	defer stop()
+	commands := []tbot.BotCommand{{Command: "ban", Description: "Ban the user"}}
+	err := c.SetMyCommands(commands, tbot.OptCommandScope(tbot.BotCommandScopeChatAdministrators{ChatID: -100}),
+		tbot.OptLanguageCode("en"))
+	if err != nil {
+		t.Fatalf("error on setMyCommands: %v", err)
+	}
+	req := <-requests
+	if req.method != "setMyCommands" || req.params.Get("commands") != `[{"command":"ban","description":"Ban the user"}]` ||
+		req.params.Get("scope") != `{"type":"chat_administrators","chat_id":-100}` || req.params.Get("language_code") != "en" {
+		t.Fatalf("unexpected request %s: %v", req.method, req.params)
+	}
+
+	scopes := []struct {
+		scope    tbot.BotCommandScope
+		expected string
+	}{
+		{tbot.BotCommandScopeDefault{}, `{"type":"default"}`},
+		{tbot.BotCommandScopeAllPrivateChats{}, `{"type":"all_private_chats"}`},
+		{tbot.BotCommandScopeAllGroupChats{}, `{"type":"all_group_chats"}`},
+		{tbot.BotCommandScopeAllChatAdministrators{}, `{"type":"all_chat_administrators"}`},
+		{tbot.BotCommandScopeChat{ChatID: -100}, `{"type":"chat","chat_id":-100}`},
+		{tbot.BotCommandScopeChatMember{ChatID: -100, UserID: 5}, `{"type":"chat_member","chat_id":-100,"user_id":5}`},
+	}
+	for _, tc := range scopes {
+		if err := c.DeleteMyCommands(tbot.OptCommandScope(tc.scope)); err != nil {
+			t.Fatalf("error on deleteMyCommands: %v", err)
+		}
+		req := <-requests
+		if req.method != "deleteMyCommands" || req.params.Get("scope") != tc.expected {
+			t.Fatalf("unexpected request %s: %v", req.method, req.params)
+		}
+	}
+
+	c, requests, stop2 := testRecorder(t, `{"ok": true, "result": [{"command": "ban", "description": "Ban the user"}]}`)
+	defer stop2()
+	got, err := c.GetMyCommands(tbot.OptCommandScope(tbot.BotCommandScopeAllGroupChats{}))
+	if err != nil {
+		t.Fatalf("error on getMyCommands: %v", err)
+	}
+	if !reflect.DeepEqual(*got, commands) {
+		t.Fatalf("unexpected commands: %v", *got)
+	}
+	if req := <-requests; req.params.Get("scope") != `{"type":"all_group_chats"}` {
+		t.Fatalf("unexpected request %s: %v", req.method, req.params)
+	}
+}
+
+func TestChatMenuButton(t *testing.T) {
+	c, requests, stop := testRecorder(t, `{"ok": true, "result": true}`)
+	defer stop()
+	err := c.SetChatMenuButton(tbot.OptMenuChatID(5),
+		tbot.OptMenuButton(tbot.MenuButtonWebApp{Text: "Open store", WebApp: tbot.WebAppInfo{URL: "https://shop.example.com"}}))
+	if err != nil {
+		t.Fatalf("error on setChatMenuButton: %v", err)
+	}
+	req := <-requests
+	if req.method != "setChatMenuButton" || req.params.Get("chat_id") != "5" ||
+		req.params.Get("menu_button") != `{"type":"web_app","text":"Open store","web_app":{"url":"https://shop.example.com"}}` {
+		t.Fatalf("unexpected request %s: %v", req.method, req.params)
+	}
+	if err := c.SetChatMenuButton(tbot.OptMenuButton(tbot.MenuButtonCommands{})); err != nil {
+		t.Fatalf("error on setChatMenuButton: %v", err)
+	}
+	if req := <-requests; req.params.Get("menu_button") != `{"type":"commands"}` || req.params.Get("chat_id") != "" {
+		t.Fatalf("unexpected request %s: %v", req.method, req.params)
+	}
+
+	tt := []struct {
+		result   string
+		expected tbot.MenuButton
+	}{
+		{`{"type": "commands"}`, tbot.MenuButtonCommands{}},
+		{`{"type": "default"}`, tbot.MenuButtonDefault{}},
+		{`{"type": "web_app", "text": "Open store", "web_app": {"url": "https://shop.example.com"}}`,
+			tbot.MenuButtonWebApp{Text: "Open store", WebApp: tbot.WebAppInfo{URL: "https://shop.example.com"}}},
+	}
+	for _, tc := range tt {
+		c, requests, stop2 := testRecorder(t, `{"ok": true, "result": `+tc.result+`}`)
+		defer stop2()
+		button, err := c.GetChatMenuButton(tbot.OptMenuChatID(5))
+		if err != nil {
+			t.Fatalf("error on getChatMenuButton: %v", err)
+		}
+		if !reflect.DeepEqual(button, tc.expected) {
+			t.Fatalf("unexpected button: %#v", button)
+		}
+		if req := <-requests; req.method != "getChatMenuButton" || req.params.Get("chat_id") != "5" {
+			t.Fatalf("unexpected request %s: %v", req.method, req.params)
+		}
+	}
+}
+
+func TestSetMyProfile(t *testing.T) {
+	c, requests, stop := testRecorder(t, `{"ok": true, "result": true}`)
+	defer stop()
+	tt := []struct {
+		set      func() error
+		method   string
+		expected url.Values
+	}{
+		{
+			set:      func() error { return c.SetMyName("Помощник", "ru") },
+			method:   "setMyName",
+			expected: url.Values{"name": {"Помощник"}, "language_code": {"ru"}},
+		},
+		{
+			set:      func() error { return c.SetMyName("Helper", "") },
+			method:   "setMyName",
+			expected: url.Values{"name": {"Helper"}},
+		},
+		{
+			set:      func() error { return c.SetMyDescription("", "de") },
+			method:   "setMyDescription",
+			expected: url.Values{"description": {""}, "language_code": {"de"}},
+		},
+		{
+			set:      func() error { return c.SetMyShortDescription("", "") },
+			method:   "setMyShortDescription",
+			expected: url.Values{"short_description": {""}},
+		},
+	}
+	for _, tc := range tt {
+		if err := tc.set(); err != nil {
+			t.Fatalf("error on %s: %v", tc.method, err)
+		}
+		req := <-requests
+		if req.method != tc.method || !reflect.DeepEqual(req.params, tc.expected) {
+			t.Fatalf("unexpected request %s: %v", req.method, req.params)
+		}
+	}
+	if err := c.SetMyShortDescription(strings.Repeat("a", tbot.MaxBotShortDescriptionLength+1), ""); err == nil {
+		t.Fatalf("expected error for long short description")
+	}
+	if len(requests) != 0 {
+		t.Fatalf("invalid request should not be sent")
+	}
+
+	c, requests, stop2 := testRecorder(t, `{"ok": true, "result": {"name": "Помощник"}}`)
+	defer stop2()
+	name, err := c.GetMyName("ru")
+	if err != nil {
+		t.Fatalf("error on getMyName: %v", err)
+	}
+	if name != "Помощник" {
+		t.Fatalf("unexpected name: %s", name)
+	}
+	if req := <-requests; req.method != "getMyName" || req.params.Get("language_code") != "ru" {
+		t.Fatalf("unexpected request %s: %v", req.method, req.params)
+	}
+	c, requests, stop3 := testRecorder(t, `{"ok": true, "result": {"short_description": "Helps"}}`)
+	defer stop3()
+	short, err := c.GetMyShortDescription("")
+	if err != nil {
+		t.Fatalf("error on getMyShortDescription: %v", err)
+	}
+	if short != "Helps" {
+		t.Fatalf("unexpected short description: %s", short)
+	}
+	if req := <-requests; req.method != "getMyShortDescription" || len(req.params) != 0 {
+		t.Fatalf("unexpected request %s: %v", req.method, req.params)
+	}
+}
+
+func TestSetMyCommandsValidation(t *testing.T) {
+	c, requests, stop := testRecorder(t, `{"ok": true, "result": true}`)
+	defer stop()
+	invalid := []tbot.BotCommand{
+		{Command: "", Description: "Empty"},
+		{Command: "/start", Description: "Slash"},
+		{Command: "Start", Description: "Uppercase"},
+		{Command: strings.Repeat("a", tbot.MaxBotCommandLength+1), Description: "Long"},
+		{Command: "start", Description: ""},
+		{Command: "start", Description: strings.Repeat("d", tbot.MaxBotCommandDescriptionLength+1)},
+	}
+	for _, command := range invalid {
+		if err := c.SetMyCommands([]tbot.BotCommand{command}); err == nil {
+			t.Fatalf("expected error for %+v", command)
+		}
+	}
+	if len(requests) != 0 {
+		t.Fatalf("invalid commands should not be sent")
+	}
+	if err := c.SetMyCommands([]tbot.BotCommand{{Command: strings.Repeat("a", tbot.MaxBotCommandLength), Description: "Start_2"}}); err != nil {
+		t.Fatalf("error on setMyCommands: %v", err)
+	}
+}
+
+func TestAnswerInlineQuery(t *testing.T) {
+	markup := &tbot.InlineKeyboardMarkup{InlineKeyboard: [][]tbot.InlineKeyboardButton{{{Text: "Open", URL: "https://example.com"}}}}
+	text := tbot.InputTextMessageContent{MessageText: "Go is expressive"}
+	tt := []struct {
+		result   tbot.InlineQueryResult
+		expected string
+	}{
+		{
+			tbot.InlineQueryResultArticle{ID: "1", Title: "Go", InputMessageContent: text, ReplyMarkup: markup},
+			`{"type":"article","id":"1","title":"Go","input_message_content":{"message_text":"Go is expressive"},` +
+				`"reply_markup":{"inline_keyboard":[[{"text":"Open","url":"https://example.com"}]]}}`,
+		},
+		{
+			tbot.InlineQueryResultPhoto{ID: "2", PhotoURL: "https://example.com/p.jpg", ThumbURL: "https://example.com/t.jpg"},
+			`{"type":"photo","id":"2","photo_url":"https://example.com/p.jpg","thumb_url":"https://example.com/t.jpg"}`,
+		},
+		{
+			tbot.InlineQueryResultGif{ID: "3", GifURL: "https://example.com/g.gif", ThumbURL: "https://example.com/t.jpg"},
+			`{"type":"gif","id":"3","gif_url":"https://example.com/g.gif","thumb_url":"https://example.com/t.jpg"}`,
+		},
+		{
+			tbot.InlineQueryResultVideo{ID: "4", VideoURL: "https://example.com/v.mp4", MimeType: "video/mp4",
+				ThumbURL: "https://example.com/t.jpg", Title: "Video"},
+			`{"type":"video","id":"4","video_url":"https://example.com/v.mp4","mime_type":"video/mp4",` +
+				`"thumb_url":"https://example.com/t.jpg","title":"Video"}`,
+		},
+		{
+			tbot.InlineQueryResultAudio{ID: "5", AudioURL: "https://example.com/a.mp3", Title: "Audio", Performer: "Gopher"},
+			`{"type":"audio","id":"5","audio_url":"https://example.com/a.mp3","title":"Audio","performer":"Gopher"}`,
+		},
+		{
+			tbot.InlineQueryResultDocument{ID: "6", Title: "Spec", DocumentURL: "https://example.com/s.pdf", MimeType: "application/pdf"},
+			`{"type":"document","id":"6","title":"Spec","document_url":"https://example.com/s.pdf","mime_type":"application/pdf"}`,
+		},
+		{
+			tbot.InlineQueryResultLocation{ID: "7", Latitude: 50.45, Longitude: 30.52, Title: "Kyiv",
+				InputMessageContent: tbot.InputLocationMessageContent{Latitude: 50.45, Longitude: 30.52}},
+			`{"type":"location","id":"7","latitude":50.45,"longitude":30.52,"title":"Kyiv",` +
+				`"input_message_content":{"latitude":50.45,"longitude":30.52}}`,
+		},
+		{
+			tbot.InlineQueryResultVenue{ID: "8", Latitude: 50.45, Longitude: 30.52, Title: "Office", Address: "Main st. 1"},
+			`{"type":"venue","id":"8","latitude":50.45,"longitude":30.52,"title":"Office","address":"Main st. 1"}`,
+		},
+		{
+			tbot.InlineQueryResultContact{ID: "9", PhoneNumber: "+380000000000", FirstName: "Gopher",
+				InputMessageContent: tbot.InputContactMessageContent{PhoneNumber: "+380000000000", FirstName: "Gopher"}},
+			`{"type":"contact","id":"9","phone_number":"+380000000000","first_name":"Gopher",` +
+				`"input_message_content":{"phone_number":"+380000000000","first_name":"Gopher"}}`,
+		},
+		{
+			tbot.InlineQueryResultCachedSticker{ID: "10", StickerFileID: "sticker-1", ReplyMarkup: markup},
+			`{"type":"sticker","id":"10","sticker_file_id":"sticker-1",` +
+				`"reply_markup":{"inline_keyboard":[[{"text":"Open","url":"https://example.com"}]]}}`,
+		},
+		{
+			tbot.InlineQueryResultCachedPhoto{Type: "photo", ID: "11", PhotoFileID: "photo-1"},
+			`{"type":"photo","id":"11","photo_file_id":"photo-1"}`,
+		},
+		{
+			&tbot.InlineQueryResultArticle{ID: "12", Title: "Pointer", InputMessageContent: text},
+			`{"type":"article","id":"12","title":"Pointer","input_message_content":{"message_text":"Go is expressive"}}`,
+		},
+	}
+	c, requests, stop := testRecorder(t, `{"ok": true, "result": true}`)
+	defer stop()
+	for _, tc := range tt {
+		err := c.AnswerInlineQuery("query-1", []tbot.InlineQueryResult{tc.result})
+		if err != nil {
+			t.Fatalf("error on answerInlineQuery: %v", err)
+		}
+		req := <-requests
+		if req.method != "answerInlineQuery" || req.params.Get("inline_query_id") != "query-1" {
+			t.Fatalf("unexpected request %s: %v", req.method, req.params)
+		}
+		if results := req.params.Get("results"); results != "["+tc.expected+"]" {
+			t.Errorf("unexpected results:\n%s\nexpected:\n[%s]", results, tc.expected)
+		}
+	}
+
+	err := c.AnswerInlineQuery("query-1", []tbot.InlineQueryResult{tt[0].result},
+		tbot.OptCacheTime(time.Minute), tbot.OptIsPersonal, tbot.OptNextOffset("20"))
+	if err != nil {
+		t.Fatalf("error on answerInlineQuery: %v", err)
+	}
+	req := <-requests
+	if req.params.Get("cache_time") != "60" || req.params.Get("is_personal") != "true" || req.params.Get("next_offset") != "20" {
+		t.Fatalf("unexpected request %s: %v", req.method, req.params)
+	}
+
+	results := make([]tbot.InlineQueryResult, tbot.MaxInlineQueryResults+1)
+	for i := range results {
+		results[i] = tt[0].result
+	}
+	if err := c.AnswerInlineQuery("query-1", results); err == nil {
+		t.Fatalf("expected error for too many results")
+	}
+}
+
+func TestAnswerWebAppQuery(t *testing.T) {
+	c, requests, stop := testRecorder(t, `{"ok": true, "result": {"inline_message_id": "inline-1"}}`)
+	defer stop()
+	msg, err := c.AnswerWebAppQuery("query-1", tbot.InlineQueryResultArticle{
+		ID:                  "1",
+		Title:               "Order",
+		InputMessageContent: tbot.InputTextMessageContent{MessageText: "Order #7 is paid"},
+	})
+	if err != nil {
+		t.Fatalf("error on answerWebAppQuery: %v", err)
+	}
+	if msg.InlineMessageID != "inline-1" {
+		t.Fatalf("unexpected message: %+v", msg)
+	}
+	req := <-requests
+	if req.method != "answerWebAppQuery" || req.params.Get("web_app_query_id") != "query-1" ||
+		req.params.Get("result") != `{"type":"article","id":"1","title":"Order","input_message_content":{"message_text":"Order #7 is paid"}}` {
+		t.Fatalf("unexpected request %s: %v", req.method, req.params)
+	}
+
+	article := &tbot.InlineQueryResultArticle{ID: "2", Title: "Order", InputMessageContent: tbot.InputTextMessageContent{MessageText: "Paid"}}
+	if _, err := c.AnswerWebAppQuery("query-2", article); err != nil {
+		t.Fatalf("error on answerWebAppQuery: %v", err)
+	}
+	req = <-requests
+	if req.params.Get("result") != `{"type":"article","id":"2","title":"Order","input_message_content":{"message_text":"Paid"}}` {
+		t.Fatalf("unexpected request %s: %v", req.method, req.params)
+	}
+	if article.Type != "" {
+		t.Fatalf("pointer result should not be changed")
+	}
+}
+
+func TestForumTopics(t *testing.T) {
+	c, requests, stop := testRecorder(t, `{"ok": true, "result": {"message_thread_id": 3, "name": "Ticket #42", "icon_color": 7322096}}`)
+	defer stop()
+	topic, err := c.CreateForumTopic(tbot.ChatID(-100), "Ticket #42", tbot.OptIconColor(tbot.ForumTopicIconBlue))
+	if err != nil {
+		t.Fatalf("error on createForumTopic: %v", err)
+	}
+	if topic.MessageThreadID != 3 || topic.Name != "Ticket #42" || topic.IconColor != tbot.ForumTopicIconBlue {
+		t.Fatalf("unexpected topic: %+v", topic)
+	}
+	req := <-requests
+	if req.method != "createForumTopic" || req.params.Get("chat_id") != "-100" ||
+		req.params.Get("name") != "Ticket #42" || req.params.Get("icon_color") != "7322096" {
+		t.Fatalf("unexpected request %s: %v", req.method, req.params)
+	}
+	if _, err := c.CreateForumTopic(tbot.ChatID(-100), ""); err == nil {
+		t.Fatalf("expected error for empty topic name")
+	}
+	if err := c.EditForumTopic(tbot.ChatID(-100), 3, tbot.OptTopicName(strings.Repeat("a", tbot.MaxForumTopicNameLength+1))); err == nil {
+		t.Fatalf("expected error for long topic name")
+	}
+	if len(requests) != 0 {
+		t.Fatalf("invalid requests should not be sent")
+	}
+
+	c, requests, stop2 := testRecorder(t, `{"ok": true, "result": true}`)
+	defer stop2()
+	if err := c.EditForumTopic(tbot.ChatID(-100), 3, tbot.OptTopicName("Ticket #42 [resolved]"), tbot.OptIconCustomEmojiID("")); err != nil {
+		t.Fatalf("error on editForumTopic: %v", err)
+	}
+	req = <-requests
+	if req.method != "editForumTopic" || req.params.Get("message_thread_id") != "3" ||
+		req.params.Get("name") != "Ticket #42 [resolved]" || req.params["icon_custom_emoji_id"] == nil {
+		t.Fatalf("unexpected request %s: %v", req.method, req.params)
+	}
+	for method, fn := range map[string]func(tbot.SendChatID, int) error{
+		"closeForumTopic":            c.CloseForumTopic,
+		"reopenForumTopic":           c.ReopenForumTopic,
+		"deleteForumTopic":           c.DeleteForumTopic,
+		"unpinAllForumTopicMessages": c.UnpinAllForumTopicMessages,
+	} {
+		if err := fn(tbot.ChatID(-100), 3); err != nil {
+			t.Fatalf("error on %s: %v", method, err)
+		}
+		req := <-requests
+		if req.method != method || req.params.Get("chat_id") != "-100" || req.params.Get("message_thread_id") != "3" {
+			t.Fatalf("unexpected request %s: %v", req.method, req.params)
+		}
+	}
+}
+
+func TestGetForumTopicIconStickers(t *testing.T) {
+	c, requests, stop := testRecorder(t, `{"ok": true, "result": [{"file_id": "sticker-1", "custom_emoji_id": "5312536423851630001"}]}`)
+	defer stop()
+	stickers, err := c.GetForumTopicIconStickers()
+	if err != nil {
+		t.Fatalf("error on getForumTopicIconStickers: %v", err)
+	}
+	if len(stickers) != 1 || stickers[0].FileID != "sticker-1" {
+		t.Fatalf("unexpected stickers: %+v", stickers)
+	}
+	if req := <-requests; req.method != "getForumTopicIconStickers" {
+		t.Fatalf("unexpected request %s", req.method)
+	}
+}
+
+func TestLeaveChat(t *testing.T) {
+	c, requests, stop := testRecorder(t, `{"ok": true, "result": true}`)
+	defer stop()
+	err := c.LeaveChat(tbot.ChatID(-100))
+	if err != nil {
+		t.Fatalf("error on leaveChat: %v", err)
+	}
+	req := <-requests
+	if req.method != "leaveChat" || req.params.Get("chat_id") != "-100" {
+		t.Fatalf("unexpected request %s: %v", req.method, req.params)
+	}
+	c, stop2 := testClientStatus(t, http.StatusForbidden,
+		`{"ok": false, "error_code": 403, "description": "Forbidden: bot is not a member of the supergroup chat"}`)
+	defer stop2()
+	err = c.LeaveChat(tbot.ChatID(-100))
+	if !errors.Is(err, tbot.ErrBotBlocked) {
+		t.Fatalf("expected ErrBotBlocked, got %v", err)
+	}
+}
+
+func TestDeleteMessages(t *testing.T) {
+	c, requests, stop := testRecorder(t, `{"ok": true, "result": true}`)
+	defer stop()
+	err := c.DeleteMessage(tbot.ChatID(123), 321)
+	if err != nil {
+		t.Fatalf("error on deleteMessage: %v", err)
+	}
+	req := <-requests
+	if req.method != "deleteMessage" || req.params.Get("message_id") != "321" {
+		t.Fatalf("unexpected request %s: %v", req.method, req.params)
+	}
+	err = c.DeleteMessages(tbot.ChatID(123), []int{1, 2, 3})
+	if err != nil {
+		t.Fatalf("error on deleteMessages: %v", err)
+	}
+	req = <-requests
+	if req.method != "deleteMessages" || req.params.Get("chat_id") != "123" || req.params.Get("message_ids") != "[1,2,3]" {
+		t.Fatalf("unexpected request %s: %v", req.method, req.params)
+	}
+	var batchErr *tbot.MessageBatchError
+	if err = c.DeleteMessages(tbot.ChatID(123), nil); !errors.As(err, &batchErr) || batchErr.Count != 0 {
+		t.Fatalf("expected batch error for empty messages, got %v", err)
+	}
+	err = c.DeleteMessages(tbot.ChatID(123), make([]int, tbot.MaxMessageBatch+1))
+	if !errors.As(err, &batchErr) || batchErr.Count != tbot.MaxMessageBatch+1 || batchErr.Unsorted {
+		t.Fatalf("expected batch error for too many messages, got %v", err)
+	}
+	if len(requests) != 0 {
+		t.Fatalf("invalid requests should not be sent")
+	}
+}
+
+func TestDeleteMessageErrors(t *testing.T) {
+	tt := []struct {
+		description string
+		expected    error
+	}{
+		{description: "Bad Request: message to delete not found", expected: tbot.ErrMessageNotFound},
+		{description: "Bad Request: message can't be deleted", expected: tbot.ErrMessageCantBeDeleted},
+		{description: "Bad Request: message can't be deleted for everyone", expected: tbot.ErrMessageCantBeDeleted},
+		{description: "Bad Request: not enough rights to delete messages", expected: tbot.ErrNotEnoughRights},
+	}
+	for _, tc := range tt {
+		c, stop := testClientStatus(t, http.StatusBadRequest, `{"ok": false, "error_code": 400, "description": "`+tc.description+`"}`)
+		defer stop()
+		err := c.DeleteMessage(tbot.ChatID(123), 321)
+		if !errors.Is(err, tc.expected) {
+			t.Fatalf("%s: expected %v, got %v", tc.description, tc.expected, err)
+		}
+		for _, other := range []error{tbot.ErrMessageNotFound, tbot.ErrMessageCantBeDeleted, tbot.ErrNotEnoughRights} {
+			if other != tc.expected && errors.Is(err, other) {
+				t.Fatalf("%s: should not match %v", tc.description, other)
+			}
+		}
+	}
+}
+
+func TestSendDice(t *testing.T) {
+	c, requests, stop := testRecorder(t, `
+		{
+			"ok": true,
+			"result": {
+				"message_id": 321,
+				"dice": {
+					"emoji": "🎰",
+					"value": 64
+				}
+			}
+		}
+	`)
+	defer stop()
+	msg, err := c.SendDice(tbot.ChatID(123), tbot.OptEmoji(tbot.DiceEmojiSlotMachine))
+	if err != nil {
+		t.Fatalf("error on sendDice: %v", err)
+	}
+	if msg.Dice == nil || msg.Dice.Value != 64 {
+		t.Fatalf("unexpected dice: %+v", msg.Dice)
+	}
+	req := <-requests
+	if req.params.Get("chat_id") != "123" || req.params.Get("emoji") != "🎰" {
+		t.Fatalf("unexpected request: %v", req.params)
+	}
+}
+
+func TestClientWithContext(t *testing.T) {
+	release := make(chan struct{})
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		fmt.Fprint(w, `{"ok": true, "result": {"message_id": 321}}`)
+	}
+	httpServer := httptest.NewServer(http.HandlerFunc(handler))
+	defer httpServer.Close()
+	defer close(release)
+	c := tbot.NewClient(token, httpServer.Client(), httpServer.URL)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	start := time.Now()
+	_, err := c.WithContext(ctx).SendMessage(tbot.ChatID(123), "hi")
+	if err == nil || !strings.Contains(err.Error(), context.Canceled.Error()) {
+		t.Fatalf("expected cancelled request, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("request was not aborted: %v", elapsed)
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	_, err = c.WithContext(ctx).SendDocument(tbot.ChatID(123), tbot.InputFileReader("doc.txt", strings.NewReader("doc")))
+	if err == nil {
+		t.Fatalf("expected error for cancelled upload")
+	}
+}
+
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) {
+	return 0, errors.New("disk is on fire")
+}
+
+func TestUploadErrors(t *testing.T) {
+	httpServer := httptest.NewServer(http.NotFoundHandler())
+	c := tbot.NewClient(token, httpServer.Client(), httpServer.URL)
+	httpServer.Close()
+	_, err := c.SendDocument(tbot.ChatID(123), tbot.InputFileReader("doc.txt", strings.NewReader("doc")))
+	if err == nil || errors.Is(err, tbot.ErrAmbiguousDelivery) || strings.Contains(err.Error(), "request is done") {
+		t.Fatalf("expected dial error, got %v", err)
+	}
+
+	hangUp := func(w http.ResponseWriter, r *http.Request) {
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Errorf("unable to hijack connection: %v", err)
+			return
+		}
+		conn.Close()
+	}
+	httpServer = httptest.NewServer(http.HandlerFunc(hangUp))
+	defer httpServer.Close()
+	c = tbot.NewClient(token, httpServer.Client(), httpServer.URL)
+	_, err = c.SendDocument(tbot.ChatID(123), tbot.InputFileReader("doc.txt", strings.NewReader(strings.Repeat("doc", 1<<20))))
+	if !errors.Is(err, tbot.ErrAmbiguousDelivery) {
+		t.Fatalf("expected ambiguous delivery, got %v", err)
+	}
+
+	c = testClient(t, `{"ok": true, "result": {"message_id": 1}}`)
+	_, err = c.SendDocument(tbot.ChatID(123), tbot.InputFileReader("doc.txt", failingReader{}))
+	if err == nil || errors.Is(err, tbot.ErrAmbiguousDelivery) || !strings.Contains(err.Error(), "disk is on fire") {
+		t.Fatalf("expected file read error, got %v", err)
+	}
+}
+
+func TestDeduplicatorTimeout(t *testing.T) {
+	release := make(chan struct{})
+	var mu sync.Mutex
+	var sent []string
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		mu.Lock()
+		sent = append(sent, r.Form.Get("text"))
+		first := len(sent) == 1
+		mu.Unlock()
+		if first {
+			// message is delivered, but response is late
+			<-release
+		}
+		fmt.Fprint(w, `{"ok": true, "result": {"message_id": 321}}`)
+	}
+	httpServer := httptest.NewServer(http.HandlerFunc(handler))
+	defer httpServer.Close()
+	defer close(release)
+	httpClient := httpServer.Client()
+	httpClient.Timeout = 50 * time.Millisecond
+	c := tbot.NewClient(token, httpClient, httpServer.URL)
+
+	send := func(text string) func() error {
+		return func() error {
+			_, err := c.SendMessage(tbot.ChatID(123), text)
+			return err
+		}
+	}
+	dedup := tbot.NewDeduplicator(time.Minute)
+	err := dedup.Do("update-1", send("hello"))
+	var deliveryErr *tbot.DeliveryError
+	if !errors.Is(err, tbot.ErrAmbiguousDelivery) || !errors.As(err, &deliveryErr) || deliveryErr.Method != "sendMessage" {
+		t.Fatalf("expected ambiguous delivery of sendMessage, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "sendMessage request may have been delivered") {
+		t.Fatalf("unexpected error message: %v", err)
+	}
+	err = dedup.Do("update-1", send("hello"))
+	if err != nil {
+		t.Fatalf("retry should be suppressed, got %v", err)
+	}
+	err = dedup.Do("update-2", send("world"))
+	if err != nil {
+		t.Fatalf("error on sendMessage: %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if !reflect.DeepEqual(sent, []string{"hello", "world"}) {
+		t.Fatalf("unexpected sent messages: %v", sent)
+	}
+}
+
+func TestDeduplicatorConcurrent(t *testing.T) {
+	dedup := tbot.NewDeduplicator(time.Minute)
+	var mu sync.Mutex
+	calls := 0
+	send := func(err error) func() error {
+		return func() error {
+			mu.Lock()
+			calls++
+			mu.Unlock()
+			time.Sleep(10 * time.Millisecond)
+			return err
+		}
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := dedup.Do("update-1", send(nil)); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if calls != 1 {
+		t.Fatalf("expected one call for concurrent retries, got %d", calls)
+	}
+
+	// failed call is not remembered, waiting retry is called
+	started, release := make(chan struct{}), make(chan struct{})
+	go func() {
+		dedup.Do("update-2", func() error {
+			close(started)
+			<-release
+			return errors.New("bad request")
+		})
+	}()
+	<-started
+	retried := make(chan error)
+	go func() {
+		retried <- dedup.Do("update-2", send(nil))
+	}()
+	close(release)
+	if err := <-retried; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls != 2 {
+		t.Fatalf("expected retry after failed call, got %d calls", calls)
+	}
+}
+
+func TestDialErrorIsNotAmbiguous(t *testing.T) {
+	httpServer := httptest.NewServer(http.NotFoundHandler())
+	c := tbot.NewClient(token, httpServer.Client(), httpServer.URL)
+	httpServer.Close()
+	_, err := c.EditMessageText(tbot.ChatID(123), 7, "hello")
+	if err == nil || errors.Is(err, tbot.ErrAmbiguousDelivery) {
+		t.Fatalf("expected unambiguous error, got %v", err)
+	}
+	var opErr *net.OpError
+	if !errors.As(err, &opErr) || !strings.Contains(err.Error(), "editMessageText") {
+		t.Fatalf("expected dial error of editMessageText, got %v", err)
+	}
+}
+
+func testClient(t *testing.T, resp string) *tbot.Client {
+	t.Helper()
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, resp)
+	}
+	httpServer := httptest.NewServer(http.HandlerFunc(handler))
+	httpClient := httpServer.Client()
+	return tbot.NewClient(token, httpClient, httpServer.URL)
+}
+
+// testClientStatus returns client of the server responding with status,
+// the server is closed by the returned func
+func testClientStatus(t *testing.T, status int, resp string) (*tbot.Client, func()) {
+	t.Helper()
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		fmt.Fprint(w, resp)
+	}
+	httpServer := httptest.NewServer(http.HandlerFunc(handler))
+	return tbot.NewClient(token, httpServer.Client(), httpServer.URL), httpServer.Close
+}
+
+// apiRequest is a Bot API request captured by testRecorder
+type apiRequest struct {
+	method    string
+	multipart bool
+	params    url.Values
+	files     map[string]string
+	filenames map[string]string
+}
+
+// testRecorder returns client of the server recording requests,
+// the server is closed by the returned func
+func testRecorder(t *testing.T, resp string) (*tbot.Client, chan *apiRequest, func()) {
+	t.Helper()
+	requests := make(chan *apiRequest, 100)
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		req := &apiRequest{
+			method:    path.Base(r.URL.Path),
+			files:     map[string]string{},
+			filenames: map[string]string{},
+		}
+		if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+			req.multipart = true
+			err := r.ParseMultipartForm(1 << 20)
+			if err != nil {
+				t.Errorf("unable to parse multipart form: %v", err)
+			}
+			for field, headers := range r.MultipartForm.File {
+				f, _ := headers[0].Open()
+				data, _ := ioutil.ReadAll(f)
+				f.Close()
+				req.files[field] = string(data)
+				req.filenames[field] = headers[0].Filename
+			}
+		} else {
+			r.ParseForm()
+		}
+		req.params = r.Form
+		requests <- req
+		fmt.Fprint(w, resp)
+	}
+	httpServer := httptest.NewServer(http.HandlerFunc(handler))
+	return tbot.NewClient(token, httpServer.Client(), httpServer.URL), requests, httpServer.Close
 }
@@ -0,0 +1,82 @@
+package tbot
+
+import (
+	"fmt"
+	"reflect"
+)
+
+/*
+CommandSet declares bot commands of every scope at one place,
+Client.SyncCommands updates commands in Telegram to match the set:
+
+	commands := tbot.NewCommandSet()
+	commands.Add(tbot.BotCommandScopeDefault{},
+		tbot.BotCommand{Command: "help", Description: "Show help"})
+	commands.Add(tbot.BotCommandScopeAllChatAdministrators{},
+		tbot.BotCommand{Command: "help", Description: "Show help"},
+		tbot.BotCommand{Command: "ban", Description: "Ban the user"})
+	err := client.SyncCommands(commands)
+
+Scope added without commands has its commands deleted, scopes not added to the set are not changed.
+*/
+type CommandSet struct {
+	scopes []*scopeCommands
+}
+
+type scopeCommands struct {
+	scope        BotCommandScope
+	languageCode string
+	commands     []BotCommand
+}
+
+// NewCommandSet creates empty CommandSet
+func NewCommandSet() *CommandSet {
+	return &CommandSet{}
+}
+
+// Add adds commands to the scope for users of all languages
+func (s *CommandSet) Add(scope BotCommandScope, commands ...BotCommand) {
+	s.AddLanguage(scope, "", commands...)
+}
+
+// AddLanguage adds commands to the scope for users with languageCode
+func (s *CommandSet) AddLanguage(scope BotCommandScope, languageCode string, commands ...BotCommand) {
+	for _, sc := range s.scopes {
+		if sc.scope.commandScope() == scope.commandScope() && sc.languageCode == languageCode {
+			sc.commands = append(sc.commands, commands...)
+			return
+		}
+	}
+	s.scopes = append(s.scopes, &scopeCommands{scope: scope, languageCode: languageCode, commands: commands})
+}
+
+func (sc *scopeCommands) opts() []sendOption {
+	opts := []sendOption{OptCommandScope(sc.scope)}
+	if sc.languageCode != "" {
+		opts = append(opts, OptLanguageCode(sc.languageCode))
+	}
+	return opts
+}
+
+// SyncCommands updates bot commands to match the set. Current commands of each scope
+// are requested with GetMyCommands, only changed scopes are updated.
+func (c *Client) SyncCommands(set *CommandSet) error {
+	for _, sc := range set.scopes {
+		current, err := c.GetMyCommands(sc.opts()...)
+		if err != nil {
+			return fmt.Errorf("unable to get %s commands: %v", sc.scope.commandScope().Type, err)
+		}
+		if len(*current) == 0 && len(sc.commands) == 0 || reflect.DeepEqual(*current, sc.commands) {
+			continue
+		}
+		if len(sc.commands) == 0 {
+			err = c.DeleteMyCommands(sc.opts()...)
+		} else {
+			err = c.SetMyCommands(sc.commands, sc.opts()...)
+		}
+		if err != nil {
+			return fmt.Errorf("unable to update %s commands: %v", sc.scope.commandScope().Type, err)
+		}
+	}
+	return nil
+}
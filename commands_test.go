@@ -0,0 +1,49 @@
+package tbot
+
+import (
+	"net/http"
+	"path"
+	"reflect"
+	"testing"
+)
+
+func TestSyncCommands(t *testing.T) {
+	current := map[string]string{
+		`{"type":"default"}`:                 `[{"command":"help","description":"Show help"}]`,
+		`{"type":"all_chat_administrators"}`: `[{"command":"help","description":"Show help"}]`,
+		`{"type":"all_group_chats"}`:         `[{"command":"start","description":"Start"}]`,
+		`{"type":"chat","chat_id":-100}`:     `[]`,
+	}
+	var updates []string
+	transport := func(r *http.Request) (*http.Response, error) {
+		r.ParseForm()
+		scope := r.PostForm.Get("scope")
+		switch method := path.Base(r.URL.Path); method {
+		case "getMyCommands":
+			return jsonResponse(`{"ok": true, "result": ` + current[scope] + `}`), nil
+		default:
+			updates = append(updates, method+" "+scope+" "+r.PostForm.Get("commands"))
+		}
+		return jsonResponse(`{"ok": true, "result": true}`), nil
+	}
+	c := NewClient("TOKEN", &http.Client{Transport: roundTripFunc(transport)}, "https://api.telegram.org")
+
+	help := BotCommand{Command: "help", Description: "Show help"}
+	ban := BotCommand{Command: "ban", Description: "Ban the user"}
+	set := NewCommandSet()
+	set.Add(BotCommandScopeDefault{}, help)
+	set.Add(BotCommandScopeAllChatAdministrators{}, help)
+	set.Add(BotCommandScopeAllChatAdministrators{}, ban)
+	set.Add(BotCommandScopeAllGroupChats{})
+	set.Add(BotCommandScopeChat{ChatID: -100})
+	if err := c.SyncCommands(set); err != nil {
+		t.Fatalf("error on sync: %v", err)
+	}
+	expected := []string{
+		`setMyCommands {"type":"all_chat_administrators"} [{"command":"help","description":"Show help"},{"command":"ban","description":"Ban the user"}]`,
+		`deleteMyCommands {"type":"all_group_chats"} `,
+	}
+	if !reflect.DeepEqual(updates, expected) {
+		t.Fatalf("unexpected updates:\n%v", updates)
+	}
+}
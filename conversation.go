@@ -0,0 +1,115 @@
+package tbot
+
+import "sync"
+
+// Conversation keeps states of multi-step dialogs.
+// State is tracked separately for each user in each chat,
+// empty state means user is not in a dialog.
+type Conversation struct {
+	store StateStore
+}
+
+/*
+StateStore keeps conversation states. Default store keeps states in memory,
+implement StateStore to keep them in external storage, so states survive restarts
+and can be shared between bot replicas. Get should return empty state
+without error for users not in a dialog. For example, with Redis client:
+
+	type redisStore struct {
+		client *redis.Client
+	}
+
+	func (r *redisStore) key(chatID, userID int64) string {
+		return fmt.Sprintf("tbot:state:%d:%d", chatID, userID)
+	}
+
+	func (r *redisStore) Get(chatID, userID int64) (string, error) {
+		state, err := r.client.Get(r.key(chatID, userID)).Result()
+		if err == redis.Nil {
+			return "", nil
+		}
+		return state, err
+	}
+
+	func (r *redisStore) Set(chatID, userID int64, state string) error {
+		return r.client.Set(r.key(chatID, userID), state, 0).Err()
+	}
+
+	func (r *redisStore) Delete(chatID, userID int64) error {
+		return r.client.Del(r.key(chatID, userID)).Err()
+	}
+
+	bot := tbot.New(token, tbot.WithStateStore(&redisStore{client: client}))
+*/
+type StateStore interface {
+	Get(chatID, userID int64) (string, error)
+	Set(chatID, userID int64, state string) error
+	Delete(chatID, userID int64) error
+}
+
+type stateKey struct {
+	chatID int64
+	userID int64
+}
+
+type memoryStateStore struct {
+	mu     sync.Mutex
+	states map[stateKey]string
+}
+
+// NewMemoryStateStore returns StateStore keeping states in memory
+func NewMemoryStateStore() StateStore {
+	return &memoryStateStore{states: make(map[stateKey]string)}
+}
+
+func (m *memoryStateStore) Get(chatID, userID int64) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.states[stateKey{chatID: chatID, userID: userID}], nil
+}
+
+func (m *memoryStateStore) Set(chatID, userID int64, state string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.states[stateKey{chatID: chatID, userID: userID}] = state
+	return nil
+}
+
+func (m *memoryStateStore) Delete(chatID, userID int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.states, stateKey{chatID: chatID, userID: userID})
+	return nil
+}
+
+// WithStateStore sets store for conversation states
+func WithStateStore(store StateStore) ServerOption {
+	return func(s *Server) {
+		s.conversation.store = store
+	}
+}
+
+// SetState sets state of the user in the chat
+func (c *Conversation) SetState(chatID, userID int64, state string) error {
+	if state == "" {
+		return c.ClearState(chatID, userID)
+	}
+	return c.store.Set(chatID, userID, state)
+}
+
+// GetState returns state of the user in the chat
+func (c *Conversation) GetState(chatID, userID int64) (string, error) {
+	return c.store.Get(chatID, userID)
+}
+
+// ClearState resets state of the user in the chat
+func (c *Conversation) ClearState(chatID, userID int64) error {
+	return c.store.Delete(chatID, userID)
+}
+
+func (c *Conversation) messageState(m *Message) (string, error) {
+	if m.From == nil {
+		return "", nil
+	}
+	return c.GetState(m.Chat.ID, int64(m.From.ID))
+}
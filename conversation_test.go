@@ -0,0 +1,100 @@
+package tbot
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestConversationRegistration(t *testing.T) {
+	s := New("TOKEN")
+	conv := s.Conversation()
+	var name, age string
+	var other int
+	s.HandleMessage("/register", func(m *Message) {
+		conv.SetState(m.Chat.ID, int64(m.From.ID), "name")
+	})
+	s.HandleState("name", func(m *Message) {
+		name = m.Text
+		conv.SetState(m.Chat.ID, int64(m.From.ID), "age")
+	})
+	s.HandleState("age", func(m *Message) {
+		age = m.Text
+		conv.ClearState(m.Chat.ID, int64(m.From.ID))
+	})
+	s.HandleDefault(func(m *Message) {
+		other++
+	})
+
+	user := &User{ID: 2}
+	for _, text := range []string{"/register", "Alice", "30", "hello"} {
+		s.processSingleUpdate(&Update{Message: &Message{Text: text, From: user, Chat: Chat{ID: 1}}})
+	}
+	if name != "Alice" || age != "30" {
+		t.Fatalf("unexpected registration: name %q, age %q", name, age)
+	}
+	if other != 1 {
+		t.Fatalf("expected message after registration to reach default handler, got %d", other)
+	}
+	if state, _ := conv.GetState(1, 2); state != "" {
+		t.Fatalf("expected empty state, got %q", state)
+	}
+}
+
+func TestConversationPerUser(t *testing.T) {
+	s := New("TOKEN")
+	var inState, other int
+	s.HandleState("name", func(*Message) { inState++ })
+	s.HandleDefault(func(*Message) { other++ })
+	s.HandleMessage("/start", func(*Message) { other++ })
+	s.Conversation().SetState(1, 2, "name")
+
+	// state handler takes precedence over message handler
+	s.processSingleUpdate(&Update{Message: &Message{Text: "/start", From: &User{ID: 2}, Chat: Chat{ID: 1}}})
+	s.processSingleUpdate(&Update{Message: &Message{Text: "b", From: &User{ID: 3}, Chat: Chat{ID: 1}}})
+	s.processSingleUpdate(&Update{Message: &Message{Text: "c", From: &User{ID: 2}, Chat: Chat{ID: 5}}})
+	if inState != 1 || other != 2 {
+		t.Fatalf("unexpected calls: in state %d, other %d", inState, other)
+	}
+}
+
+type fakeStateStore struct {
+	states map[int64]string
+	calls  []string
+}
+
+func (f *fakeStateStore) Get(chatID, userID int64) (string, error) {
+	f.calls = append(f.calls, "get")
+	return f.states[userID], nil
+}
+
+func (f *fakeStateStore) Set(chatID, userID int64, state string) error {
+	f.calls = append(f.calls, "set "+state)
+	f.states[userID] = state
+	return nil
+}
+
+func (f *fakeStateStore) Delete(chatID, userID int64) error {
+	f.calls = append(f.calls, "delete")
+	delete(f.states, userID)
+	return nil
+}
+
+func TestConversationStateStore(t *testing.T) {
+	store := &fakeStateStore{states: map[int64]string{}}
+	s := New("TOKEN", WithStateStore(store))
+	conv := s.Conversation()
+	s.HandleMessage("/start", func(m *Message) {
+		conv.SetState(m.Chat.ID, int64(m.From.ID), "name")
+	})
+	s.HandleState("name", func(m *Message) {
+		conv.ClearState(m.Chat.ID, int64(m.From.ID))
+	})
+
+	for _, text := range []string{"/start", "Alice"} {
+		s.processSingleUpdate(&Update{Message: &Message{Text: text, From: &User{ID: 2}, Chat: Chat{ID: 1}}})
+	}
+	expected := []string{"get", "set name", "get", "delete"}
+	if !reflect.DeepEqual(store.calls, expected) {
+		t.Fatalf("expected store calls %v, got %v", expected, store.calls)
+	}
+}
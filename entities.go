@@ -0,0 +1,125 @@
+package tbot
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf16"
+)
+
+// entities returns message text or caption with their entities
+func (m *Message) entities() (string, []*MessageEntity) {
+	if m.Text != "" {
+		return m.Text, m.Entities
+	}
+	return m.Caption, m.CaptionEntities
+}
+
+// EntityText returns part of the message text (or caption for media messages) covered by the entity.
+// Entity offset and length are in UTF-16 code units, so they can't be used to slice Go strings directly.
+func (m *Message) EntityText(e *MessageEntity) string {
+	text, _ := m.entities()
+	return utf16Slice(text, e.Offset, e.Length)
+}
+
+// CommandAndArgs returns bot command the message starts with, e.g. "/start",
+// and space separated arguments following it. Bot username is removed from the command.
+// Command is empty if the message doesn't start with a command.
+func (m *Message) CommandAndArgs() (command string, args []string) {
+	text, entities := m.entities()
+	for _, e := range entities {
+		if e.Type != "bot_command" || e.Offset != 0 {
+			continue
+		}
+		command = utf16Slice(text, 0, e.Length)
+		if i := strings.Index(command, "@"); i != -1 {
+			command = command[:i]
+		}
+		return command, strings.Fields(text[len(utf16Slice(text, 0, e.Length)):])
+	}
+	return "", nil
+}
+
+// Mentions returns usernames mentioned in the message, e.g. "@username".
+// Users without usernames are mentioned with "text_mention" entities, check MessageEntity.User for them.
+func (m *Message) Mentions() []string {
+	return m.entityTexts("mention")
+}
+
+// URLs returns links in the message, both written in the text and attached to the text ("text_link" entities)
+func (m *Message) URLs() []string {
+	text, entities := m.entities()
+	var urls []string
+	for _, e := range entities {
+		switch e.Type {
+		case "url":
+			urls = append(urls, utf16Slice(text, e.Offset, e.Length))
+		case "text_link":
+			urls = append(urls, e.URL)
+		}
+	}
+	return urls
+}
+
+// Hashtags returns hashtags in the message, e.g. "#golang"
+func (m *Message) Hashtags() []string {
+	return m.entityTexts("hashtag")
+}
+
+func (m *Message) entityTexts(entityType string) []string {
+	text, entities := m.entities()
+	var texts []string
+	for _, e := range entities {
+		if e.Type == entityType {
+			texts = append(texts, utf16Slice(text, e.Offset, e.Length))
+		}
+	}
+	return texts
+}
+
+// EntityOffset converts index of the rune in text into offset in UTF-16 code units
+// used by MessageEntity. Characters outside of the Basic Multilingual Plane, e.g. most emoji,
+// take 2 code units, so neither byte nor rune indexes can be used as entity offsets.
+// Combining characters are separate runes and are counted separately.
+// Index out of range is clamped to the text.
+func EntityOffset(text string, runeIndex int) int {
+	offset := 0
+	for i, r := range []rune(text) {
+		if i >= runeIndex {
+			break
+		}
+		offset += utf16RuneLen(r)
+	}
+	return offset
+}
+
+func utf16RuneLen(r rune) int {
+	if r >= 0x10000 && r <= unicode.MaxRune {
+		return 2
+	}
+	return 1
+}
+
+// EntityLength returns length of the text in UTF-16 code units, see EntityOffset
+func EntityLength(text string) int {
+	return EntityOffset(text, len(text))
+}
+
+// utf16Slice returns substring at offset with length measured in UTF-16 code units,
+// out of range offset and length are clamped to the text
+func utf16Slice(text string, offset, length int) string {
+	encoded := utf16.Encode([]rune(text))
+	if offset < 0 {
+		offset = 0
+	}
+	if offset > len(encoded) {
+		offset = len(encoded)
+	}
+	end := offset + length
+	if end > len(encoded) {
+		end = len(encoded)
+	}
+	if end < offset {
+		end = offset
+	}
+	return string(utf16.Decode(encoded[offset:end]))
+}
@@ -0,0 +1,109 @@
+package tbot_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/yanzay/tbot/v2"
+)
+
+func TestMessageEntities(t *testing.T) {
+	// 👋 takes 2 UTF-16 code units and 4 bytes, ü takes 1 code unit and 2 bytes
+	msg := &tbot.Message{
+		Text: "👋 Grüße @gopher, see https://go.dev and docs #golang",
+		Entities: []*tbot.MessageEntity{
+			{Type: "mention", Offset: 9, Length: 7},
+			{Type: "url", Offset: 22, Length: 14},
+			{Type: "text_link", Offset: 41, Length: 4, URL: "https://pkg.go.dev"},
+			{Type: "hashtag", Offset: 46, Length: 7},
+		},
+	}
+	if text := msg.EntityText(msg.Entities[0]); text != "@gopher" {
+		t.Fatalf("unexpected mention text: %q", text)
+	}
+	if text := msg.EntityText(msg.Entities[2]); text != "docs" {
+		t.Fatalf("unexpected link text: %q", text)
+	}
+	if mentions := msg.Mentions(); !reflect.DeepEqual(mentions, []string{"@gopher"}) {
+		t.Fatalf("unexpected mentions: %v", mentions)
+	}
+	if urls := msg.URLs(); !reflect.DeepEqual(urls, []string{"https://go.dev", "https://pkg.go.dev"}) {
+		t.Fatalf("unexpected urls: %v", urls)
+	}
+	if hashtags := msg.Hashtags(); !reflect.DeepEqual(hashtags, []string{"#golang"}) {
+		t.Fatalf("unexpected hashtags: %v", hashtags)
+	}
+	out := &tbot.MessageEntity{Type: "bold", Offset: 50, Length: 100}
+	if text := msg.EntityText(out); text != "ang" {
+		t.Fatalf("entity out of range should be clamped, got %q", text)
+	}
+}
+
+func TestMessageCommandAndArgs(t *testing.T) {
+	tt := []struct {
+		msg     *tbot.Message
+		command string
+		args    []string
+	}{
+		{
+			msg: &tbot.Message{
+				Text:     "/start@testbot 🎉 ready",
+				Entities: []*tbot.MessageEntity{{Type: "bot_command", Offset: 0, Length: 14}},
+			},
+			command: "/start",
+			args:    []string{"🎉", "ready"},
+		},
+		{
+			msg: &tbot.Message{
+				Caption:         "/resize 100 200",
+				CaptionEntities: []*tbot.MessageEntity{{Type: "bot_command", Offset: 0, Length: 7}},
+			},
+			command: "/resize",
+			args:    []string{"100", "200"},
+		},
+		{
+			msg: &tbot.Message{
+				Text:     "😀 /start",
+				Entities: []*tbot.MessageEntity{{Type: "bot_command", Offset: 3, Length: 6}},
+			},
+		},
+	}
+	for _, tc := range tt {
+		command, args := tc.msg.CommandAndArgs()
+		if command != tc.command || !reflect.DeepEqual(args, tc.args) {
+			t.Fatalf("expected %q %v, got %q %v", tc.command, tc.args, command, args)
+		}
+	}
+}
+
+func TestEntityOffset(t *testing.T) {
+	// "é" is "e" followed by combining acute accent, 2 runes
+	text := "Hi 👋🏽 café ok"
+	tt := []struct {
+		runeIndex int
+		expected  int
+	}{
+		{runeIndex: 0, expected: 0},
+		{runeIndex: 3, expected: 3},
+		{runeIndex: 4, expected: 5},   // after 👋
+		{runeIndex: 5, expected: 7},   // after skin tone modifier
+		{runeIndex: 10, expected: 12}, // after "e", before the accent
+		{runeIndex: 11, expected: 13},
+		{runeIndex: 100, expected: 16},
+	}
+	for _, tc := range tt {
+		if offset := tbot.EntityOffset(text, tc.runeIndex); offset != tc.expected {
+			t.Fatalf("rune %d: expected offset %d, got %d", tc.runeIndex, tc.expected, offset)
+		}
+	}
+	if length := tbot.EntityLength(text); length != 16 {
+		t.Fatalf("expected length 16, got %d", length)
+	}
+
+	runes := []rune(text)
+	msg := &tbot.Message{Text: text}
+	bold := &tbot.MessageEntity{Type: "bold", Offset: tbot.EntityOffset(text, 6), Length: tbot.EntityLength(string(runes[6:11]))}
+	if got := msg.EntityText(bold); got != "café" {
+		t.Fatalf("unexpected entity text: %q", got)
+	}
+}
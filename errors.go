@@ -0,0 +1,150 @@
+package tbot
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// APIError is an error returned by Telegram Bot API
+type APIError struct {
+	Method          string // Bot API method, e.g. "sendMessage"
+	Code            int    // error_code from the response, usually HTTP status code
+	Description     string // human-readable description of the error
+	RetryAfter      int    // seconds to wait before the request can be repeated, set on flood control
+	MigrateToChatID int64  // new identifier of the group migrated to a supergroup
+}
+
+func (e *APIError) Error() string {
+	return e.Description
+}
+
+// Common Bot API errors. APIError matches them by description, check them with errors.Is:
+//
+//	err := client.SetChatTitle(chatID, title)
+//	if errors.Is(err, tbot.ErrNotEnoughRights) {
+//		// ask to promote the bot
+//	}
+var (
+	// ErrNotEnoughRights is returned if the bot lacks administrator rights for the action
+	ErrNotEnoughRights = errors.New("not enough rights")
+	// ErrMessageNotFound is returned for messages already deleted or never existed
+	ErrMessageNotFound = errors.New("message not found")
+	// ErrMessageCantBeDeleted is returned for messages that are too old to be deleted,
+	// or if the bot has no rights to delete messages of other users
+	ErrMessageCantBeDeleted = errors.New("message can't be deleted")
+	// ErrMessageNotModified is returned on edit if the new content is the same as the current one,
+	// it is usually safe to ignore
+	ErrMessageNotModified = errors.New("message is not modified")
+	// ErrMessageTooLong is returned for message text longer than MaxMessageLength,
+	// use Client.SendLongMessage to send it as several messages
+	ErrMessageTooLong = errors.New("message is too long")
+	// ErrBotBlocked is returned if the user blocked the bot or the bot was removed from the chat
+	ErrBotBlocked = errors.New("bot was blocked")
+	// ErrChatNotFound is returned for unknown or inaccessible chats
+	ErrChatNotFound = errors.New("chat not found")
+	// ErrAmbiguousDelivery is matched by DeliveryError, the request may have been processed by Telegram
+	ErrAmbiguousDelivery = errors.New("request may have been delivered")
+)
+
+var errorDescriptions = map[error][]string{
+	ErrNotEnoughRights: {
+		"not enough rights",
+		"have no rights",
+		"chat_admin_required",
+		"need administrator rights",
+	},
+	ErrMessageNotFound: {
+		"message to delete not found",
+		"message not found",
+		"message_id_invalid",
+	},
+	ErrMessageCantBeDeleted: {
+		"message can't be deleted",
+	},
+	ErrMessageNotModified: {
+		"message is not modified",
+	},
+	ErrMessageTooLong: {
+		"message is too long",
+		"message_too_long",
+	},
+	ErrBotBlocked: {
+		"bot was blocked by the user",
+		"bot was kicked",
+		"bot is not a member",
+		"user is deactivated",
+	},
+	ErrChatNotFound: {
+		"chat not found",
+	},
+}
+
+// Is reports whether the error is one of the common Bot API errors, e.g. ErrNotEnoughRights
+func (e *APIError) Is(target error) bool {
+	description := strings.ToLower(e.Description)
+	for _, substr := range errorDescriptions[target] {
+		if strings.Contains(description, substr) {
+			return true
+		}
+	}
+	return false
+}
+
+// DeliveryError is returned when request was sent, but response was not received,
+// e.g. on timeout. Telegram may have processed the request, so retrying a send
+// can post the message twice. It matches ErrAmbiguousDelivery, see also Deduplicator.
+type DeliveryError struct {
+	Method string
+	Err    error
+}
+
+func (e *DeliveryError) Error() string {
+	return fmt.Sprintf("%s request may have been delivered: %v", e.Method, e.Err)
+}
+
+// Unwrap returns the transport error
+func (e *DeliveryError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is ErrAmbiguousDelivery
+func (e *DeliveryError) Is(target error) bool {
+	return target == ErrAmbiguousDelivery
+}
+
+// transportError wraps errors of requests which may have reached Telegram into DeliveryError
+func transportError(method string, err error) error {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return fmt.Errorf("unable to send %s request: %w", method, err)
+	}
+	return &DeliveryError{Method: method, Err: err}
+}
+
+// FileDownloadError is returned by DownloadFile when file server responds with
+// non-200 HTTP status, unlike APIError it has no Bot API description
+type FileDownloadError struct {
+	StatusCode int
+	Status     string // e.g. "404 Not Found"
+}
+
+func (e *FileDownloadError) Error() string {
+	return fmt.Sprintf("unable to download file: %s", e.Status)
+}
+
+// MessageBatchError is returned by DeleteMessages, ForwardMessages and CopyMessages
+// for message IDs rejected before the request: empty, longer than MaxMessageBatch,
+// or, for forwarding and copying, not in strictly increasing order
+type MessageBatchError struct {
+	Count    int  // number of message IDs
+	Unsorted bool // message IDs are not in strictly increasing order
+}
+
+func (e *MessageBatchError) Error() string {
+	if e.Unsorted {
+		return "message IDs must be in strictly increasing order"
+	}
+	return fmt.Sprintf("number of messages must be 1-%d, got %d", MaxMessageBatch, e.Count)
+}
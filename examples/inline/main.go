@@ -4,12 +4,16 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"sync"
 
 	"github.com/yanzay/tbot/v2"
 )
 
 type application struct {
-	client  *tbot.Client
+	client tbot.TelegramClient
+
+	// handlers are called concurrently, votings are guarded by mu
+	mu      sync.Mutex
 	votings map[string]*voting
 }
 
@@ -21,32 +25,50 @@ type voting struct {
 func main() {
 	token := os.Getenv("TELEGRAM_TOKEN")
 	bot := tbot.New(token)
+	newApplication(bot)
+	bot.Start()
+}
+
+func newApplication(bot *tbot.Server) *application {
 	app := &application{
+		client:  bot.Client(),
 		votings: make(map[string]*voting),
 	}
-	app.client = bot.Client()
 	bot.HandleMessage("/vote", app.votingHandler)
 	bot.HandleCallback(app.callbackHandler)
-	bot.Start()
+	return app
 }
 
 func (a *application) votingHandler(m *tbot.Message) {
 	buttons := makeButtons(0, 0)
 	msg, _ := a.client.SendMessage(tbot.ChatID(m.Chat.ID), "Please vote", tbot.OptInlineKeyboardMarkup(buttons))
+	if msg == nil {
+		return
+	}
 	votingID := strconv.FormatInt(m.Chat.ID, 10) + ":" + strconv.Itoa(msg.MessageID)
+	a.mu.Lock()
 	a.votings[votingID] = &voting{}
+	a.mu.Unlock()
 }
 
 func (a *application) callbackHandler(cq *tbot.CallbackQuery) {
 	votingID := strconv.FormatInt(cq.Message.Chat.ID, 10) + ":" + strconv.Itoa(cq.Message.MessageID)
-	v := a.votings[votingID]
+	a.mu.Lock()
+	v, ok := a.votings[votingID]
+	if !ok {
+		a.mu.Unlock()
+		a.client.AnswerCallbackQuery(cq.ID, tbot.OptText("Voting is closed"))
+		return
+	}
 	if cq.Data == "up" {
 		v.ups++
 	}
 	if cq.Data == "down" {
 		v.downs++
 	}
-	buttons := makeButtons(v.ups, v.downs)
+	ups, downs := v.ups, v.downs
+	a.mu.Unlock()
+	buttons := makeButtons(ups, downs)
 	a.client.EditMessageReplyMarkup(tbot.ChatID(cq.Message.Chat.ID), cq.Message.MessageID, tbot.OptInlineKeyboardMarkup(buttons))
 	a.client.AnswerCallbackQuery(cq.ID, tbot.OptText("OK"))
 }
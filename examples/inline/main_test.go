@@ -0,0 +1,82 @@
+package main
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/yanzay/tbot/v2"
+)
+
+func TestVoting(t *testing.T) {
+	bot := tbot.NewTestServer()
+	app := newApplication(bot.Server)
+
+	bot.Send(&tbot.Update{Message: &tbot.Message{Text: "/vote", Chat: tbot.Chat{ID: 42}}})
+	sent := bot.Calls("sendMessage")
+	if len(sent) != 1 || sent[0].Params.Get("chat_id") != "42" || sent[0].Params.Get("text") != "Please vote" {
+		t.Fatalf("expected voting message to chat 42, got %v", sent)
+	}
+	if len(app.votings) != 1 {
+		t.Fatalf("voting should be created by /vote handler")
+	}
+
+	voting := &tbot.Message{MessageID: 1, Chat: tbot.Chat{ID: 42}}
+	bot.Send(&tbot.Update{CallbackQuery: &tbot.CallbackQuery{ID: "cq1", Message: voting, Data: "up"}})
+	bot.Send(&tbot.Update{CallbackQuery: &tbot.CallbackQuery{ID: "cq2", Message: voting, Data: "up"}})
+	bot.Send(&tbot.Update{CallbackQuery: &tbot.CallbackQuery{ID: "cq3", Message: voting, Data: "down"}})
+
+	edits := bot.Calls("editMessageReplyMarkup")
+	if len(edits) != 3 {
+		t.Fatalf("expected 3 keyboard updates, got %d", len(edits))
+	}
+	expected := `{"inline_keyboard":[[{"text":"👍 2","callback_data":"up"},{"text":"👎 1","callback_data":"down"}]]}`
+	if markup := edits[2].Params.Get("reply_markup"); markup != expected {
+		t.Fatalf("unexpected keyboard: %s", markup)
+	}
+	if answers := bot.Calls("answerCallbackQuery"); len(answers) != 3 || answers[2].Params.Get("callback_query_id") != "cq3" {
+		t.Fatalf("unexpected callback answers: %v", answers)
+	}
+}
+
+// TestConcurrentVotes handles votes like a webhook server does, run it with -race
+func TestConcurrentVotes(t *testing.T) {
+	bot := tbot.NewTestServer()
+	app := newApplication(bot.Server)
+	for chatID := int64(1); chatID <= 3; chatID++ {
+		bot.Send(&tbot.Update{Message: &tbot.Message{Text: "/vote", Chat: tbot.Chat{ID: chatID}}})
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 30; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			chatID := int64(i%3 + 1)
+			voting := &tbot.Message{MessageID: int(chatID), Chat: tbot.Chat{ID: chatID}}
+			bot.Send(&tbot.Update{CallbackQuery: &tbot.CallbackQuery{ID: "cq", Message: voting, Data: "up"}})
+		}(i)
+	}
+	wg.Wait()
+
+	app.mu.Lock()
+	defer app.mu.Unlock()
+	if len(app.votings) != 3 {
+		t.Fatalf("expected 3 votings, got %d", len(app.votings))
+	}
+	for id, v := range app.votings {
+		if v.ups != 10 {
+			t.Fatalf("voting %s: expected 10 votes, got %d", id, v.ups)
+		}
+	}
+}
+
+func TestVoteClosed(t *testing.T) {
+	bot := tbot.NewTestServer()
+	newApplication(bot.Server)
+	voting := &tbot.Message{MessageID: 7, Chat: tbot.Chat{ID: 42}}
+	bot.Send(&tbot.Update{CallbackQuery: &tbot.CallbackQuery{ID: "cq", Message: voting, Data: "up"}})
+	answers := bot.Calls("answerCallbackQuery")
+	if len(answers) != 1 || answers[0].Params.Get("text") != "Voting is closed" || len(bot.Calls("editMessageReplyMarkup")) != 0 {
+		t.Fatalf("unexpected calls: %v", bot.Calls(""))
+	}
+}
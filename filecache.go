@@ -0,0 +1,79 @@
+package tbot
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// fileCache is LRU cache of file paths resolved with getFile, see WithFileCache.
+// Telegram guarantees file path to be valid for at least an hour.
+type fileCache struct {
+	size int
+	ttl  time.Duration
+	now  func() time.Time
+
+	mu    sync.Mutex
+	order *list.List // most recently used first
+	items map[string]*list.Element
+}
+
+type fileCacheEntry struct {
+	fileID  string
+	path    string
+	expires time.Time
+}
+
+func newFileCache(size int, ttl time.Duration) *fileCache {
+	return &fileCache{
+		size:  size,
+		ttl:   ttl,
+		now:   time.Now,
+		order: list.New(),
+		items: make(map[string]*list.Element),
+	}
+}
+
+func (c *fileCache) get(fileID string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.items[fileID]
+	if !ok {
+		return "", false
+	}
+	entry := e.Value.(*fileCacheEntry)
+	if !c.now().Before(entry.expires) {
+		c.order.Remove(e)
+		delete(c.items, fileID)
+		return "", false
+	}
+	c.order.MoveToFront(e)
+	return entry.path, true
+}
+
+func (c *fileCache) put(fileID, path string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	expires := c.now().Add(c.ttl)
+	if e, ok := c.items[fileID]; ok {
+		entry := e.Value.(*fileCacheEntry)
+		entry.path, entry.expires = path, expires
+		c.order.MoveToFront(e)
+		return
+	}
+	c.items[fileID] = c.order.PushFront(&fileCacheEntry{fileID: fileID, path: path, expires: expires})
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*fileCacheEntry).fileID)
+	}
+}
+
+func (c *fileCache) remove(fileID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.items[fileID]; ok {
+		c.order.Remove(e)
+		delete(c.items, fileID)
+	}
+}
@@ -0,0 +1,92 @@
+package tbot
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFileCacheEviction(t *testing.T) {
+	c := newFileCache(2, time.Hour)
+	c.put("a", "photos/a.jpg")
+	c.put("b", "photos/b.jpg")
+	c.get("a")
+	c.put("c", "photos/c.jpg")
+	if _, ok := c.get("b"); ok {
+		t.Fatalf("least recently used file should be evicted")
+	}
+	if p, ok := c.get("a"); !ok || p != "photos/a.jpg" {
+		t.Fatalf("unexpected path of a: %q %v", p, ok)
+	}
+	if p, ok := c.get("c"); !ok || p != "photos/c.jpg" {
+		t.Fatalf("unexpected path of c: %q %v", p, ok)
+	}
+}
+
+// fileTransport serves getFile and file downloads, expired paths are answered with 400
+func fileTransport(getFileCalls *int, expired map[string]bool) *http.Client {
+	transport := func(r *http.Request) (*http.Response, error) {
+		if strings.Contains(r.URL.Path, "/file/") {
+			if expired[path.Base(r.URL.Path)] {
+				return &http.Response{StatusCode: http.StatusBadRequest, Status: "400 Bad Request", Body: http.NoBody}, nil
+			}
+			return jsonResponse("content of " + path.Base(r.URL.Path)), nil
+		}
+		*getFileCalls++
+		return jsonResponse(fmt.Sprintf(`{"ok": true, "result": {"file_id": "abc", "file_path": "photos/file_%d.jpg"}}`, *getFileCalls)), nil
+	}
+	return &http.Client{Transport: roundTripFunc(transport)}
+}
+
+func TestDownloadFileCache(t *testing.T) {
+	var getFileCalls int
+	expired := map[string]bool{}
+	s := New("TOKEN", WithHTTPClient(fileTransport(&getFileCalls, expired)), WithFileCache(10, time.Hour))
+	now := time.Now()
+	s.fileCache.now = func() time.Time { return now }
+
+	download := func() string {
+		t.Helper()
+		var buf bytes.Buffer
+		if err := s.client.DownloadFile("abc", &buf); err != nil {
+			t.Fatalf("error on download: %v", err)
+		}
+		return buf.String()
+	}
+	if content := download(); content != "content of file_1.jpg" {
+		t.Fatalf("unexpected content: %s", content)
+	}
+	download()
+	if getFileCalls != 1 {
+		t.Fatalf("second download should use cached path, getFile called %d times", getFileCalls)
+	}
+
+	now = now.Add(time.Hour)
+	if content := download(); content != "content of file_2.jpg" || getFileCalls != 2 {
+		t.Fatalf("path should be resolved after ttl: %s, %d getFile calls", content, getFileCalls)
+	}
+
+	expired["file_2.jpg"] = true
+	if content := download(); content != "content of file_3.jpg" || getFileCalls != 3 {
+		t.Fatalf("expired path should be resolved again: %s, %d getFile calls", content, getFileCalls)
+	}
+}
+
+func TestDownloadFileWithoutCache(t *testing.T) {
+	var getFileCalls int
+	s := New("TOKEN", WithHTTPClient(fileTransport(&getFileCalls, map[string]bool{"file_2.jpg": true})))
+	var buf bytes.Buffer
+	if err := s.client.DownloadFile("abc", &buf); err != nil {
+		t.Fatalf("error on download: %v", err)
+	}
+	if err := s.client.DownloadFile("abc", &buf); err == nil || !strings.Contains(err.Error(), "400") {
+		t.Fatalf("expected download error, got %v", err)
+	}
+	if getFileCalls != 2 {
+		t.Fatalf("expected getFile on every download, got %d calls", getFileCalls)
+	}
+}
@@ -1,5 +1,11 @@
 package tbot
 
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
 // Buttons construct ReplyKeyboardMarkup from strings
 func Buttons(buttons [][]string) *ReplyKeyboardMarkup {
 	keyboard := make([][]KeyboardButton, len(buttons))
@@ -11,3 +17,107 @@ func Buttons(buttons [][]string) *ReplyKeyboardMarkup {
 	}
 	return &ReplyKeyboardMarkup{Keyboard: keyboard}
 }
+
+// MaxCallbackDataLength is the maximum size of inline button callback data in bytes
+const MaxCallbackDataLength = 64
+
+const callbackDataDelimiter = ":"
+
+// EncodeCallbackData joins action and params into "action:param1:param2" callback data.
+// Telegram limits callback data to 64 bytes (bytes, not characters),
+// error is returned if the result is longer or any part contains the delimiter.
+// Action can be routed with HandleCallbackPrefix.
+func EncodeCallbackData(action string, params ...string) (string, error) {
+	parts := append([]string{action}, params...)
+	for _, part := range parts {
+		if strings.Contains(part, callbackDataDelimiter) {
+			return "", fmt.Errorf("callback data part %q contains delimiter %q", part, callbackDataDelimiter)
+		}
+	}
+	data := strings.Join(parts, callbackDataDelimiter)
+	if len(data) > MaxCallbackDataLength {
+		return "", fmt.Errorf("callback data is %d bytes long, max is %d", len(data), MaxCallbackDataLength)
+	}
+	return data, nil
+}
+
+// DecodeCallbackData splits callback data built by EncodeCallbackData into action and params
+func DecodeCallbackData(data string) (action string, params []string) {
+	parts := strings.Split(data, callbackDataDelimiter)
+	return parts[0], parts[1:]
+}
+
+// Paginator builds inline keyboards for long lists of buttons split into pages.
+// Navigation buttons have callback data in "<prefix>:<page>" form, use Page
+// to get requested page number from callback query.
+type Paginator struct {
+	items    []InlineKeyboardButton
+	pageSize int
+	prefix   string
+}
+
+// NewPaginator creates Paginator showing pageSize items per page.
+// Prefix is used in callback data of navigation buttons.
+func NewPaginator(items []InlineKeyboardButton, pageSize int, prefix string) *Paginator {
+	if pageSize < 1 {
+		pageSize = 1
+	}
+	return &Paginator{items: items, pageSize: pageSize, prefix: prefix}
+}
+
+// Pages returns number of pages
+func (p *Paginator) Pages() int {
+	if len(p.items) == 0 {
+		return 1
+	}
+	return (len(p.items) + p.pageSize - 1) / p.pageSize
+}
+
+// Markup returns keyboard for the page, one item per row followed by ◀/▶ navigation buttons.
+// Pages are numbered from 0, page out of range is clamped to the first or the last page.
+func (p *Paginator) Markup(page int) *InlineKeyboardMarkup {
+	if page >= p.Pages() {
+		page = p.Pages() - 1
+	}
+	if page < 0 {
+		page = 0
+	}
+	start := page * p.pageSize
+	end := start + p.pageSize
+	if end > len(p.items) {
+		end = len(p.items)
+	}
+	keyboard := make([][]InlineKeyboardButton, 0, end-start+1)
+	for _, item := range p.items[start:end] {
+		keyboard = append(keyboard, []InlineKeyboardButton{item})
+	}
+	var nav []InlineKeyboardButton
+	if page > 0 {
+		nav = append(nav, InlineKeyboardButton{Text: "◀", CallbackData: p.callbackData(page - 1)})
+	}
+	if page < p.Pages()-1 {
+		nav = append(nav, InlineKeyboardButton{Text: "▶", CallbackData: p.callbackData(page + 1)})
+	}
+	if len(nav) != 0 {
+		keyboard = append(keyboard, nav)
+	}
+	return &InlineKeyboardMarkup{InlineKeyboard: keyboard}
+}
+
+// Page returns page number requested by navigation button.
+// ok is false if callback query was not sent by the paginator buttons.
+func (p *Paginator) Page(cq *CallbackQuery) (page int, ok bool) {
+	data := strings.TrimPrefix(cq.Data, p.prefix+":")
+	if data == cq.Data {
+		return 0, false
+	}
+	page, err := strconv.Atoi(data)
+	if err != nil || page < 0 {
+		return 0, false
+	}
+	return page, true
+}
+
+func (p *Paginator) callbackData(page int) string {
+	return p.prefix + ":" + strconv.Itoa(page)
+}
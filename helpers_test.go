@@ -0,0 +1,125 @@
+package tbot_test
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/yanzay/tbot/v2"
+)
+
+func testItems(n int) []tbot.InlineKeyboardButton {
+	items := make([]tbot.InlineKeyboardButton, n)
+	for i := range items {
+		items[i] = tbot.InlineKeyboardButton{Text: strconv.Itoa(i), CallbackData: "item:" + strconv.Itoa(i)}
+	}
+	return items
+}
+
+func TestPaginatorBoundaries(t *testing.T) {
+	p := tbot.NewPaginator(testItems(5), 2, "list")
+	if p.Pages() != 3 {
+		t.Fatalf("expected 3 pages, got %d", p.Pages())
+	}
+	tt := []struct {
+		page  int
+		items []string
+		nav   []string
+	}{
+		{page: 0, items: []string{"0", "1"}, nav: []string{"list:1"}},
+		{page: 1, items: []string{"2", "3"}, nav: []string{"list:0", "list:2"}},
+		{page: 2, items: []string{"4"}, nav: []string{"list:1"}},
+	}
+	for _, tc := range tt {
+		keyboard := p.Markup(tc.page).InlineKeyboard
+		if len(keyboard) != len(tc.items)+1 {
+			t.Fatalf("page %d: unexpected keyboard: %v", tc.page, keyboard)
+		}
+		for i, text := range tc.items {
+			if keyboard[i][0].Text != text {
+				t.Fatalf("page %d: expected item %s, got %s", tc.page, text, keyboard[i][0].Text)
+			}
+		}
+		nav := keyboard[len(keyboard)-1]
+		if len(nav) != len(tc.nav) {
+			t.Fatalf("page %d: unexpected navigation: %v", tc.page, nav)
+		}
+		for i, data := range tc.nav {
+			if nav[i].CallbackData != data {
+				t.Fatalf("page %d: expected navigation %s, got %s", tc.page, data, nav[i].CallbackData)
+			}
+		}
+	}
+}
+
+func TestPaginatorSinglePage(t *testing.T) {
+	p := tbot.NewPaginator(testItems(2), 5, "list")
+	keyboard := p.Markup(0).InlineKeyboard
+	if len(keyboard) != 2 {
+		t.Fatalf("single page should have no navigation: %v", keyboard)
+	}
+}
+
+func TestPaginatorPage(t *testing.T) {
+	p := tbot.NewPaginator(testItems(5), 2, "list")
+	tt := []struct {
+		data string
+		page int
+		ok   bool
+	}{
+		{data: "list:2", page: 2, ok: true},
+		{data: "list:0", page: 0, ok: true},
+		{data: "list:x", ok: false},
+		{data: "item:2", ok: false},
+		{data: "list", ok: false},
+	}
+	for _, tc := range tt {
+		page, ok := p.Page(&tbot.CallbackQuery{Data: tc.data})
+		if page != tc.page || ok != tc.ok {
+			t.Fatalf("%s: expected %d %v, got %d %v", tc.data, tc.page, tc.ok, page, ok)
+		}
+	}
+}
+
+func TestCallbackDataRoundTrip(t *testing.T) {
+	tt := []struct {
+		action string
+		params []string
+		data   string
+	}{
+		{action: "vote", params: []string{"-100123", "42", "yes"}, data: "vote:-100123:42:yes"},
+		{action: "menu", params: []string{}, data: "menu"},
+		{action: "search", params: []string{""}, data: "search:"},
+	}
+	for _, tc := range tt {
+		data, err := tbot.EncodeCallbackData(tc.action, tc.params...)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.action, err)
+		}
+		if data != tc.data {
+			t.Fatalf("%s: expected %q, got %q", tc.action, tc.data, data)
+		}
+		action, params := tbot.DecodeCallbackData(data)
+		if action != tc.action || !reflect.DeepEqual(params, tc.params) {
+			t.Fatalf("%s: unexpected decoded data: %q %q", tc.action, action, params)
+		}
+	}
+}
+
+func TestEncodeCallbackDataErrors(t *testing.T) {
+	// exactly 64 bytes is allowed
+	_, err := tbot.EncodeCallbackData("a", strings.Repeat("b", 62))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// length is counted in bytes, "я" is 2 bytes long
+	_, err = tbot.EncodeCallbackData("a", strings.Repeat("я", 32))
+	if err == nil {
+		t.Fatalf("expected error for over-limit data")
+	}
+	_, err = tbot.EncodeCallbackData("vote", "a:b")
+	if err == nil {
+		t.Fatalf("expected error for param with delimiter")
+	}
+}
@@ -0,0 +1,77 @@
+package tbot
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// InputFile represents the contents of a file to be uploaded using multipart/form-data.
+// Methods accepting files take either a string (file_id or http(s) URL) or *InputFile.
+type InputFile struct {
+	name   string
+	path   string
+	reader io.Reader
+}
+
+// InputFilePath returns InputFile which will be read from the local path on upload
+func InputFilePath(path string) *InputFile {
+	return &InputFile{path: path}
+}
+
+// InputFileReader returns InputFile streamed from r on upload. Name is the file name reported to Telegram.
+func InputFileReader(name string, r io.Reader) *InputFile {
+	return &InputFile{name: name, reader: r}
+}
+
+type inputFile struct {
+	field  string
+	name   string
+	reader io.Reader
+}
+
+func (f inputFile) open() (io.ReadCloser, error) {
+	if f.reader != nil {
+		return ioutil.NopCloser(f.reader), nil
+	}
+	return os.Open(f.name)
+}
+
+func (f inputFile) filename() string {
+	return filepath.Base(f.name)
+}
+
+// setInputFile sets string file (file_id or URL) as request field,
+// *InputFile is returned as a file to be uploaded with the request
+func setInputFile(req url.Values, field string, file interface{}) ([]inputFile, error) {
+	switch f := file.(type) {
+	case string:
+		req.Set(field, f)
+		return nil, nil
+	case *InputFile:
+		if f.reader != nil {
+			return []inputFile{{field: field, name: f.name, reader: f.reader}}, nil
+		}
+		return []inputFile{{field: field, name: f.path}}, nil
+	}
+	return nil, fmt.Errorf("unsupported %s type: %T", field, file)
+}
+
+func isURL(s string) bool {
+	return strings.HasPrefix(s, "http://") || strings.HasPrefix(s, "https://")
+}
+
+// thumbFile replaces thumbnail filename set by OptThumb with attach:// reference
+// and returns the thumbnail as a file to be uploaded with the request
+func thumbFile(req url.Values) []inputFile {
+	thumb := req.Get("thumb")
+	if thumb == "" {
+		return nil
+	}
+	req.Set("thumb", "attach://thumb_file")
+	return []inputFile{{field: "thumb_file", name: thumb}}
+}
@@ -0,0 +1,66 @@
+//go:build go1.21
+// +build go1.21
+
+package tbot
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+// SlogLogger returns Logger writing to structured logger l.
+// Formatted messages have "format" and "error" attributes, see logf.
+// Debug messages are logged with slog.LevelDebug, Info and Print with slog.LevelInfo,
+// Warn with slog.LevelWarn and Error with slog.LevelError.
+func SlogLogger(l *slog.Logger) Logger {
+	return slogLogger{l: l}
+}
+
+type slogLogger struct {
+	l *slog.Logger
+}
+
+// logf logs the formatted message with the format string as "format" attribute,
+// so messages of the same kind can be grouped, and error arguments as "error" attributes.
+func (s slogLogger) logf(level slog.Level, format string, args ...interface{}) {
+	ctx := context.Background()
+	if !s.l.Enabled(ctx, level) {
+		return
+	}
+	attrs := []interface{}{slog.String("format", format)}
+	for _, arg := range args {
+		if err, ok := arg.(error); ok {
+			attrs = append(attrs, slog.Any("error", err))
+		}
+	}
+	s.l.Log(ctx, level, fmt.Sprintf(format, args...), attrs...)
+}
+
+func (s slogLogger) log(level slog.Level, args ...interface{}) {
+	ctx := context.Background()
+	if s.l.Enabled(ctx, level) {
+		s.l.Log(ctx, level, fmt.Sprint(args...))
+	}
+}
+
+func (s slogLogger) Debugf(format string, args ...interface{}) {
+	s.logf(slog.LevelDebug, format, args...)
+}
+func (s slogLogger) Infof(format string, args ...interface{}) {
+	s.logf(slog.LevelInfo, format, args...)
+}
+func (s slogLogger) Printf(format string, args ...interface{}) {
+	s.logf(slog.LevelInfo, format, args...)
+}
+func (s slogLogger) Warnf(format string, args ...interface{}) {
+	s.logf(slog.LevelWarn, format, args...)
+}
+func (s slogLogger) Errorf(format string, args ...interface{}) {
+	s.logf(slog.LevelError, format, args...)
+}
+func (s slogLogger) Debug(args ...interface{}) { s.log(slog.LevelDebug, args...) }
+func (s slogLogger) Info(args ...interface{})  { s.log(slog.LevelInfo, args...) }
+func (s slogLogger) Print(args ...interface{}) { s.log(slog.LevelInfo, args...) }
+func (s slogLogger) Warn(args ...interface{})  { s.log(slog.LevelWarn, args...) }
+func (s slogLogger) Error(args ...interface{}) { s.log(slog.LevelError, args...) }
@@ -0,0 +1,43 @@
+//go:build go1.21
+// +build go1.21
+
+package tbot_test
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/yanzay/tbot/v2"
+)
+
+func TestSlogLogger(t *testing.T) {
+	var buf bytes.Buffer
+	handler := slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})
+	logger := tbot.SlogLogger(slog.New(handler))
+	logger.Debugf("update %d received", 42)
+	logger.Errorf("unable to send message: %v", errors.New("timeout"))
+	logger.Warn("webhook ", "error")
+
+	expected := "level=DEBUG msg=\"update 42 received\" format=\"update %d received\"\n" +
+		"level=ERROR msg=\"unable to send message: timeout\" format=\"unable to send message: %v\" error=timeout\n" +
+		"level=WARN msg=\"webhook error\"\n"
+	if buf.String() != expected {
+		t.Fatalf("unexpected output:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	tbot.SlogLogger(slog.New(slog.NewTextHandler(&buf, nil))).Debugf("skipped")
+	if buf.Len() != 0 {
+		t.Fatalf("debug message should be skipped on info level: %s", buf.String())
+	}
+}
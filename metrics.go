@@ -0,0 +1,105 @@
+package tbot
+
+import "time"
+
+/*
+Metrics receives statistics of updates processing and Bot API calls.
+Methods are called concurrently when updates are handled concurrently, e.g. with webhook.
+Update types are names of the update fields: "message", "callback_query", etc.
+For example, with Prometheus client:
+
+	type promMetrics struct {
+		updates   *prometheus.CounterVec
+		handlers  *prometheus.HistogramVec
+		apiErrors *prometheus.CounterVec
+	}
+
+	func (m *promMetrics) IncUpdate(updateType string) {
+		m.updates.WithLabelValues(updateType).Inc()
+	}
+
+	func (m *promMetrics) ObserveHandler(updateType string, d time.Duration) {
+		m.handlers.WithLabelValues(updateType).Observe(d.Seconds())
+	}
+
+	func (m *promMetrics) IncAPIError(method string, code int) {
+		m.apiErrors.WithLabelValues(method, strconv.Itoa(code)).Inc()
+	}
+
+	m := &promMetrics{
+		updates: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "tbot_updates_total",
+		}, []string{"type"}),
+		handlers: promauto.NewHistogramVec(prometheus.HistogramOpts{
+			Name: "tbot_handler_duration_seconds",
+		}, []string{"type"}),
+		apiErrors: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "tbot_api_errors_total",
+		}, []string{"method", "code"}),
+	}
+	bot := tbot.New(token, tbot.WithMetrics(m))
+*/
+type Metrics interface {
+	// IncUpdate is called for each received update
+	IncUpdate(updateType string)
+	// ObserveHandler is called with duration of update processing, including all handlers
+	ObserveHandler(updateType string, d time.Duration)
+	// IncAPIError is called for each failed Bot API call. Code is error_code from the response,
+	// HTTP status code for malformed responses, or 0 if request failed without response.
+	IncAPIError(method string, code int)
+}
+
+type nopMetrics struct{}
+
+func (nopMetrics) IncUpdate(string)                     {}
+func (nopMetrics) ObserveHandler(string, time.Duration) {}
+func (nopMetrics) IncAPIError(string, int)              {}
+
+// WithMetrics sets metrics for updates processing and Bot API calls
+func WithMetrics(metrics Metrics) ServerOption {
+	return func(s *Server) {
+		s.metrics = metrics
+	}
+}
+
+func updateType(u *Update) string {
+	switch {
+	case u.Message != nil:
+		return "message"
+	case u.EditedMessage != nil:
+		return "edited_message"
+	case u.ChannelPost != nil:
+		return "channel_post"
+	case u.EditedChannelPost != nil:
+		return "edited_channel_post"
+	case u.InlineQuery != nil:
+		return "inline_query"
+	case u.ChosenInlineResult != nil:
+		return "chosen_inline_result"
+	case u.CallbackQuery != nil:
+		return "callback_query"
+	case u.ShippingQuery != nil:
+		return "shipping_query"
+	case u.PreCheckoutQuery != nil:
+		return "pre_checkout_query"
+	case u.Poll != nil:
+		return "poll"
+	case u.PollAnswer != nil:
+		return "poll_answer"
+	case u.MessageReaction != nil:
+		return "message_reaction"
+	case u.MessageReactionCount != nil:
+		return "message_reaction_count"
+	case u.MyChatMember != nil:
+		return "my_chat_member"
+	case u.ChatJoinRequest != nil:
+		return "chat_join_request"
+	case u.BusinessConnection != nil:
+		return "business_connection"
+	case u.BusinessMessage != nil:
+		return "business_message"
+	case u.EditedBusinessMessage != nil:
+		return "edited_business_message"
+	}
+	return "unknown"
+}
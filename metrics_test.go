@@ -0,0 +1,76 @@
+package tbot_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yanzay/tbot/v2"
+)
+
+type testMetrics struct {
+	mu        sync.Mutex
+	updates   []string
+	handlers  []string
+	durations []time.Duration
+	apiErrors []string
+}
+
+func (m *testMetrics) IncUpdate(updateType string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.updates = append(m.updates, updateType)
+}
+
+func (m *testMetrics) ObserveHandler(updateType string, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.handlers = append(m.handlers, updateType)
+	m.durations = append(m.durations, d)
+}
+
+func (m *testMetrics) IncAPIError(method string, code int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.apiErrors = append(m.apiErrors, fmt.Sprintf("%s %d", method, code))
+}
+
+func TestMetricsUpdate(t *testing.T) {
+	m := &testMetrics{}
+	bot := tbot.NewTestServer(tbot.WithMetrics(m))
+	bot.HandleMessage("hi", func(*tbot.Message) {
+		time.Sleep(10 * time.Millisecond)
+	})
+	bot.Send(&tbot.Update{Message: &tbot.Message{Text: "hi"}})
+	bot.Send(&tbot.Update{CallbackQuery: &tbot.CallbackQuery{Data: "up"}})
+	if fmt.Sprint(m.updates) != "[message callback_query]" || fmt.Sprint(m.handlers) != "[message callback_query]" {
+		t.Fatalf("unexpected metrics: %v %v", m.updates, m.handlers)
+	}
+	if m.durations[0] < 10*time.Millisecond {
+		t.Fatalf("handler duration is too small: %v", m.durations[0])
+	}
+	if len(m.apiErrors) != 0 {
+		t.Fatalf("unexpected API errors: %v", m.apiErrors)
+	}
+}
+
+func TestMetricsAPIError(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `{"ok": false, "error_code": 403, "description": "Forbidden: bot was blocked by the user"}`)
+	}
+	httpServer := httptest.NewServer(http.HandlerFunc(handler))
+	defer httpServer.Close()
+	m := &testMetrics{}
+	bot := tbot.New("TOKEN", tbot.WithHTTPClient(httpServer.Client()), tbot.WithBaseURL(httpServer.URL), tbot.WithMetrics(m))
+	_, err := bot.Client().SendMessage(tbot.ChatID(123), "hi")
+	if err == nil {
+		t.Fatalf("expected error")
+	}
+	if fmt.Sprint(m.apiErrors) != "[sendMessage 403]" {
+		t.Fatalf("unexpected API errors: %v", m.apiErrors)
+	}
+}
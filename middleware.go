@@ -0,0 +1,145 @@
+package tbot
+
+// IgnoreBotsMiddleware drops updates sent by bots, including the bot's own messages,
+// to prevent loops between bots reacting to each other
+func IgnoreBotsMiddleware() Middleware {
+	return func(next UpdateHandler) UpdateHandler {
+		return func(u *Update) {
+			if from := updateSender(u); from != nil && from.IsBot {
+				return
+			}
+			next(u)
+		}
+	}
+}
+
+// IgnoreSelfMiddleware drops updates sent by the bot itself, e.g. its posts in channels.
+// The bot is known after Start, see Me.
+func (s *Server) IgnoreSelfMiddleware() Middleware {
+	return func(next UpdateHandler) UpdateHandler {
+		return func(u *Update) {
+			from := updateSender(u)
+			if from != nil && s.me != nil && from.ID == s.me.ID {
+				return
+			}
+			next(u)
+		}
+	}
+}
+
+// updateSender returns user the update came from, nil if it's unknown, e.g. for polls
+func updateSender(u *Update) *User {
+	switch {
+	case u.Message != nil:
+		return u.Message.From
+	case u.EditedMessage != nil:
+		return u.EditedMessage.From
+	case u.ChannelPost != nil:
+		return u.ChannelPost.From
+	case u.EditedChannelPost != nil:
+		return u.EditedChannelPost.From
+	case u.InlineQuery != nil:
+		return u.InlineQuery.From
+	case u.ChosenInlineResult != nil:
+		return u.ChosenInlineResult.From
+	case u.CallbackQuery != nil:
+		return u.CallbackQuery.From
+	case u.ShippingQuery != nil:
+		return u.ShippingQuery.From
+	case u.PreCheckoutQuery != nil:
+		return u.PreCheckoutQuery.From
+	case u.PollAnswer != nil:
+		return &u.PollAnswer.User
+	case u.MessageReaction != nil:
+		return u.MessageReaction.User
+	case u.MyChatMember != nil:
+		return &u.MyChatMember.From
+	case u.ChatJoinRequest != nil:
+		return &u.ChatJoinRequest.From
+	case u.BusinessConnection != nil:
+		return &u.BusinessConnection.User
+	case u.BusinessMessage != nil:
+		return u.BusinessMessage.From
+	case u.EditedBusinessMessage != nil:
+		return u.EditedBusinessMessage.From
+	}
+	return nil
+}
+
+/*
+AuthMiddleware drops updates not allowed by the predicate. Rejected updates are passed
+to onReject if it's not nil, e.g. to reply that the user is not authorized:
+
+	bot.Use(tbot.AuthMiddleware(tbot.AllowUsers(adminID), func(u *tbot.Update) {
+		if u.Message != nil {
+			bot.Client().SendMessage(tbot.ChatID(u.Message.Chat.ID), "You are not authorized")
+		}
+	}))
+*/
+func AuthMiddleware(allowed func(*Update) bool, onReject func(*Update)) Middleware {
+	return func(next UpdateHandler) UpdateHandler {
+		return func(u *Update) {
+			if allowed(u) {
+				next(u)
+				return
+			}
+			if onReject != nil {
+				onReject(u)
+			}
+		}
+	}
+}
+
+// AllowUsers allows updates sent by the users, updates without sender are rejected
+func AllowUsers(ids ...int64) func(*Update) bool {
+	allowed := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		allowed[id] = true
+	}
+	return func(u *Update) bool {
+		from := updateSender(u)
+		return from != nil && allowed[int64(from.ID)]
+	}
+}
+
+// AllowChats allows updates from the chats, updates not related to a chat
+// (e.g. inline queries) are rejected
+func AllowChats(ids ...int64) func(*Update) bool {
+	allowed := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		allowed[id] = true
+	}
+	return func(u *Update) bool {
+		chat := updateChat(u)
+		return chat != nil && allowed[chat.ID]
+	}
+}
+
+// updateChat returns chat of the update, nil if the update is not related to a chat
+func updateChat(u *Update) *Chat {
+	switch {
+	case u.Message != nil:
+		return &u.Message.Chat
+	case u.EditedMessage != nil:
+		return &u.EditedMessage.Chat
+	case u.ChannelPost != nil:
+		return &u.ChannelPost.Chat
+	case u.EditedChannelPost != nil:
+		return &u.EditedChannelPost.Chat
+	case u.CallbackQuery != nil && u.CallbackQuery.Message != nil:
+		return &u.CallbackQuery.Message.Chat
+	case u.MessageReaction != nil:
+		return &u.MessageReaction.Chat
+	case u.MessageReactionCount != nil:
+		return &u.MessageReactionCount.Chat
+	case u.MyChatMember != nil:
+		return &u.MyChatMember.Chat
+	case u.ChatJoinRequest != nil:
+		return &u.ChatJoinRequest.Chat
+	case u.BusinessMessage != nil:
+		return &u.BusinessMessage.Chat
+	case u.EditedBusinessMessage != nil:
+		return &u.EditedBusinessMessage.Chat
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package tbot
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIgnoreBotsMiddleware(t *testing.T) {
+	s := New("TOKEN")
+	s.Use(IgnoreBotsMiddleware())
+	var handled []string
+	s.HandleDefault(func(m *Message) { handled = append(handled, m.Text) })
+	s.processSingleUpdate(&Update{Message: &Message{Text: "from bot", From: &User{ID: 2, IsBot: true}}})
+	s.processSingleUpdate(&Update{Message: &Message{Text: "from human", From: &User{ID: 3}}})
+	s.processSingleUpdate(&Update{Message: &Message{Text: "anonymous"}})
+	if !reflect.DeepEqual(handled, []string{"from human", "anonymous"}) {
+		t.Fatalf("unexpected handled messages: %v", handled)
+	}
+}
+
+func TestIgnoreSelfMiddleware(t *testing.T) {
+	s := New("TOKEN")
+	s.me = &User{ID: 1, IsBot: true}
+	s.Use(s.IgnoreSelfMiddleware())
+	var handled []string
+	s.HandleChannelPost(func(m *Message) { handled = append(handled, m.Text) })
+	s.processSingleUpdate(&Update{ChannelPost: &Message{Text: "own post", From: &User{ID: 1, IsBot: true}}})
+	s.processSingleUpdate(&Update{ChannelPost: &Message{Text: "other bot", From: &User{ID: 2, IsBot: true}}})
+	if !reflect.DeepEqual(handled, []string{"other bot"}) {
+		t.Fatalf("unexpected handled messages: %v", handled)
+	}
+}
+
+func TestMiddlewareOrder(t *testing.T) {
+	s := New("TOKEN")
+	var calls []string
+	middleware := func(name string) Middleware {
+		return func(next UpdateHandler) UpdateHandler {
+			return func(u *Update) {
+				calls = append(calls, name)
+				next(u)
+			}
+		}
+	}
+	s.Use(middleware("first"))
+	s.Use(middleware("second"))
+	s.HandleDefault(func(m *Message) { calls = append(calls, "handler") })
+	s.processSingleUpdate(&Update{Message: &Message{Text: "hi"}})
+	if !reflect.DeepEqual(calls, []string{"first", "second", "handler"}) {
+		t.Fatalf("unexpected calls: %v", calls)
+	}
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	message := func(userID int, chatID int64) *Update {
+		return &Update{Message: &Message{From: &User{ID: userID}, Chat: Chat{ID: chatID}}}
+	}
+	callback := func(userID int, chatID int64) *Update {
+		return &Update{CallbackQuery: &CallbackQuery{From: &User{ID: userID}, Message: &Message{Chat: Chat{ID: chatID}}}}
+	}
+	inline := &Update{InlineQuery: &InlineQuery{From: &User{ID: 1}}}
+	tt := []struct {
+		name     string
+		allowed  func(*Update) bool
+		update   *Update
+		expected bool
+	}{
+		{name: "allowed user message", allowed: AllowUsers(1, 2), update: message(1, 100), expected: true},
+		{name: "rejected user message", allowed: AllowUsers(1, 2), update: message(3, 100)},
+		{name: "allowed user callback", allowed: AllowUsers(1, 2), update: callback(2, 100), expected: true},
+		{name: "rejected user callback", allowed: AllowUsers(1, 2), update: callback(3, 100)},
+		{name: "allowed user inline query", allowed: AllowUsers(1), update: inline, expected: true},
+		{name: "allowed chat message", allowed: AllowChats(100), update: message(3, 100), expected: true},
+		{name: "rejected chat message", allowed: AllowChats(100), update: message(1, 200)},
+		{name: "allowed chat callback", allowed: AllowChats(100), update: callback(3, 100), expected: true},
+		{name: "rejected chat callback", allowed: AllowChats(100), update: callback(1, 200)},
+		{name: "inline query without chat", allowed: AllowChats(100), update: inline},
+	}
+	for _, tc := range tt {
+		var handled, rejected int
+		handler := AuthMiddleware(tc.allowed, func(*Update) { rejected++ })(func(*Update) { handled++ })
+		handler(tc.update)
+		if tc.expected && (handled != 1 || rejected != 0) || !tc.expected && (handled != 0 || rejected != 1) {
+			t.Fatalf("%s: handled %d, rejected %d", tc.name, handled, rejected)
+		}
+	}
+
+	// rejection callback is optional
+	AuthMiddleware(AllowUsers(), nil)(func(*Update) { t.Fatalf("update should be rejected") })(message(1, 100))
+}
@@ -0,0 +1,109 @@
+package tbot
+
+import "sync"
+
+/*
+OffsetStore keeps offset of the next update to receive with long polling.
+Server saves the offset after updates are processed and loads it on start, so the bot
+restarted with persistent store continues from the first unprocessed update.
+With WithPerChatOrdering updates of other chats are received and handled while an update
+is queued or running, the saved offset is the lowest update ID not processed yet,
+so updates processed after it are received again after restart.
+Default store keeps offset in memory. Load should return 0 without error if no offset
+is saved. For example, store in a file:
+
+	type fileOffsetStore string
+
+	func (f fileOffsetStore) Load() (int, error) {
+		data, err := ioutil.ReadFile(string(f))
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		return strconv.Atoi(strings.TrimSpace(string(data)))
+	}
+
+	func (f fileOffsetStore) Save(offset int) error {
+		return ioutil.WriteFile(string(f), []byte(strconv.Itoa(offset)), 0644)
+	}
+
+	bot := tbot.New(token, tbot.WithOffsetStore(fileOffsetStore("offset.txt")))
+*/
+type OffsetStore interface {
+	Load() (int, error)
+	Save(offset int) error
+}
+
+type memoryOffsetStore struct {
+	mu     sync.Mutex
+	offset int
+}
+
+// NewMemoryOffsetStore returns OffsetStore keeping offset in memory
+func NewMemoryOffsetStore() OffsetStore {
+	return &memoryOffsetStore{}
+}
+
+func (m *memoryOffsetStore) Load() (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.offset, nil
+}
+
+func (m *memoryOffsetStore) Save(offset int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.offset = offset
+	return nil
+}
+
+// WithOffsetStore sets store for long polling offset
+func WithOffsetStore(store OffsetStore) ServerOption {
+	return func(s *Server) {
+		s.offsetStore = store
+	}
+}
+
+// pendingOffsets tracks queued long polling updates and saves the offset of
+// the first unprocessed update whenever it grows
+type pendingOffsets struct {
+	save func(offset int)
+
+	mu      sync.Mutex
+	pending map[int]bool // IDs of updates not processed yet
+	next    int          // ID after the last received update
+	saved   int
+}
+
+func newPendingOffsets(save func(offset int)) *pendingOffsets {
+	return &pendingOffsets{save: save, pending: make(map[int]bool)}
+}
+
+// add marks update as received and not processed
+func (p *pendingOffsets) add(updateID int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.pending[updateID] = true
+	if updateID >= p.next {
+		p.next = updateID + 1
+	}
+}
+
+// done marks update as processed and saves the offset if it has grown
+func (p *pendingOffsets) done(updateID int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.pending, updateID)
+	offset := p.next
+	for id := range p.pending {
+		if id < offset {
+			offset = id
+		}
+	}
+	if offset > p.saved {
+		p.saved = offset
+		p.save(offset)
+	}
+}
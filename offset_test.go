@@ -0,0 +1,175 @@
+package tbot
+
+import (
+	"net/http"
+	"path"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+type recordingOffsetStore struct {
+	mu     sync.Mutex
+	loaded int
+	saved  []int
+}
+
+func (r *recordingOffsetStore) Load() (int, error) {
+	return r.loaded, nil
+}
+
+func (r *recordingOffsetStore) Save(offset int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.saved = append(r.saved, offset)
+	return nil
+}
+
+func TestOffsetStore(t *testing.T) {
+	testOffsetStore(t, []int{12, 13})
+}
+
+func TestOffsetStorePerChatOrdering(t *testing.T) {
+	// queued updates are saved one by one
+	testOffsetStore(t, []int{11, 12, 13}, WithPerChatOrdering())
+}
+
+func testOffsetStore(t *testing.T, saved []int, options ...ServerOption) {
+	offsets := make(chan string, 10)
+	batches := []string{
+		`{"ok": true, "result": [{"update_id": 10, "message": {"text": "a"}}, {"update_id": 11, "message": {"text": "b"}}]}`,
+		`{"ok": true, "result": []}`,
+		`{"ok": true, "result": [{"update_id": 12, "message": {"text": "c"}}]}`,
+	}
+	transport := func(r *http.Request) (*http.Response, error) {
+		switch path.Base(r.URL.Path) {
+		case "getMe":
+			return jsonResponse(`{"ok": true, "result": {"id": 42, "is_bot": true, "username": "mybot"}}`), nil
+		case "getUpdates":
+			offsets <- r.URL.Query().Get("offset")
+			if len(batches) == 0 {
+				<-r.Context().Done()
+				return nil, r.Context().Err()
+			}
+			batch := batches[0]
+			batches = batches[1:]
+			return jsonResponse(batch), nil
+		}
+		return jsonResponse(`{"ok": true, "result": true}`), nil
+	}
+	store := &recordingOffsetStore{loaded: 10}
+	options = append(options, WithHTTPClient(&http.Client{Transport: roundTripFunc(transport)}), WithOffsetStore(store))
+	s := New("TOKEN", options...)
+	updateIDs := map[string]int{"a": 10, "b": 11, "c": 12}
+	var handled []string
+	s.HandleDefault(func(m *Message) {
+		// queued updates are handled after the batch is received
+		time.Sleep(10 * time.Millisecond)
+		handled = append(handled, m.Text)
+		// offset is saved only after the update is processed
+		store.mu.Lock()
+		defer store.mu.Unlock()
+		if n := len(store.saved); n != 0 && store.saved[n-1] > updateIDs[m.Text] {
+			t.Errorf("offset %d saved before update %s is processed", store.saved[n-1], m.Text)
+		}
+	})
+	done := make(chan error)
+	go func() {
+		done <- s.Start()
+	}()
+	var requested []string
+	for i := 0; i < 4; i++ {
+		requested = append(requested, <-offsets)
+	}
+	s.Stop()
+	<-done
+
+	if !reflect.DeepEqual(requested, []string{"10", "12", "12", "13"}) {
+		t.Fatalf("unexpected offsets requested: %v", requested)
+	}
+	if !reflect.DeepEqual(store.saved, saved) {
+		t.Fatalf("unexpected offsets saved: %v", store.saved)
+	}
+	if !reflect.DeepEqual(handled, []string{"a", "b", "c"}) {
+		t.Fatalf("unexpected updates handled: %v", handled)
+	}
+}
+
+func TestOffsetStoreSlowChat(t *testing.T) {
+	offsets := make(chan string, 10)
+	batches := []string{
+		`{"ok": true, "result": [{"update_id": 10, "message": {"text": "slow", "chat": {"id": 1}}}, {"update_id": 11, "message": {"text": "a", "chat": {"id": 2}}}]}`,
+		`{"ok": true, "result": [{"update_id": 12, "message": {"text": "b", "chat": {"id": 2}}}]}`,
+	}
+	transport := func(r *http.Request) (*http.Response, error) {
+		switch path.Base(r.URL.Path) {
+		case "getMe":
+			return jsonResponse(`{"ok": true, "result": {"id": 42, "is_bot": true, "username": "mybot"}}`), nil
+		case "getUpdates":
+			offsets <- r.URL.Query().Get("offset")
+			if len(batches) == 0 {
+				<-r.Context().Done()
+				return nil, r.Context().Err()
+			}
+			batch := batches[0]
+			batches = batches[1:]
+			return jsonResponse(batch), nil
+		}
+		return jsonResponse(`{"ok": true, "result": true}`), nil
+	}
+	store := &recordingOffsetStore{}
+	s := New("TOKEN", WithPerChatOrdering(), WithOffsetStore(store),
+		WithHTTPClient(&http.Client{Transport: roundTripFunc(transport)}))
+	release := make(chan struct{})
+	handled := make(chan string, 10)
+	s.HandleDefault(func(m *Message) {
+		if m.Text == "slow" {
+			<-release
+		}
+		handled <- m.Text
+	})
+	done := make(chan error)
+	go func() {
+		done <- s.Start()
+	}()
+	// updates of the other chat are received and handled while the slow one is running
+	for _, text := range []string{"a", "b"} {
+		select {
+		case h := <-handled:
+			if h != text {
+				t.Fatalf("expected update %s to be handled, got %s", text, h)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("update %s is blocked by the slow chat", text)
+		}
+	}
+	// offset stays at the slow update until it's processed
+	store.mu.Lock()
+	if !reflect.DeepEqual(store.saved, []int{10}) {
+		t.Errorf("unexpected offsets saved while the slow update is running: %v", store.saved)
+	}
+	store.mu.Unlock()
+
+	close(release)
+	<-handled
+	for i := 0; i < 3; i++ {
+		<-offsets
+	}
+	s.Stop()
+	<-done
+	if !reflect.DeepEqual(store.saved, []int{10, 13}) {
+		t.Fatalf("unexpected offsets saved: %v", store.saved)
+	}
+}
+
+func TestMemoryOffsetStore(t *testing.T) {
+	store := NewMemoryOffsetStore()
+	if offset, err := store.Load(); err != nil || offset != 0 {
+		t.Fatalf("unexpected initial offset: %d, %v", offset, err)
+	}
+	store.Save(100)
+	if offset, _ := store.Load(); offset != 100 {
+		t.Fatalf("unexpected offset: %d", offset)
+	}
+}
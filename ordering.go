@@ -0,0 +1,190 @@
+package tbot
+
+import "sync"
+
+/*
+WithPerChatOrdering makes updates of different chats handled in parallel,
+while updates of the same chat are handled one by one in the order of arrival.
+Use it to keep per-chat state consistent without locking in handlers,
+handlers of different chats still run concurrently.
+
+Updates are keyed by chat, updates without chat (e.g. inline queries) by user.
+Poll updates have neither and are handled concurrently.
+
+Number of queued updates is limited, see WithMaxPendingUpdates.
+Server.Stop waits for queued updates to be handled.
+*/
+func WithPerChatOrdering() ServerOption {
+	return func(s *Server) {
+		s.perChatOrdering = true
+	}
+}
+
+// Default limits of updates queued with WithPerChatOrdering
+const (
+	DefaultMaxPendingUpdatesPerChat = 100
+	DefaultMaxPendingUpdates        = 1000
+)
+
+// WithMaxPendingUpdates limits number of updates queued with WithPerChatOrdering
+// in one chat and in total, including updates being handled. When a limit is reached,
+// receiving of updates waits for handlers, so a slow chat delays other chats too.
+// Non-positive values keep the defaults.
+func WithMaxPendingUpdates(perChat, total int) ServerOption {
+	return func(s *Server) {
+		if perChat > 0 {
+			s.maxPendingPerChat = perChat
+		}
+		if total > 0 {
+			s.maxPendingTotal = total
+		}
+	}
+}
+
+// chatQueues runs one worker per chat with pending updates
+type chatQueues struct {
+	process    func(*Update)
+	maxPerChat int // updates waiting in the queue of one chat
+	maxTotal   int // queued and running updates of all chats
+
+	mu      sync.Mutex
+	changed *sync.Cond               // signaled when updates are taken from queues or queues are closed
+	queues  map[int64][]queuedUpdate // chat has a running worker while it is in the map
+	pending int                      // queued and running updates
+	closed  bool
+	wg      sync.WaitGroup
+}
+
+type queuedUpdate struct {
+	update *Update
+	done   func()
+}
+
+func newChatQueues(process func(*Update), maxPerChat, maxTotal int) *chatQueues {
+	q := &chatQueues{
+		process:    process,
+		maxPerChat: maxPerChat,
+		maxTotal:   maxTotal,
+		queues:     make(map[int64][]queuedUpdate),
+	}
+	q.changed = sync.NewCond(&q.mu)
+	return q
+}
+
+// push queues update for processing and returns without waiting for it.
+// It blocks while limits of pending updates are reached. Done is optional,
+// it's called when the update is processed.
+// Returns false if the queues are closed, the update is dropped then.
+func (q *chatQueues) push(update *Update, done func()) bool {
+	chatID, keyed := updateChatID(update)
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for !q.closed && (q.pending >= q.maxTotal || keyed && len(q.queues[chatID]) >= q.maxPerChat) {
+		q.changed.Wait()
+	}
+	if q.closed {
+		return false
+	}
+	q.pending++
+	item := queuedUpdate{update: update, done: done}
+	if !keyed {
+		q.wg.Add(1)
+		go func() {
+			defer q.wg.Done()
+			q.handle(item)
+		}()
+		return true
+	}
+	pending, running := q.queues[chatID]
+	q.queues[chatID] = append(pending, item)
+	if !running {
+		q.wg.Add(1)
+		go q.run(chatID)
+	}
+	return true
+}
+
+func (q *chatQueues) run(chatID int64) {
+	defer q.wg.Done()
+	for {
+		q.mu.Lock()
+		pending := q.queues[chatID]
+		if len(pending) == 0 {
+			delete(q.queues, chatID)
+			q.mu.Unlock()
+			return
+		}
+		item := pending[0]
+		q.queues[chatID] = pending[1:]
+		q.changed.Broadcast()
+		q.mu.Unlock()
+		q.handle(item)
+	}
+}
+
+func (q *chatQueues) handle(item queuedUpdate) {
+	q.process(item.update)
+	if item.done != nil {
+		item.done()
+	}
+	q.mu.Lock()
+	q.pending--
+	q.changed.Broadcast()
+	q.mu.Unlock()
+}
+
+// wait returns when all queued updates are processed
+func (q *chatQueues) wait() {
+	q.wg.Wait()
+}
+
+// close stops accepting new updates and waits for queued updates to be processed
+func (q *chatQueues) close() {
+	q.mu.Lock()
+	q.closed = true
+	q.changed.Broadcast()
+	q.mu.Unlock()
+	q.wait()
+}
+
+func updateChatID(u *Update) (int64, bool) {
+	switch {
+	case u.Message != nil:
+		return u.Message.Chat.ID, true
+	case u.EditedMessage != nil:
+		return u.EditedMessage.Chat.ID, true
+	case u.ChannelPost != nil:
+		return u.ChannelPost.Chat.ID, true
+	case u.EditedChannelPost != nil:
+		return u.EditedChannelPost.Chat.ID, true
+	case u.CallbackQuery != nil && u.CallbackQuery.Message != nil:
+		return u.CallbackQuery.Message.Chat.ID, true
+	case u.CallbackQuery != nil && u.CallbackQuery.From != nil:
+		return int64(u.CallbackQuery.From.ID), true
+	case u.InlineQuery != nil && u.InlineQuery.From != nil:
+		return int64(u.InlineQuery.From.ID), true
+	case u.ChosenInlineResult != nil && u.ChosenInlineResult.From != nil:
+		return int64(u.ChosenInlineResult.From.ID), true
+	case u.ShippingQuery != nil && u.ShippingQuery.From != nil:
+		return int64(u.ShippingQuery.From.ID), true
+	case u.PreCheckoutQuery != nil && u.PreCheckoutQuery.From != nil:
+		return int64(u.PreCheckoutQuery.From.ID), true
+	case u.PollAnswer != nil:
+		return int64(u.PollAnswer.User.ID), true
+	case u.MessageReaction != nil:
+		return u.MessageReaction.Chat.ID, true
+	case u.MessageReactionCount != nil:
+		return u.MessageReactionCount.Chat.ID, true
+	case u.MyChatMember != nil:
+		return u.MyChatMember.Chat.ID, true
+	case u.ChatJoinRequest != nil:
+		return u.ChatJoinRequest.Chat.ID, true
+	case u.BusinessConnection != nil:
+		return u.BusinessConnection.UserChatID, true
+	case u.BusinessMessage != nil:
+		return u.BusinessMessage.Chat.ID, true
+	case u.EditedBusinessMessage != nil:
+		return u.EditedBusinessMessage.Chat.ID, true
+	}
+	return 0, false
+}
@@ -0,0 +1,145 @@
+package tbot
+
+import (
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPerChatOrdering(t *testing.T) {
+	s := New("TOKEN", WithPerChatOrdering())
+	var mu sync.Mutex
+	handled := map[int64][]string{}
+	running := map[int64]int{}
+	maxRunning := 0
+	s.HandleDefault(func(m *Message) {
+		mu.Lock()
+		running[m.Chat.ID]++
+		if running[m.Chat.ID] > 1 {
+			t.Errorf("concurrent handlers for chat %d", m.Chat.ID)
+		}
+		total := 0
+		for _, n := range running {
+			total += n
+		}
+		if total > maxRunning {
+			maxRunning = total
+		}
+		mu.Unlock()
+
+		time.Sleep(10 * time.Millisecond)
+
+		mu.Lock()
+		handled[m.Chat.ID] = append(handled[m.Chat.ID], m.Text)
+		running[m.Chat.ID]--
+		mu.Unlock()
+	})
+
+	var updates []*Update
+	for i := 0; i < 5; i++ {
+		for _, chatID := range []int64{1, 2} {
+			updates = append(updates, &Update{Message: &Message{Text: strconv.Itoa(i), Chat: Chat{ID: chatID}}})
+		}
+	}
+	s.processBatchOfUpdates(updates)
+	s.chatQueues.wait()
+
+	expected := []string{"0", "1", "2", "3", "4"}
+	if !reflect.DeepEqual(handled[1], expected) || !reflect.DeepEqual(handled[2], expected) {
+		t.Fatalf("updates are handled out of order: %v", handled)
+	}
+	if maxRunning != 2 {
+		t.Fatalf("expected chats to be handled in parallel, max running handlers %d", maxRunning)
+	}
+	if len(s.chatQueues.queues) != 0 {
+		t.Fatalf("workers are not stopped: %v", s.chatQueues.queues)
+	}
+}
+
+func TestPerChatOrderingLimits(t *testing.T) {
+	release := make(chan struct{})
+	s := New("TOKEN", WithPerChatOrdering(), WithMaxPendingUpdates(1, 3))
+	s.HandleDefault(func(m *Message) { <-release })
+
+	pushed := make(chan int, 10)
+	push := func(chatID int64) {
+		go func() {
+			s.processUpdate(&Update{Message: &Message{Chat: Chat{ID: chatID}}})
+			pushed <- int(chatID)
+		}()
+	}
+	expectPushed := func(n int) {
+		t.Helper()
+		for i := 0; i < n; i++ {
+			select {
+			case <-pushed:
+			case <-time.After(time.Second):
+				t.Fatalf("update is not queued")
+			}
+		}
+		select {
+		case chatID := <-pushed:
+			t.Fatalf("update of chat %d is queued over the limit", chatID)
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+	// first update of the chat is handled, second one waits in the queue, third one is blocked
+	push(1)
+	push(1)
+	push(1)
+	expectPushed(2)
+	// other chat is not limited by the chat queue, but by total number of updates
+	push(2)
+	push(3)
+	expectPushed(1)
+
+	close(release)
+	expectPushed(2)
+	s.chatQueues.wait()
+}
+
+func TestStopWaitsForQueuedUpdates(t *testing.T) {
+	s := New("TOKEN", WithPerChatOrdering())
+	var mu sync.Mutex
+	handled := 0
+	s.HandleDefault(func(m *Message) {
+		time.Sleep(10 * time.Millisecond)
+		mu.Lock()
+		handled++
+		mu.Unlock()
+	})
+	for i := 0; i < 5; i++ {
+		s.processUpdate(&Update{Message: &Message{Chat: Chat{ID: 1}}})
+	}
+	s.Stop()
+	mu.Lock()
+	defer mu.Unlock()
+	if handled != 5 {
+		t.Fatalf("expected queued updates to be handled on stop, handled %d", handled)
+	}
+	if s.chatQueues.push(&Update{Message: &Message{Chat: Chat{ID: 1}}}, nil) {
+		t.Fatalf("updates should not be queued after stop")
+	}
+}
+
+func TestUpdateChatID(t *testing.T) {
+	tt := []struct {
+		update *Update
+		chatID int64
+		ok     bool
+	}{
+		{update: &Update{Message: &Message{Chat: Chat{ID: 1}}}, chatID: 1, ok: true},
+		{update: &Update{CallbackQuery: &CallbackQuery{From: &User{ID: 5}, Message: &Message{Chat: Chat{ID: 2}}}}, chatID: 2, ok: true},
+		{update: &Update{CallbackQuery: &CallbackQuery{From: &User{ID: 5}, InlineMessageID: "inline"}}, chatID: 5, ok: true},
+		{update: &Update{InlineQuery: &InlineQuery{From: &User{ID: 6}}}, chatID: 6, ok: true},
+		{update: &Update{Poll: &Poll{}}},
+	}
+	for i, tc := range tt {
+		chatID, ok := updateChatID(tc.update)
+		if chatID != tc.chatID || ok != tc.ok {
+			t.Fatalf("update %d: expected chat %d %v, got %d %v", i, tc.chatID, tc.ok, chatID, ok)
+		}
+	}
+}
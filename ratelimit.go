@@ -0,0 +1,112 @@
+package tbot
+
+import (
+	"sync"
+	"time"
+)
+
+const rateLimitShards = 16
+
+// rateLimitEvictAfter is the idle time after which buckets are evicted if they are never refilled
+const rateLimitEvictAfter = time.Hour
+
+/*
+RateLimitMiddleware drops updates of users sending more than perSecond updates per second
+on average, allowing bursts of up to burst updates. Dropped updates are passed to onLimit
+if it's not nil, e.g. to ask the user to slow down. Updates without sender are not limited.
+If perSecond is not positive tokens are not refilled, users get burst updates
+and are limited until they are idle for an hour.
+For example, allow one command per 2 seconds with bursts of 5:
+
+	bot.Use(tbot.RateLimitMiddleware(0.5, 5, nil))
+*/
+func RateLimitMiddleware(perSecond float64, burst int, onLimit func(*Update)) Middleware {
+	limiter := newRateLimiter(perSecond, burst)
+	return func(next UpdateHandler) UpdateHandler {
+		return func(u *Update) {
+			from := updateSender(u)
+			if from == nil || limiter.allow(int64(from.ID)) {
+				next(u)
+				return
+			}
+			if onLimit != nil {
+				onLimit(u)
+			}
+		}
+	}
+}
+
+// rateLimiter keeps token buckets of users in shards to reduce lock contention
+type rateLimiter struct {
+	rate  float64 // tokens per second
+	burst float64
+	now   func() time.Time
+
+	// buckets are evicted when they are full, i.e. idle for evictAfter,
+	// or after rateLimitEvictAfter if they are never refilled
+	evictAfter time.Duration
+	shards     [rateLimitShards]rateLimitShard
+}
+
+type rateLimitShard struct {
+	mu        sync.Mutex
+	buckets   map[int64]*tokenBucket
+	lastEvict time.Time
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(perSecond float64, burst int) *rateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	l := &rateLimiter{
+		rate:       perSecond,
+		burst:      float64(burst),
+		now:        time.Now,
+		evictAfter: rateLimitEvictAfter,
+	}
+	if perSecond > 0 {
+		l.evictAfter = time.Duration(float64(burst) / perSecond * float64(time.Second))
+	} else {
+		l.rate = 0
+	}
+	for i := range l.shards {
+		l.shards[i].buckets = make(map[int64]*tokenBucket)
+	}
+	return l
+}
+
+// allow takes a token from the user's bucket, reports false if the bucket is empty
+func (l *rateLimiter) allow(userID int64) bool {
+	shard := &l.shards[uint64(userID)%rateLimitShards]
+	now := l.now()
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	if now.Sub(shard.lastEvict) > l.evictAfter {
+		for id, b := range shard.buckets {
+			if now.Sub(b.last) > l.evictAfter {
+				delete(shard.buckets, id)
+			}
+		}
+		shard.lastEvict = now
+	}
+	b, ok := shard.buckets[userID]
+	if !ok {
+		b = &tokenBucket{tokens: l.burst, last: now}
+		shard.buckets[userID] = b
+	}
+	b.tokens += now.Sub(b.last).Seconds() * l.rate
+	if b.tokens > l.burst {
+		b.tokens = l.burst
+	}
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
@@ -0,0 +1,78 @@
+package tbot
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateLimitMiddleware(t *testing.T) {
+	var handled, limited []int
+	middleware := RateLimitMiddleware(1, 2, func(u *Update) { limited = append(limited, u.Message.From.ID) })
+	polls := 0
+	handler := middleware(func(u *Update) {
+		if u.Poll != nil {
+			polls++
+			return
+		}
+		handled = append(handled, u.Message.From.ID)
+	})
+	message := func(userID int) *Update {
+		return &Update{Message: &Message{From: &User{ID: userID}}}
+	}
+	for i := 0; i < 5; i++ {
+		handler(message(1))
+	}
+	handler(message(2))
+	handler(&Update{Poll: &Poll{}})
+	if len(handled) != 3 || handled[0] != 1 || handled[1] != 1 || handled[2] != 2 {
+		t.Fatalf("expected 2 updates of user 1 and update of user 2, got %v", handled)
+	}
+	if polls != 1 {
+		t.Fatalf("updates without sender should not be limited")
+	}
+	if len(limited) != 3 {
+		t.Fatalf("expected 3 limited updates, got %v", limited)
+	}
+}
+
+func TestRateLimiterRefill(t *testing.T) {
+	now := time.Now()
+	l := newRateLimiter(2, 1)
+	l.now = func() time.Time { return now }
+	if !l.allow(1) || l.allow(1) {
+		t.Fatalf("expected burst of 1")
+	}
+	now = now.Add(250 * time.Millisecond)
+	if l.allow(1) {
+		t.Fatalf("token should not be refilled in 250ms")
+	}
+	now = now.Add(250 * time.Millisecond)
+	if !l.allow(1) {
+		t.Fatalf("token should be refilled in 500ms")
+	}
+
+	// idle buckets are evicted
+	now = now.Add(time.Second)
+	l.allow(1 + rateLimitShards)
+	if _, ok := l.shards[1].buckets[1]; ok {
+		t.Fatalf("idle bucket is not evicted")
+	}
+}
+
+func TestRateLimiterZeroRate(t *testing.T) {
+	now := time.Now()
+	l := newRateLimiter(0, 1)
+	l.now = func() time.Time { return now }
+	if !l.allow(1) || l.allow(1) {
+		t.Fatalf("expected burst of 1")
+	}
+	now = now.Add(time.Minute)
+	if l.allow(1) {
+		t.Fatalf("token should not be refilled with zero rate")
+	}
+	now = now.Add(rateLimitEvictAfter + time.Second)
+	l.allow(1 + rateLimitShards)
+	if _, ok := l.shards[1].buckets[1]; ok {
+		t.Fatalf("idle bucket is not evicted")
+	}
+}
@@ -1,16 +1,138 @@
 package tbot
 
+// Router handles incoming messages
 type Router interface {
 	Handle(m *Message)
 }
 
+// TypedRouter routes service messages by their type,
+// messages without matching handler go to the default handler
 type TypedRouter struct {
-	onNewChatMembers handlerFunc
+	onNewChatMembers    handlerFunc
+	onLeftChatMember    handlerFunc
+	onPinnedMessage     handlerFunc
+	onNewChatTitle      handlerFunc
+	onNewChatPhoto      handlerFunc
+	onDeleteChatPhoto   handlerFunc
+	onGroupChatCreated  handlerFunc
+	onMigrateToChatID   handlerFunc
+	onSuccessfulPayment handlerFunc
+	onDefault           handlerFunc
 }
 
+// Handle dispatches message to the first matching handler
 func (s *TypedRouter) Handle(m *Message) {
-	if len(m.NewChatMembers) != 0 && s.onNewChatMembers != nil {
-		s.onNewChatMembers(m)
-		return
+	var h handlerFunc
+	switch {
+	case len(m.NewChatMembers) != 0:
+		h = s.onNewChatMembers
+	case m.LeftChatMember != nil:
+		h = s.onLeftChatMember
+	case m.PinnedMessage != nil:
+		h = s.onPinnedMessage
+	case m.NewChatTitle != "":
+		h = s.onNewChatTitle
+	case len(m.NewChatPhoto) != 0:
+		h = s.onNewChatPhoto
+	case m.DeleteChatPhoto:
+		h = s.onDeleteChatPhoto
+	case m.GroupChatCreated:
+		h = s.onGroupChatCreated
+	case m.MigrateToChatID != 0:
+		h = s.onMigrateToChatID
+	case m.SuccessfulPayment != nil:
+		h = s.onSuccessfulPayment
 	}
+	if h == nil {
+		h = s.onDefault
+	}
+	if h != nil {
+		h(m)
+	}
+}
+
+// OnNewChatMembers sets handler for messages about new chat members
+func (s *TypedRouter) OnNewChatMembers(handler func(*Message)) {
+	s.onNewChatMembers = handler
+}
+
+// OnLeftChatMember sets handler for messages about members removed from chat
+func (s *TypedRouter) OnLeftChatMember(handler func(*Message)) {
+	s.onLeftChatMember = handler
+}
+
+// OnPinnedMessage sets handler for messages about pinned messages
+func (s *TypedRouter) OnPinnedMessage(handler func(*Message)) {
+	s.onPinnedMessage = handler
+}
+
+// OnNewChatTitle sets handler for messages about chat title change
+func (s *TypedRouter) OnNewChatTitle(handler func(*Message)) {
+	s.onNewChatTitle = handler
+}
+
+// OnNewChatPhoto sets handler for messages about chat photo change
+func (s *TypedRouter) OnNewChatPhoto(handler func(*Message)) {
+	s.onNewChatPhoto = handler
+}
+
+// OnDeleteChatPhoto sets handler for messages about chat photo removal
+func (s *TypedRouter) OnDeleteChatPhoto(handler func(*Message)) {
+	s.onDeleteChatPhoto = handler
+}
+
+// OnGroupChatCreated sets handler for messages about group creation
+func (s *TypedRouter) OnGroupChatCreated(handler func(*Message)) {
+	s.onGroupChatCreated = handler
+}
+
+// OnMigrateToChatID sets handler for messages about group migration to a supergroup
+func (s *TypedRouter) OnMigrateToChatID(handler func(*Message)) {
+	s.onMigrateToChatID = handler
+}
+
+// OnSuccessfulPayment sets handler for messages about successful payments
+func (s *TypedRouter) OnSuccessfulPayment(handler func(*Message)) {
+	s.onSuccessfulPayment = handler
+}
+
+// OnDefault sets handler for messages not matched by other handlers
+func (s *TypedRouter) OnDefault(handler func(*Message)) {
+	s.onDefault = handler
+}
+
+// OrderedRouter tries handlers in the order they were added, the first handler
+// returning true stops routing. Handler returns false to pass the message further,
+// messages not handled by any handler go to the default handler:
+//
+//	r := &tbot.OrderedRouter{}
+//	r.Add(commands.handle)
+//	r.Add(nlp.handle)
+//	r.OnDefault(app.help)
+//	bot.HandleRouter(r)
+type OrderedRouter struct {
+	handlers  []func(*Message) bool
+	onDefault handlerFunc
+}
+
+// Handle passes message to handlers until one of them handles it
+func (r *OrderedRouter) Handle(m *Message) {
+	for _, h := range r.handlers {
+		if h(m) {
+			return
+		}
+	}
+	if r.onDefault != nil {
+		r.onDefault(m)
+	}
+}
+
+// Add appends handler to the end of the list, handler returns false if the message is not handled
+func (r *OrderedRouter) Add(handler func(*Message) bool) {
+	r.handlers = append(r.handlers, handler)
+}
+
+// OnDefault sets handler for messages not handled by other handlers
+func (r *OrderedRouter) OnDefault(handler func(*Message)) {
+	r.onDefault = handler
 }
@@ -0,0 +1,83 @@
+package tbot_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/yanzay/tbot/v2"
+)
+
+func TestTypedRouterLeftChatMember(t *testing.T) {
+	var left, joined, other int
+	r := &tbot.TypedRouter{}
+	r.OnLeftChatMember(func(*tbot.Message) { left++ })
+	r.OnNewChatMembers(func(*tbot.Message) { joined++ })
+	r.OnDefault(func(*tbot.Message) { other++ })
+
+	r.Handle(&tbot.Message{LeftChatMember: &tbot.User{ID: 1}})
+	if left != 1 || joined != 0 || other != 0 {
+		t.Fatalf("unexpected calls: left %d, joined %d, other %d", left, joined, other)
+	}
+}
+
+func TestTypedRouterFallthrough(t *testing.T) {
+	var left, other int
+	r := &tbot.TypedRouter{}
+	r.OnLeftChatMember(func(*tbot.Message) { left++ })
+
+	// no default handler, message is ignored
+	r.Handle(&tbot.Message{Text: "hello"})
+	// no handler for pinned message, falls through to default
+	r.OnDefault(func(*tbot.Message) { other++ })
+	r.Handle(&tbot.Message{PinnedMessage: &tbot.Message{}})
+	r.Handle(&tbot.Message{Text: "hello"})
+	if left != 0 || other != 2 {
+		t.Fatalf("unexpected calls: left %d, other %d", left, other)
+	}
+}
+
+func TestOrderedRouter(t *testing.T) {
+	var calls []string
+	r := &tbot.OrderedRouter{}
+	r.Add(func(m *tbot.Message) bool {
+		calls = append(calls, "command")
+		return strings.HasPrefix(m.Text, "/")
+	})
+	r.Add(func(m *tbot.Message) bool {
+		calls = append(calls, "greeting")
+		return strings.Contains(m.Text, "hello")
+	})
+	r.OnDefault(func(*tbot.Message) { calls = append(calls, "default") })
+
+	cases := []struct {
+		text  string
+		calls []string
+	}{
+		{"/start", []string{"command"}},
+		{"/hello", []string{"command"}},
+		{"hello bot", []string{"command", "greeting"}},
+		{"bye", []string{"command", "greeting", "default"}},
+	}
+	for _, c := range cases {
+		calls = nil
+		r.Handle(&tbot.Message{Text: c.text})
+		if !reflect.DeepEqual(calls, c.calls) {
+			t.Errorf("%q: expected calls %v, got %v", c.text, c.calls, calls)
+		}
+	}
+}
+
+func TestOrderedRouterWithoutDefault(t *testing.T) {
+	var handled int
+	r := &tbot.OrderedRouter{}
+	r.Handle(&tbot.Message{Text: "hello"})
+	r.Add(func(*tbot.Message) bool {
+		handled++
+		return false
+	})
+	r.Handle(&tbot.Message{Text: "hello"})
+	if handled != 1 {
+		t.Fatalf("expected 1 call, got %d", handled)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"net"
 	"net/http"
 	"net/url"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -16,24 +17,52 @@ const (
 	apiBaseURL = "https://api.telegram.org"
 )
 
-// Server will connect and serve all updates from Telegram
+/*
+Server will connect and serve all updates from Telegram.
+
+With long polling updates are handled one by one in the order they are received.
+With webhook every request is handled in its own goroutine, so handlers run concurrently,
+even for the same chat. WithPerChatOrdering handles updates of the same chat in order
+and different chats in parallel in both modes. In any case state shared between chats,
+e.g. a map in the application struct, must be guarded by a mutex.
+*/
 type Server struct {
 	ctx    context.Context
 	cancel func()
 
 	listeningSocket net.Listener
 
-	webhookURL string
-	listenAddr string
+	webhookURL            string
+	listenAddr            string
+	webhookMaxConnections int
+	webhookIPAddress      string
+
 	baseURL    string
 	httpClient *http.Client
 	client     *Client
 	token      string
 	logger     Logger
+	metrics    Metrics
 	bufferSize int
 	nextOffset int
 
+	allowedUpdates []string
+	chatQueues     *chatQueues
+	pendingOffsets *pendingOffsets
+	fileCache      *fileCache
+	watchdog       *watchdog
+	offsetStore    OffsetStore
+
+	perChatOrdering   bool
+	maxPendingPerChat int
+	maxPendingTotal   int
+
+	me           *User
+	conversation *Conversation
+
+	stateHandlers          map[string]handlerFunc
 	messageHandlers        map[string]handlerFunc
+	commandHandlers        map[string]commandFunc
 	defaultMessageHandler  handlerFunc
 	editMessageHandler     handlerFunc
 	channelPostHandler     handlerFunc
@@ -41,12 +70,22 @@ type Server struct {
 	inlineQueryHandler     func(*InlineQuery)
 	inlineResultHandler    func(*ChosenInlineResult)
 	callbackHandler        func(*CallbackQuery)
+	callbackPrefixHandlers map[string]callbackFunc
 	shippingHandler        func(*ShippingQuery)
 	preCheckoutHandler     func(*PreCheckoutQuery)
 	pollHandler            func(*Poll)
 	pollAnswerHandler      func(*PollAnswer)
-
-	//	middlewares []Middleware
+	reactionHandler        func(*MessageReactionUpdated)
+	reactionCountHandler   func(*MessageReactionCountUpdated)
+	myChatMemberHandler    func(*ChatMemberUpdated)
+	botRemovedHandler      func(chatID int64)
+	joinRequestHandler     func(*ChatJoinRequest)
+	businessConnHandler    func(*BusinessConnection)
+	businessMessageHandler handlerFunc
+	editBusinessHandler    handlerFunc
+	errorHandler           func(*Message, error)
+
+	middlewares []Middleware
 }
 
 // UpdateHandler is a function for middlewares
@@ -60,18 +99,40 @@ type ServerOption func(*Server)
 
 type handlerFunc func(*Message)
 
+type commandFunc func(*Message, []string)
+
+type callbackFunc func(*CallbackQuery, string)
+
 /*
 New creates new Server. Available options:
 	WithWebhook(url, addr string)
+	WithWebhookOptions(maxConnections int, ipAddress string)
 	WithHTTPClient(client *http.Client)
 	WithBaseURL(baseURL string)
+	WithLogger(logger Logger)
+	WithStateStore(store StateStore)
+	WithMetrics(metrics Metrics)
+	WithAllowedUpdates(updateTypes ...string)
+	WithErrorHandler(handler func(*Message, error))
+	WithPerChatOrdering()
+	WithMaxPendingUpdates(perChat, total int)
+	WithFileCache(size int, ttl time.Duration)
+	WithStallTimeout(d time.Duration, onStall func())
+	WithOffsetStore(store OffsetStore)
 */
 func New(token string, options ...ServerOption) *Server {
 	s := &Server{
-		httpClient: http.DefaultClient,
-		token:      token,
-		logger:     nopLogger{},
-		baseURL:    apiBaseURL,
+		httpClient:  http.DefaultClient,
+		token:       token,
+		logger:      nopLogger{},
+		metrics:     nopMetrics{},
+		baseURL:     apiBaseURL,
+		offsetStore: NewMemoryOffsetStore(),
+		conversation: &Conversation{
+			store: NewMemoryStateStore(),
+		},
+		maxPendingPerChat: DefaultMaxPendingUpdatesPerChat,
+		maxPendingTotal:   DefaultMaxPendingUpdates,
 	}
 
 	s.ctx, s.cancel = context.WithCancel(context.Background())
@@ -79,8 +140,15 @@ func New(token string, options ...ServerOption) *Server {
 	for _, opt := range options {
 		opt(s)
 	}
+	if s.perChatOrdering {
+		s.chatQueues = newChatQueues(s.processSingleUpdate, s.maxPendingPerChat, s.maxPendingTotal)
+		s.pendingOffsets = newPendingOffsets(s.saveOffset)
+	}
 	// bot, err :=  tgbotapi.NewBotAPIWithClient(token, s.httpClient)
 	s.client = NewClient(token, s.httpClient, s.baseURL)
+	s.client.logger = s.logger
+	s.client.metrics = s.metrics
+	s.client.fileCache = s.fileCache
 	return s
 }
 
@@ -93,6 +161,23 @@ func WithWebhook(url, addr string) ServerOption {
 	}
 }
 
+// Limits of webhook max_connections
+const (
+	MinWebhookConnections = 1
+	MaxWebhookConnections = 100
+)
+
+// WithWebhookOptions sets maximum number of simultaneous webhook connections (1-100)
+// and fixed IP address to send webhook requests to instead of resolving the webhook host.
+// Zero maxConnections or empty ipAddress keep Telegram defaults.
+// Updates sent to the webhook are limited with WithAllowedUpdates.
+func WithWebhookOptions(maxConnections int, ipAddress string) ServerOption {
+	return func(s *Server) {
+		s.webhookMaxConnections = maxConnections
+		s.webhookIPAddress = ipAddress
+	}
+}
+
 // WithBaseURL sets custom apiBaseURL for server.
 // It may be necessary to run the server in some countries
 func WithBaseURL(baseURL string) ServerOption {
@@ -108,6 +193,23 @@ func WithHTTPClient(client *http.Client) ServerOption {
 	}
 }
 
+// WithAllowedUpdates sets types of updates the bot receives, e.g. "message", "callback_query".
+// By default Telegram sends all types except "chat_member", "message_reaction" and "message_reaction_count".
+func WithAllowedUpdates(updateTypes ...string) ServerOption {
+	return func(s *Server) {
+		s.allowedUpdates = updateTypes
+	}
+}
+
+// WithFileCache enables cache of file paths used by Client.DownloadFile,
+// up to size files are downloaded without getFile request during ttl.
+// Telegram keeps file path valid for at least an hour.
+func WithFileCache(size int, ttl time.Duration) ServerOption {
+	return func(s *Server) {
+		s.fileCache = newFileCache(size, ttl)
+	}
+}
+
 // WithLogger sets logger for tbot
 func WithLogger(logger Logger) ServerOption {
 	return func(s *Server) {
@@ -115,23 +217,91 @@ func WithLogger(logger Logger) ServerOption {
 	}
 }
 
-// Use adds middleware to server
-// func (s *Server) Use(m Middleware) {
-// 	s.middlewares = append(s.middlewares, m)
-// }
+// WithErrorHandler sets handler for errors returned by message handlers registered
+// with HandleMessageE and HandleCommandE. By default errors are logged.
+func WithErrorHandler(handler func(*Message, error)) ServerOption {
+	return func(s *Server) {
+		s.errorHandler = handler
+	}
+}
 
-func (s *Server) processBatchOfUpdates(updates []*Update) {
-	for _, v := range updates {
-		s.processSingleUpdate(v)
+// Use adds middleware to server. Middlewares are called in the order they are added,
+// before the update is passed to handlers.
+func (s *Server) Use(m Middleware) {
+	s.middlewares = append(s.middlewares, m)
+}
+
+// processBatchOfUpdates processes long polling updates and saves the offset of processed updates.
+// With WithPerChatOrdering updates are queued, the offset is saved by pendingOffsets
+// when they are processed. Returns false if some updates are dropped by Stop.
+func (s *Server) processBatchOfUpdates(updates []*Update) bool {
+	if s.chatQueues == nil {
+		for _, update := range updates {
+			s.processSingleUpdate(update)
+		}
+		s.saveOffset(updates[len(updates)-1].UpdateID + 1)
+		return true
 	}
+	for _, update := range updates {
+		updateID := update.UpdateID
+		s.pendingOffsets.add(updateID)
+		if !s.chatQueues.push(update, func() { s.pendingOffsets.done(updateID) }) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Server) saveOffset(offset int) {
+	if err := s.offsetStore.Save(offset); err != nil {
+		s.logger.Errorf("unable to save updates offset: %v", err)
+	}
+}
+
+// processUpdate handles update synchronously or queues it with WithPerChatOrdering
+func (s *Server) processUpdate(update *Update) {
+	if s.chatQueues != nil {
+		s.chatQueues.push(update, nil)
+		return
+	}
+	s.processSingleUpdate(update)
 }
 
 func (s *Server) processSingleUpdate(update *Update) {
+	updateType := updateType(update)
+	s.metrics.IncUpdate(updateType)
+	start := time.Now()
+	s.bindClient(update)
+	var handler UpdateHandler = s.dispatch
+	for i := len(s.middlewares) - 1; i >= 0; i-- {
+		handler = s.middlewares[i](handler)
+	}
+	handler(update)
+	s.metrics.ObserveHandler(updateType, time.Since(start))
+	if s.watchdog != nil {
+		s.watchdog.touch()
+	}
+}
+
+// bindClient sets client of incoming messages to make Message.Reply work in handlers
+func (s *Server) bindClient(update *Update) {
+	for _, m := range []*Message{update.Message, update.EditedMessage, update.ChannelPost, update.EditedChannelPost,
+		update.BusinessMessage, update.EditedBusinessMessage} {
+		if m != nil {
+			m.client = s.client
+		}
+	}
+	if update.CallbackQuery != nil && update.CallbackQuery.Message != nil {
+		update.CallbackQuery.Message.client = s.client
+	}
+}
+
+func (s *Server) dispatch(update *Update) {
 	switch {
 	case update.Message != nil:
 		s.handleMessage(update.Message)
 	case update.EditedMessage != nil:
-		if s.editChannelPostHandler != nil {
+		if s.editMessageHandler != nil {
 			s.editMessageHandler(update.EditedMessage)
 		}
 	case update.ChannelPost != nil:
@@ -151,9 +321,7 @@ func (s *Server) processSingleUpdate(update *Update) {
 			s.inlineResultHandler(update.ChosenInlineResult)
 		}
 	case update.CallbackQuery != nil:
-		if s.callbackHandler != nil {
-			s.callbackHandler(update.CallbackQuery)
-		}
+		s.handleCallback(update.CallbackQuery)
 	case update.ShippingQuery != nil:
 		if s.shippingHandler != nil {
 			s.shippingHandler(update.ShippingQuery)
@@ -170,18 +338,59 @@ func (s *Server) processSingleUpdate(update *Update) {
 		if s.pollAnswerHandler != nil {
 			s.pollAnswerHandler(update.PollAnswer)
 		}
+	case update.MessageReaction != nil:
+		if s.reactionHandler != nil {
+			s.reactionHandler(update.MessageReaction)
+		}
+	case update.MessageReactionCount != nil:
+		if s.reactionCountHandler != nil {
+			s.reactionCountHandler(update.MessageReactionCount)
+		}
+	case update.MyChatMember != nil:
+		s.handleMyChatMember(update.MyChatMember)
+	case update.ChatJoinRequest != nil:
+		if s.joinRequestHandler != nil {
+			s.joinRequestHandler(update.ChatJoinRequest)
+		}
+	case update.BusinessConnection != nil:
+		if s.businessConnHandler != nil {
+			s.businessConnHandler(update.BusinessConnection)
+		}
+	case update.BusinessMessage != nil:
+		if s.businessMessageHandler != nil {
+			s.businessMessageHandler(update.BusinessMessage)
+		}
+	case update.EditedBusinessMessage != nil:
+		if s.editBusinessHandler != nil {
+			s.editBusinessHandler(update.EditedBusinessMessage)
+		}
 	}
 }
 
-// Start listening for updates
+// Start listening for updates. Server uses webhook if it's configured with WithWebhook,
+// otherwise webhook is deleted and updates are received with long polling.
+// Bot info is requested with GetMe first, see Me, Start fails if the request fails,
+// e.g. on invalid token or network error.
 func (s *Server) Start() error {
 	if len(s.token) == 0 {
 		return fmt.Errorf("token is empty")
 	}
+	me, err := s.client.GetMe()
+	if err != nil {
+		return fmt.Errorf("unable to get bot info: %v", err)
+	}
+	s.me = me
+	if s.watchdog != nil {
+		go s.watchdog.run(s.ctx)
+	}
 	if s.webhookURL != "" && s.listenAddr != "" {
 		return s.listenUpdates()
 	}
-	// s.client.deleteWebhook()
+	// long polling doesn't work while webhook is set
+	err = s.client.DeleteWebhook(false)
+	if err != nil {
+		return fmt.Errorf("unable to delete webhook: %v", err)
+	}
 	return s.processLongPollUpdates()
 }
 
@@ -190,19 +399,40 @@ func (s *Server) Client() *Client {
 	return s.client
 }
 
-// Stop listening for updates
+// Me returns bot's own user info. It is requested with GetMe on Start,
+// so it is nil until the server is started. Commands like "/start@username"
+// are handled only if the username is the bot's one.
+func (s *Server) Me() *User {
+	return s.me
+}
+
+// Conversation returns states of users in multi-step dialogs
+func (s *Server) Conversation() *Conversation {
+	return s.conversation
+}
+
+// Stop listening for updates. With WithPerChatOrdering Stop waits for queued updates
+// to be handled, so handlers must not call it synchronously, use "go server.Stop()".
 func (s *Server) Stop() {
 	s.cancel()
 	if s.listeningSocket != nil {
 		s.listeningSocket.Close()
 	}
+	if s.chatQueues != nil {
+		s.chatQueues.close()
+	}
 }
 
 func (s *Server) listenUpdates() error {
-	err := s.client.setWebhook(s.webhookURL)
+	if n := s.webhookMaxConnections; n != 0 && (n < MinWebhookConnections || n > MaxWebhookConnections) {
+		return fmt.Errorf("webhook max connections must be between %d and %d, got %d",
+			MinWebhookConnections, MaxWebhookConnections, n)
+	}
+	err := s.client.setWebhook(s.webhookURL, s.allowedUpdates, s.webhookMaxConnections, s.webhookIPAddress)
 	if err != nil {
 		return fmt.Errorf("unable to set webhook: %v", err)
 	}
+	s.logWebhookInfo()
 	handler := func(w http.ResponseWriter, r *http.Request) {
 		up := &Update{}
 		err := json.NewDecoder(r.Body).Decode(up)
@@ -210,7 +440,7 @@ func (s *Server) listenUpdates() error {
 			s.logger.Errorf("unable to decode update: %v", err)
 			return
 		}
-		s.processSingleUpdate(up)
+		s.processUpdate(up)
 	}
 	s.listeningSocket, err = net.Listen("tcp", s.listenAddr)
 	if err != nil {
@@ -219,6 +449,18 @@ func (s *Server) listenUpdates() error {
 	return http.Serve(s.listeningSocket, http.HandlerFunc(handler))
 }
 
+func (s *Server) logWebhookInfo() {
+	info, err := s.client.GetWebhookInfo()
+	if err != nil {
+		s.logger.Warnf("unable to get webhook info: %v", err)
+		return
+	}
+	s.logger.Infof("webhook %s: %d pending updates, max connections %d", info.URL, info.PendingUpdateCount, info.MaxConnections)
+	if info.LastErrorMessage != "" {
+		s.logger.Warnf("last webhook error at %s: %s", time.Unix(info.LastErrorDate, 0).UTC().Format(time.RFC3339), info.LastErrorMessage)
+	}
+}
+
 func (s *Server) processLongPollUpdates() error {
 	s.logger.Debugf("fetching updates...")
 	var endpoint strings.Builder
@@ -228,6 +470,16 @@ func (s *Server) processLongPollUpdates() error {
 	endpoint.WriteString("/getUpdates")
 	params := url.Values{}
 	params.Set("timeout", "60")
+	if s.allowedUpdates != nil {
+		params.Set("allowed_updates", structString(s.allowedUpdates))
+	}
+	offset, err := s.offsetStore.Load()
+	if err != nil {
+		return fmt.Errorf("unable to load updates offset: %v", err)
+	}
+	if offset != 0 {
+		s.nextOffset = offset
+	}
 	for {
 		if s.nextOffset != 0 {
 			params.Set("offset", strconv.Itoa(s.nextOffset))
@@ -284,19 +536,96 @@ func (s *Server) processLongPollUpdates() error {
 		if len(updatesResp.Result) == 0 {
 			continue
 		}
+		if !s.processBatchOfUpdates(updatesResp.Result) {
+			// server is stopped, unprocessed updates are received again after restart
+			return s.ctx.Err()
+		}
 		s.nextOffset = updatesResp.Result[len(updatesResp.Result)-1].UpdateID + 1
-		s.processBatchOfUpdates(updatesResp.Result)
 	}
 }
 
-// HandleMessage sets handler for incoming messages
+// HandleMessage sets handler for incoming messages.
+// Registering the same text twice replaces the handler and logs a warning.
 func (s *Server) HandleMessage(text string, handler func(*Message)) {
 	if s.messageHandlers == nil {
 		s.messageHandlers = make(map[string]handlerFunc)
 	}
+	if _, ok := s.messageHandlers[text]; ok {
+		s.logger.Warnf("duplicate handler for message %q, previous handler is replaced", text)
+	}
 	s.messageHandlers[text] = handler
 }
 
+// HandleMessageE sets handler for incoming messages like HandleMessage,
+// errors returned by the handler are passed to the error handler, see WithErrorHandler
+func (s *Server) HandleMessageE(text string, handler func(*Message) error) {
+	s.HandleMessage(text, func(m *Message) {
+		s.handleError(m, handler(m))
+	})
+}
+
+// HandleCommandE sets handler for bot command like HandleCommand,
+// errors returned by the handler are passed to the error handler, see WithErrorHandler
+func (s *Server) HandleCommandE(command string, handler func(*Message, []string) error) {
+	s.HandleCommand(command, func(m *Message, args []string) {
+		s.handleError(m, handler(m, args))
+	})
+}
+
+func (s *Server) handleError(m *Message, err error) {
+	if err == nil {
+		return
+	}
+	if s.errorHandler != nil {
+		s.errorHandler(m, err)
+		return
+	}
+	s.logger.Errorf("unable to handle message %q: %v", m.Text, err)
+}
+
+// Handlers returns sorted list of registered message texts and commands
+func (s *Server) Handlers() []string {
+	handlers := make([]string, 0, len(s.messageHandlers)+len(s.commandHandlers))
+	for text := range s.messageHandlers {
+		handlers = append(handlers, text)
+	}
+	for command := range s.commandHandlers {
+		if _, ok := s.messageHandlers[command]; !ok {
+			handlers = append(handlers, command)
+		}
+	}
+	sort.Strings(handlers)
+	return handlers
+}
+
+// HandleCommand sets handler for bot command, e.g. "/add".
+// Command matches messages starting with it, including "/add@botusername" form.
+// Remaining whitespace separated words are passed to the handler as arguments.
+func (s *Server) HandleCommand(command string, handler func(*Message, []string)) {
+	if s.commandHandlers == nil {
+		s.commandHandlers = make(map[string]commandFunc)
+	}
+	if !strings.HasPrefix(command, "/") {
+		command = "/" + command
+	}
+	if _, ok := s.commandHandlers[command]; ok {
+		s.logger.Warnf("duplicate handler for command %s, previous handler is replaced", command)
+	}
+	s.commandHandlers[command] = handler
+}
+
+// HandleState sets handler for messages from users in given conversation state.
+// State handlers take precedence over all other message handlers.
+func (s *Server) HandleState(state string, handler func(*Message)) {
+	if s.stateHandlers == nil {
+		s.stateHandlers = make(map[string]handlerFunc)
+	}
+	if _, ok := s.stateHandlers[state]; ok {
+		s.logger.Warnf("duplicate handler for state %q, previous handler is replaced", state)
+	}
+	s.stateHandlers[state] = handler
+}
+
 // HandleEditedMessage set handler for incoming edited messages
 func (s *Server) HandleEditedMessage(handler func(*Message)) {
 	s.editMessageHandler = handler
@@ -327,6 +656,19 @@ func (s *Server) HandleCallback(handler func(*CallbackQuery)) {
 	s.callbackHandler = handler
 }
 
+// HandleCallbackPrefix sets handler for inline buttons with callback data in "prefix:payload" form.
+// Data is split on the first colon, handler receives the payload after it.
+// Callbacks without registered prefix go to HandleCallback handler.
+func (s *Server) HandleCallbackPrefix(prefix string, handler func(*CallbackQuery, string)) {
+	if s.callbackPrefixHandlers == nil {
+		s.callbackPrefixHandlers = make(map[string]callbackFunc)
+	}
+	if _, ok := s.callbackPrefixHandlers[prefix]; ok {
+		s.logger.Warnf("duplicate handler for callback prefix %q, previous handler is replaced", prefix)
+	}
+	s.callbackPrefixHandlers[prefix] = handler
+}
+
 // HandleShipping set handler for shipping queries
 func (s *Server) HandleShipping(handler func(*ShippingQuery)) {
 	s.shippingHandler = handler
@@ -347,16 +689,130 @@ func (s *Server) HandlePollAnswer(handler func(*PollAnswer)) {
 	s.pollAnswerHandler = handler
 }
 
+// HandleMessageReaction set handler for changes of reactions by users.
+// Telegram sends reaction updates only if "message_reaction" is in allowed updates, see WithAllowedUpdates.
+func (s *Server) HandleMessageReaction(handler func(*MessageReactionUpdated)) {
+	s.reactionHandler = handler
+}
+
+// HandleMessageReactionCount set handler for changes of anonymous reactions.
+// Telegram sends reaction updates only if "message_reaction_count" is in allowed updates, see WithAllowedUpdates.
+func (s *Server) HandleMessageReactionCount(handler func(*MessageReactionCountUpdated)) {
+	s.reactionCountHandler = handler
+}
+
+// HandleMyChatMember set handler for changes of the bot's status in chats
+func (s *Server) HandleMyChatMember(handler func(*ChatMemberUpdated)) {
+	s.myChatMemberHandler = handler
+}
+
+// HandleBotRemoved set handler called when the bot was removed or banned from the chat,
+// use it to clean up per-chat state. It is called after HandleMyChatMember handler.
+func (s *Server) HandleBotRemoved(handler func(chatID int64)) {
+	s.botRemovedHandler = handler
+}
+
+// HandleChatJoinRequest set handler for requests to join chats where the bot
+// is an administrator with can_invite_users right
+func (s *Server) HandleChatJoinRequest(handler func(*ChatJoinRequest)) {
+	s.joinRequestHandler = handler
+}
+
+// HandleBusinessConnection set handler for connections of the bot to business accounts
+func (s *Server) HandleBusinessConnection(handler func(*BusinessConnection)) {
+	s.businessConnHandler = handler
+}
+
+// HandleBusinessMessage set handler for messages in chats of connected business accounts.
+// Message.Reply answers on behalf of the business account.
+func (s *Server) HandleBusinessMessage(handler func(*Message)) {
+	s.businessMessageHandler = handler
+}
+
+// HandleEditedBusinessMessage set handler for edited messages in chats of connected business accounts
+func (s *Server) HandleEditedBusinessMessage(handler func(*Message)) {
+	s.editBusinessHandler = handler
+}
+
+func (s *Server) handleMyChatMember(u *ChatMemberUpdated) {
+	if s.myChatMemberHandler != nil {
+		s.myChatMemberHandler(u)
+	}
+	if s.botRemovedHandler != nil && u.Left() {
+		s.botRemovedHandler(u.Chat.ID)
+	}
+}
+
+func (s *Server) handleCallback(cq *CallbackQuery) {
+	prefix, payload := cq.Data, ""
+	if i := strings.Index(cq.Data, ":"); i >= 0 {
+		prefix, payload = cq.Data[:i], cq.Data[i+1:]
+	}
+	if h := s.callbackPrefixHandlers[prefix]; h != nil {
+		h(cq, payload)
+		return
+	}
+	if s.callbackHandler != nil {
+		s.callbackHandler(cq)
+	}
+}
+
 func (s *Server) handleMessage(msg *Message) {
+	if len(s.stateHandlers) != 0 {
+		state, err := s.conversation.messageState(msg)
+		if err != nil {
+			s.logger.Errorf("unable to get conversation state: %v", err)
+		}
+		if h := s.stateHandlers[state]; state != "" && h != nil {
+			h(msg)
+			return
+		}
+	}
 	if h := s.messageHandlers[msg.Text]; h != nil {
 		h(msg)
 		return
 	}
+	if command, args, ok := parseCommand(msg.Text, s.username()); ok {
+		if h := s.commandHandlers[command]; h != nil {
+			h(msg, args)
+			return
+		}
+	}
 	if s.defaultMessageHandler != nil {
 		s.defaultMessageHandler(msg)
 	}
 }
 
+// HandleDefault sets handler for messages not matched by other handlers
 func (s *Server) HandleDefault(handler handlerFunc) {
 	s.defaultMessageHandler = handler
 }
+
+// HandleRouter sets router as default message handler
+func (s *Server) HandleRouter(r Router) {
+	s.defaultMessageHandler = r.Handle
+}
+
+func (s *Server) username() string {
+	if s.me == nil {
+		return ""
+	}
+	return s.me.Username
+}
+
+// parseCommand splits text into command and its arguments.
+// Commands addressed to other bots with @username suffix are not matched.
+func parseCommand(text, username string) (string, []string, bool) {
+	fields := strings.Fields(text)
+	if len(fields) == 0 || !strings.HasPrefix(fields[0], "/") {
+		return "", nil, false
+	}
+	command := fields[0]
+	if i := strings.Index(command, "@"); i != -1 {
+		if !strings.EqualFold(command[i+1:], username) {
+			return "", nil, false
+		}
+		command = command[:i]
+	}
+	return command, fields[1:], true
+}
@@ -0,0 +1,589 @@
+package tbot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestHandleCommand(t *testing.T) {
+	tt := []struct {
+		text string
+		args []string
+	}{
+		{text: "/add 2 3", args: []string{"2", "3"}},
+		{text: "/add@mybot 2 3", args: []string{"2", "3"}},
+		{text: "/add", args: []string{}},
+	}
+	for _, tc := range tt {
+		s := New("TOKEN")
+		s.me = &User{Username: "mybot"}
+		var got []string
+		s.HandleCommand("/add", func(m *Message, args []string) {
+			got = args
+		})
+		s.processSingleUpdate(&Update{Message: &Message{Text: tc.text}})
+		if !reflect.DeepEqual(got, tc.args) {
+			t.Fatalf("%q: expected args %v, got %v", tc.text, tc.args, got)
+		}
+	}
+}
+
+func TestHandleCommandOtherBot(t *testing.T) {
+	s := New("TOKEN")
+	s.me = &User{Username: "mybot"}
+	var command, other bool
+	s.HandleCommand("add", func(*Message, []string) { command = true })
+	s.HandleDefault(func(*Message) { other = true })
+	s.processSingleUpdate(&Update{Message: &Message{Text: "/add@otherbot 2 3"}})
+	if command || !other {
+		t.Fatalf("command for other bot should go to default handler")
+	}
+}
+
+func TestHandleCommandWithMessageHandler(t *testing.T) {
+	s := New("TOKEN")
+	var message, command bool
+	s.HandleMessage("/add", func(*Message) { message = true })
+	s.HandleCommand("/add", func(*Message, []string) { command = true })
+	s.processSingleUpdate(&Update{Message: &Message{Text: "/add"}})
+	if !message || command {
+		t.Fatalf("exact message handler should take precedence")
+	}
+	s.processSingleUpdate(&Update{Message: &Message{Text: "/add 1"}})
+	if !command {
+		t.Fatalf("command handler not called")
+	}
+}
+
+func TestHandleCallbackPrefix(t *testing.T) {
+	tt := []struct {
+		data     string
+		prefix   string
+		payload  string
+		fallback bool
+	}{
+		{data: "vote:123:456", prefix: "vote", payload: "123:456"},
+		{data: "page:", prefix: "page", payload: ""},
+		{data: "page", prefix: "page", payload: ""},
+		{data: "votes:1", fallback: true},
+		{data: "other", fallback: true},
+	}
+	for _, tc := range tt {
+		s := New("TOKEN")
+		var prefix, payload string
+		var fallback bool
+		s.HandleCallbackPrefix("vote", func(cq *CallbackQuery, p string) { prefix, payload = "vote", p })
+		s.HandleCallbackPrefix("page", func(cq *CallbackQuery, p string) { prefix, payload = "page", p })
+		s.HandleCallback(func(*CallbackQuery) { fallback = true })
+		s.processSingleUpdate(&Update{CallbackQuery: &CallbackQuery{Data: tc.data}})
+		if prefix != tc.prefix || payload != tc.payload || fallback != tc.fallback {
+			t.Fatalf("%q: expected %q %q %v, got %q %q %v",
+				tc.data, tc.prefix, tc.payload, tc.fallback, prefix, payload, fallback)
+		}
+	}
+}
+
+func TestHandleEditedMessage(t *testing.T) {
+	s := New("TOKEN")
+	var edited *Message
+	s.HandleEditedMessage(func(m *Message) { edited = m })
+	s.processSingleUpdate(&Update{EditedMessage: &Message{MessageID: 1, Text: "fixed"}})
+	if edited == nil || edited.Text != "fixed" {
+		t.Fatalf("edited message handler not called")
+	}
+}
+
+func TestDuplicateHandlers(t *testing.T) {
+	logger := &testLogger{}
+	s := New("TOKEN", WithLogger(logger))
+	var first, second bool
+	s.HandleMessage("hi", func(*Message) { first = true })
+	s.HandleCommand("/start", func(*Message, []string) {})
+	s.HandleCommand("/help", func(*Message, []string) {})
+	if len(logger.warnings) != 0 {
+		t.Fatalf("unexpected warnings: %v", logger.warnings)
+	}
+	s.HandleMessage("hi", func(*Message) { second = true })
+	s.HandleCommand("start", func(*Message, []string) {})
+	if len(logger.warnings) != 2 {
+		t.Fatalf("expected 2 warnings, got %v", logger.warnings)
+	}
+	s.processSingleUpdate(&Update{Message: &Message{Text: "hi"}})
+	if first || !second {
+		t.Fatalf("last registered handler should be called")
+	}
+	expected := []string{"/help", "/start", "hi"}
+	if handlers := s.Handlers(); !reflect.DeepEqual(handlers, expected) {
+		t.Fatalf("expected handlers %v, got %v", expected, handlers)
+	}
+}
+
+func TestHandleMessageReaction(t *testing.T) {
+	data := `{
+		"update_id": 1,
+		"message_reaction": {
+			"chat": {"id": 42},
+			"message_id": 7,
+			"user": {"id": 5},
+			"date": 1700000000,
+			"old_reaction": [],
+			"new_reaction": [{"type": "emoji", "emoji": "👍"}, {"type": "custom_emoji", "custom_emoji_id": "123"}, {"type": "new"}]
+		}
+	}`
+	update := &Update{}
+	err := json.Unmarshal([]byte(data), update)
+	if err != nil {
+		t.Fatalf("unable to decode update: %v", err)
+	}
+	s := New("TOKEN")
+	var reaction *MessageReactionUpdated
+	s.HandleMessageReaction(func(r *MessageReactionUpdated) { reaction = r })
+	s.processSingleUpdate(update)
+	if reaction == nil || reaction.MessageID != 7 || reaction.User.ID != 5 {
+		t.Fatalf("unexpected reaction: %+v", reaction)
+	}
+	expected := []ReactionType{ReactionTypeEmoji{Emoji: "👍"}, ReactionTypeCustomEmoji{CustomEmojiID: "123"}}
+	if len(reaction.OldReaction) != 0 || !reflect.DeepEqual(reaction.NewReaction, expected) {
+		t.Fatalf("unexpected reactions: %v -> %v", reaction.OldReaction, reaction.NewReaction)
+	}
+}
+
+func TestHandleMessageReactionCount(t *testing.T) {
+	data := `{
+		"update_id": 1,
+		"message_reaction_count": {
+			"chat": {"id": 42},
+			"message_id": 7,
+			"date": 1700000000,
+			"reactions": [
+				{"type": {"type": "emoji", "emoji": "👍"}, "total_count": 3},
+				{"type": {"type": "paid"}, "total_count": 1}
+			]
+		}
+	}`
+	update := &Update{}
+	err := json.Unmarshal([]byte(data), update)
+	if err != nil {
+		t.Fatalf("unable to decode update: %v", err)
+	}
+	s := New("TOKEN")
+	var count *MessageReactionCountUpdated
+	s.HandleMessageReactionCount(func(c *MessageReactionCountUpdated) { count = c })
+	s.processSingleUpdate(update)
+	if count == nil || count.Chat.ID != 42 || len(count.Reactions) != 2 {
+		t.Fatalf("unexpected reaction count: %+v", count)
+	}
+	if r := count.Reactions[0]; r.Type != (ReactionTypeEmoji{Emoji: "👍"}) || r.TotalCount != 3 {
+		t.Fatalf("unexpected reaction: %+v", r)
+	}
+	if _, ok := count.Reactions[1].Type.(ReactionTypePaid); !ok {
+		t.Fatalf("expected paid reaction, got %+v", count.Reactions[1].Type)
+	}
+}
+
+func TestHandleChatJoinRequest(t *testing.T) {
+	data := `{
+		"update_id": 1,
+		"chat_join_request": {
+			"chat": {"id": -100},
+			"from": {"id": 5},
+			"user_chat_id": 5,
+			"date": 1700000000,
+			"bio": "gopher",
+			"invite_link": {"invite_link": "https://t.me/+abc", "creates_join_request": true}
+		}
+	}`
+	update := &Update{}
+	if err := json.Unmarshal([]byte(data), update); err != nil {
+		t.Fatalf("unable to decode update: %v", err)
+	}
+	if updateType(update) != "chat_join_request" {
+		t.Fatalf("unexpected update type: %s", updateType(update))
+	}
+	var got *ChatJoinRequest
+	s := New("TOKEN")
+	s.HandleChatJoinRequest(func(r *ChatJoinRequest) { got = r })
+	s.processSingleUpdate(update)
+	if got == nil || got.Chat.ID != -100 || got.UserChatID != 5 || got.InviteLink == nil || !got.InviteLink.CreatesJoinRequest {
+		t.Fatalf("unexpected join request: %+v", got)
+	}
+}
+
+func TestMessageWebAppData(t *testing.T) {
+	data := `{
+		"message_id": 1,
+		"chat": {"id": 5, "type": "private"},
+		"web_app_data": {"data": "{\"item\": \"coffee\", \"count\": 2}", "button_text": "Order"}
+	}`
+	m := &Message{}
+	if err := json.Unmarshal([]byte(data), m); err != nil {
+		t.Fatalf("unable to decode message: %v", err)
+	}
+	if m.WebAppData == nil || m.WebAppData.ButtonText != "Order" {
+		t.Fatalf("unexpected web app data: %+v", m.WebAppData)
+	}
+	var order struct {
+		Item  string `json:"item"`
+		Count int    `json:"count"`
+	}
+	if err := m.DecodeWebAppData(&order); err != nil {
+		t.Fatalf("unable to decode web app data: %v", err)
+	}
+	if order.Item != "coffee" || order.Count != 2 {
+		t.Fatalf("unexpected order: %+v", order)
+	}
+	if err := (&Message{Text: "hi"}).DecodeWebAppData(&order); err == nil {
+		t.Fatalf("expected error for message without web app data")
+	}
+}
+
+func TestHandleBusinessUpdates(t *testing.T) {
+	params := make(chan url.Values, 1)
+	transport := func(r *http.Request) (*http.Response, error) {
+		r.ParseForm()
+		params <- r.PostForm
+		return jsonResponse(`{"ok": true, "result": {"message_id": 2}}`), nil
+	}
+	s := New("TOKEN", WithHTTPClient(&http.Client{Transport: roundTripFunc(transport)}))
+	var conn *BusinessConnection
+	s.HandleBusinessConnection(func(c *BusinessConnection) { conn = c })
+	s.HandleBusinessMessage(func(m *Message) {
+		if _, err := m.Reply("We are open 9-18"); err != nil {
+			t.Errorf("error on reply: %v", err)
+		}
+	})
+
+	data := `{
+		"update_id": 1,
+		"business_connection": {
+			"id": "conn-1",
+			"user": {"id": 5, "first_name": "Shop"},
+			"user_chat_id": 5,
+			"date": 1700000000,
+			"can_reply": true,
+			"is_enabled": true
+		}
+	}`
+	update := &Update{}
+	if err := json.Unmarshal([]byte(data), update); err != nil {
+		t.Fatalf("unable to decode update: %v", err)
+	}
+	if updateType(update) != "business_connection" {
+		t.Fatalf("unexpected update type: %s", updateType(update))
+	}
+	s.processSingleUpdate(update)
+	if conn == nil || conn.ID != "conn-1" || conn.User.ID != 5 || conn.UserChatID != 5 || !conn.CanReply || !conn.IsEnabled {
+		t.Fatalf("unexpected connection: %+v", conn)
+	}
+
+	data = `{
+		"update_id": 2,
+		"business_message": {"message_id": 1, "business_connection_id": "conn-1", "chat": {"id": 7}, "text": "When are you open?"}
+	}`
+	update = &Update{}
+	if err := json.Unmarshal([]byte(data), update); err != nil {
+		t.Fatalf("unable to decode update: %v", err)
+	}
+	s.processSingleUpdate(update)
+	req := <-params
+	if req.Get("chat_id") != "7" || req.Get("business_connection_id") != "conn-1" {
+		t.Fatalf("reply should be sent on behalf of the business account: %v", req)
+	}
+}
+
+func TestHandleBotRemoved(t *testing.T) {
+	tt := []struct {
+		status   string
+		isMember bool
+		removed  bool
+	}{
+		{status: "kicked", removed: true},
+		{status: "left", removed: true},
+		{status: "restricted", isMember: false, removed: true},
+		{status: "restricted", isMember: true, removed: false},
+		{status: "member", removed: false},
+		{status: "administrator", removed: false},
+	}
+	for _, tc := range tt {
+		data := `{
+			"update_id": 1,
+			"my_chat_member": {
+				"chat": {"id": -100},
+				"from": {"id": 5},
+				"date": 1700000000,
+				"old_chat_member": {"user": {"id": 1}, "status": "member"},
+				"new_chat_member": {"user": {"id": 1}, "status": "` + tc.status + `", "is_member": ` + fmt.Sprint(tc.isMember) + `}
+			}
+		}`
+		update := &Update{}
+		err := json.Unmarshal([]byte(data), update)
+		if err != nil {
+			t.Fatalf("unable to decode update: %v", err)
+		}
+		s := New("TOKEN")
+		var member *ChatMemberUpdated
+		var removed []int64
+		s.HandleMyChatMember(func(u *ChatMemberUpdated) { member = u })
+		s.HandleBotRemoved(func(chatID int64) { removed = append(removed, chatID) })
+		s.processSingleUpdate(update)
+		if member == nil || member.From.ID != 5 || member.NewChatMember.Status != tc.status {
+			t.Fatalf("%s: unexpected update: %+v", tc.status, member)
+		}
+		if tc.removed && !reflect.DeepEqual(removed, []int64{-100}) {
+			t.Fatalf("%s: expected removal from -100, got %v", tc.status, removed)
+		}
+		if !tc.removed && len(removed) != 0 {
+			t.Fatalf("%s: unexpected removal: %v", tc.status, removed)
+		}
+	}
+}
+
+func TestHandleMessageE(t *testing.T) {
+	var handled []error
+	s := New("TOKEN", WithErrorHandler(func(m *Message, err error) {
+		if m.Text != "/fail" && m.Text != "hi" {
+			t.Errorf("unexpected message: %q", m.Text)
+		}
+		handled = append(handled, err)
+	}))
+	errFailed := errors.New("failed")
+	s.HandleCommandE("/fail", func(m *Message, args []string) error { return errFailed })
+	s.HandleMessageE("hi", func(m *Message) error { return nil })
+	s.processSingleUpdate(&Update{Message: &Message{Text: "/fail"}})
+	s.processSingleUpdate(&Update{Message: &Message{Text: "hi"}})
+	if len(handled) != 1 || handled[0] != errFailed {
+		t.Fatalf("expected handled error, got %v", handled)
+	}
+}
+
+func TestStartGetMe(t *testing.T) {
+	httpClient, methods := testTransport(t)
+	s := New("TOKEN", WithHTTPClient(httpClient))
+	if s.Me() != nil {
+		t.Fatalf("bot info should be empty before start")
+	}
+	done := make(chan error)
+	go func() {
+		done <- s.Start()
+	}()
+	for method := range methods {
+		if method == "getUpdates" {
+			break
+		}
+	}
+	me := s.Me()
+	if me == nil || me.ID != 42 || me.Username != "mybot" {
+		t.Fatalf("unexpected bot info: %+v", me)
+	}
+	s.Stop()
+	<-done
+}
+
+func TestStartGetMeError(t *testing.T) {
+	errNetwork := errors.New("network is unreachable")
+	var methods []string
+	transport := func(r *http.Request) (*http.Response, error) {
+		methods = append(methods, path.Base(r.URL.Path))
+		return nil, errNetwork
+	}
+	s := New("TOKEN", WithHTTPClient(&http.Client{Transport: roundTripFunc(transport)}))
+	err := s.Start()
+	if err == nil || !strings.Contains(err.Error(), "unable to get bot info") ||
+		!strings.Contains(err.Error(), errNetwork.Error()) {
+		t.Fatalf("expected bot info error, got %v", err)
+	}
+	if !reflect.DeepEqual(methods, []string{"getMe"}) {
+		t.Fatalf("server should not poll updates without bot info, called %v", methods)
+	}
+	if s.Me() != nil {
+		t.Fatalf("bot info should be empty after failed start")
+	}
+}
+
+func TestStartDeletesWebhook(t *testing.T) {
+	httpClient, methods := testTransport(t)
+	s := New("TOKEN", WithHTTPClient(httpClient))
+	done := make(chan error)
+	go func() {
+		done <- s.Start()
+	}()
+	var called []string
+	for method := range methods {
+		called = append(called, method)
+		if method == "getUpdates" {
+			break
+		}
+	}
+	s.Stop()
+	<-done
+	if !reflect.DeepEqual(called, []string{"getMe", "deleteWebhook", "getUpdates"}) {
+		t.Fatalf("unexpected calls on long polling start: %v", called)
+	}
+
+	httpClient, methods = testTransport(t)
+	// invalid address stops the server right after webhook is set
+	s = New("TOKEN", WithHTTPClient(httpClient), WithWebhook("https://bot.example.com/hook", "invalid address"))
+	if err := s.Start(); err == nil {
+		t.Fatalf("expected listen error")
+	}
+	close(methods)
+	called = nil
+	for method := range methods {
+		called = append(called, method)
+	}
+	if !reflect.DeepEqual(called, []string{"getMe", "setWebhook", "getWebhookInfo"}) {
+		t.Fatalf("unexpected calls on webhook start: %v", called)
+	}
+}
+
+func TestWebhookOptions(t *testing.T) {
+	params := make(chan url.Values, 1)
+	transport := func(r *http.Request) (*http.Response, error) {
+		switch path.Base(r.URL.Path) {
+		case "getMe":
+			return jsonResponse(`{"ok": true, "result": {"id": 42, "is_bot": true, "username": "mybot"}}`), nil
+		case "setWebhook":
+			r.ParseForm()
+			params <- r.PostForm
+		}
+		return jsonResponse(`{"ok": true, "result": true}`), nil
+	}
+	httpClient := &http.Client{Transport: roundTripFunc(transport)}
+	s := New("TOKEN", WithHTTPClient(httpClient),
+		WithWebhook("https://bot.example.com/hook", "invalid address"),
+		WithWebhookOptions(80, "149.154.167.220"),
+		WithAllowedUpdates("message", "my_chat_member"))
+	s.Start()
+	req := <-params
+	if req.Get("max_connections") != "80" || req.Get("ip_address") != "149.154.167.220" ||
+		req.Get("allowed_updates") != `["message","my_chat_member"]` {
+		t.Fatalf("unexpected setWebhook params: %v", req)
+	}
+
+	for _, n := range []int{-1, 101} {
+		s = New("TOKEN", WithHTTPClient(httpClient),
+			WithWebhook("https://bot.example.com/hook", "invalid address"),
+			WithWebhookOptions(n, ""))
+		err := s.Start()
+		if err == nil || !strings.Contains(err.Error(), "max connections") {
+			t.Fatalf("expected max connections error for %d, got %v", n, err)
+		}
+	}
+	if len(params) != 0 {
+		t.Fatalf("webhook with invalid options should not be set")
+	}
+}
+
+func TestLogWebhookInfo(t *testing.T) {
+	transport := func(r *http.Request) (*http.Response, error) {
+		return jsonResponse(`{"ok": true, "result": {"url": "https://bot.example.com/hook", "pending_update_count": 3,
+			"last_error_date": 1700000000, "last_error_message": "Connection refused"}}`), nil
+	}
+	logger := &testLogger{}
+	s := New("TOKEN", WithHTTPClient(&http.Client{Transport: roundTripFunc(transport)}), WithLogger(logger))
+	s.logWebhookInfo()
+	expected := []string{"last webhook error at 2023-11-14T22:13:20Z: Connection refused"}
+	if !reflect.DeepEqual(logger.warnings, expected) {
+		t.Fatalf("unexpected warnings: %v", logger.warnings)
+	}
+}
+
+type testLogger struct {
+	nopLogger
+	warnings []string
+}
+
+func (l *testLogger) Warnf(format string, args ...interface{}) {
+	l.warnings = append(l.warnings, fmt.Sprintf(format, args...))
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func jsonResponse(body string) *http.Response {
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       ioutil.NopCloser(strings.NewReader(body)),
+	}
+}
+
+// testTransport answers getMe and blocks long polling until request is cancelled.
+// Methods of all requests are sent to the returned channel.
+func testTransport(t *testing.T) (*http.Client, chan string) {
+	t.Helper()
+	methods := make(chan string, 100)
+	transport := func(r *http.Request) (*http.Response, error) {
+		method := path.Base(r.URL.Path)
+		methods <- method
+		switch method {
+		case "getMe":
+			return jsonResponse(`{"ok": true, "result": {"id": 42, "is_bot": true, "username": "mybot"}}`), nil
+		case "getUpdates":
+			<-r.Context().Done()
+			return nil, r.Context().Err()
+		}
+		return jsonResponse(`{"ok": true, "result": true}`), nil
+	}
+	return &http.Client{Transport: roundTripFunc(transport)}, methods
+}
+
+func TestMessageReply(t *testing.T) {
+	params := make(chan url.Values, 3)
+	transport := func(r *http.Request) (*http.Response, error) {
+		r.ParseForm()
+		if path.Base(r.URL.Path) == "sendMessage" {
+			params <- r.PostForm
+		}
+		return jsonResponse(`{"ok": true, "result": {"message_id": 2}}`), nil
+	}
+	s := New("TOKEN", WithHTTPClient(&http.Client{Transport: roundTripFunc(transport)}))
+	s.HandleMessage("hi", func(m *Message) {
+		if _, err := m.Reply("hello"); err != nil {
+			t.Errorf("error on reply: %v", err)
+		}
+		if _, err := m.ReplyTo("hello again"); err != nil {
+			t.Errorf("error on reply: %v", err)
+		}
+		if _, err := m.ReplyWithMarkdown("*bye*"); err != nil {
+			t.Errorf("error on reply: %v", err)
+		}
+	})
+	s.processSingleUpdate(&Update{Message: &Message{MessageID: 7, Chat: Chat{ID: -100}, Text: "hi"}})
+
+	req := <-params
+	if req.Get("chat_id") != "-100" || req.Get("text") != "hello" || req.Get("reply_to_message_id") != "" {
+		t.Fatalf("unexpected reply: %v", req)
+	}
+	req = <-params
+	if req.Get("chat_id") != "-100" || req.Get("reply_to_message_id") != "7" {
+		t.Fatalf("unexpected threaded reply: %v", req)
+	}
+	req = <-params
+	if req.Get("chat_id") != "-100" || req.Get("parse_mode") != "MarkdownV2" {
+		t.Fatalf("unexpected markdown reply: %v", req)
+	}
+
+	s.processSingleUpdate(&Update{Message: &Message{MessageID: 8, MessageThreadID: 3, IsTopicMessage: true, Chat: Chat{ID: -100}, Text: "hi"}})
+	req = <-params
+	if req.Get("chat_id") != "-100" || req.Get("message_thread_id") != "3" {
+		t.Fatalf("unexpected topic reply: %v", req)
+	}
+	<-params
+	<-params
+
+	if _, err := (&Message{Chat: Chat{ID: -100}}).Reply("hello"); err != errNoClient {
+		t.Fatalf("expected errNoClient, got %v", err)
+	}
+}
@@ -0,0 +1,225 @@
+package tbot
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// textChunk is a part of the long message text, start and end are byte indexes in the text
+type textChunk struct {
+	start, end int
+}
+
+// splitText splits text into chunks of at most maxLength UTF-16 code units.
+// Text is split on line breaks if possible, then on spaces, then between any characters.
+// Chunks are split only at safe positions, see splitPoints. Separators and whitespace
+// around chunks are dropped, whitespace-only chunks are skipped.
+func splitText(text string, safe []bool, maxLength int) ([]textChunk, error) {
+	var chunks []textChunk
+	add := func(start, end int) {
+		for start < end && isSpace(text[start]) && safe[start+1] {
+			start++
+		}
+		for end > start && isSpace(text[end-1]) && safe[end-1] {
+			end--
+		}
+		if start < end {
+			chunks = append(chunks, textChunk{start: start, end: end})
+		}
+	}
+	start := 0
+	for {
+		limit, length := start, 0
+		for limit < len(text) {
+			r, size := utf8.DecodeRuneInString(text[limit:])
+			if length+utf16RuneLen(r) > maxLength {
+				break
+			}
+			length += utf16RuneLen(r)
+			limit += size
+		}
+		if limit == len(text) {
+			add(start, limit)
+			return chunks, nil
+		}
+		end, next := splitPoint(text, safe, start, limit)
+		if end < 0 {
+			return nil, ErrMessageTooLong
+		}
+		add(start, end)
+		start = next
+	}
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\t' || c == '\r'
+}
+
+// splitPoint returns the end of the chunk starting at start and not longer than limit,
+// and the start of the next chunk. End is -1 if the chunk can't be split.
+func splitPoint(text string, safe []bool, start, limit int) (end, next int) {
+	for _, sep := range []byte{'\n', ' '} {
+		for i := limit; i > start; i-- {
+			if text[i] == sep && safe[i] && safe[i+1] {
+				return i, i + 1
+			}
+		}
+	}
+	for i := limit; i > start; i-- {
+		if safe[i] {
+			return i, i
+		}
+	}
+	return -1, -1
+}
+
+// splitPoints reports for every byte index of the text (and the end of the text)
+// if the text can be split before it without breaking formatting of the parse mode,
+// or entities if parse mode is not set.
+func splitPoints(text, parseMode string, entities []*MessageEntity) ([]bool, error) {
+	switch strings.ToLower(parseMode) {
+	case "":
+		return entitySplitPoints(text, entities), nil
+	case "html":
+		return htmlSplitPoints(text), nil
+	case "markdown", "markdownv2":
+		return markdownSplitPoints(text), nil
+	}
+	return nil, fmt.Errorf("unable to split text with unknown parse mode %q", parseMode)
+}
+
+// runeStarts reports if byte indexes of the text are rune boundaries
+func runeStarts(text string) []bool {
+	safe := make([]bool, len(text)+1)
+	for i := range text {
+		safe[i] = true
+	}
+	safe[len(text)] = true
+	return safe
+}
+
+func entitySplitPoints(text string, entities []*MessageEntity) []bool {
+	safe := runeStarts(text)
+	// byte index of every UTF-16 offset, -1 for second halves of surrogate pairs
+	indexes := make([]int, 0, len(text)+1)
+	for i, r := range text {
+		indexes = append(indexes, i)
+		if utf16RuneLen(r) == 2 {
+			indexes = append(indexes, -1)
+		}
+	}
+	indexes = append(indexes, len(text))
+	for _, e := range entities {
+		for offset := e.Offset + 1; offset < e.Offset+e.Length && offset < len(indexes); offset++ {
+			if offset >= 0 && indexes[offset] >= 0 {
+				safe[indexes[offset]] = false
+			}
+		}
+	}
+	return safe
+}
+
+func htmlSplitPoints(text string) []bool {
+	safe := runeStarts(text)
+	depth := 0
+	inTag, inEntity := false, false
+	for i := 0; i < len(text); i++ {
+		if depth > 0 || inTag || inEntity {
+			safe[i] = false
+		}
+		switch c := text[i]; {
+		case inTag:
+			inTag = c != '>'
+		case inEntity:
+			inEntity = c != ';'
+		case c == '<':
+			inTag = true
+			if strings.HasPrefix(text[i:], "</") {
+				if depth > 0 {
+					depth--
+				}
+			} else {
+				depth++
+			}
+		case c == '&':
+			inEntity = true
+		}
+	}
+	return safe
+}
+
+func markdownSplitPoints(text string) []bool {
+	safe := runeStarts(text)
+	open := map[string]bool{}
+	toggle := func(marker string) {
+		if open[marker] {
+			delete(open, marker)
+		} else {
+			open[marker] = true
+		}
+	}
+	// code is the code delimiter inside of code, link is 1 inside of link text and 2 inside of URL
+	code, link := "", 0
+	for i := 0; i < len(text); {
+		if code != "" || link != 0 || len(open) > 0 {
+			safe[i] = false
+		}
+		c := text[i]
+		switch {
+		case c == '\\' && i+1 < len(text):
+			// escaped character is never separated from the backslash
+			safe[i+1] = false
+			_, size := utf8.DecodeRuneInString(text[i+1:])
+			i += 1 + size
+			continue
+		case code != "":
+			if strings.HasPrefix(text[i:], code) {
+				i += len(code)
+				code = ""
+				continue
+			}
+		case strings.HasPrefix(text[i:], "```"):
+			code = "```"
+			i += 3
+			continue
+		case c == '`':
+			code = "`"
+		case link == 1 && c == ']':
+			link = 0
+			if strings.HasPrefix(text[i:], "](") {
+				link = 2
+				i += 2
+				continue
+			}
+		case link == 2:
+			if c == ')' {
+				link = 0
+			}
+		case c == '[':
+			link = 1
+		case strings.HasPrefix(text[i:], "__"), strings.HasPrefix(text[i:], "||"):
+			toggle(text[i : i+2])
+			i += 2
+			continue
+		case c == '*', c == '_', c == '~':
+			toggle(string(c))
+		}
+		i++
+	}
+	return safe
+}
+
+// chunkEntities returns entities of the text covered by the chunk, with offsets relative to the chunk
+func chunkEntities(text string, entities []*MessageEntity, chunk textChunk) []*MessageEntity {
+	start, end := EntityLength(text[:chunk.start]), EntityLength(text[:chunk.end])
+	var chunkEntities []*MessageEntity
+	for _, e := range entities {
+		if e.Offset >= start && e.Offset+e.Length <= end {
+			entity := *e
+			entity.Offset -= start
+			chunkEntities = append(chunkEntities, &entity)
+		}
+	}
+	return chunkEntities
+}
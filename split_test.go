@@ -0,0 +1,155 @@
+package tbot
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitText(t *testing.T) {
+	testCases := []struct {
+		name      string
+		text      string
+		parseMode string
+		entities  []*MessageEntity
+		maxLength int
+		chunks    []string
+	}{
+		{
+			name:      "short",
+			text:      "hello world",
+			maxLength: 20,
+			chunks:    []string{"hello world"},
+		},
+		{
+			name:      "lines",
+			text:      "first line\nsecond line\nthird",
+			maxLength: 24,
+			chunks:    []string{"first line\nsecond line", "third"},
+		},
+		{
+			name:      "words",
+			text:      "one two three four",
+			maxLength: 9,
+			chunks:    []string{"one two", "three", "four"},
+		},
+		{
+			name:      "long word",
+			text:      "abcdefghij",
+			maxLength: 4,
+			chunks:    []string{"abcd", "efgh", "ij"},
+		},
+		{
+			name:      "surrogate pairs",
+			text:      "😀😀😀",
+			maxLength: 4,
+			chunks:    []string{"😀😀", "😀"},
+		},
+		{
+			name:      "empty lines",
+			text:      "first\n\n\n\nsecond",
+			maxLength: 6,
+			chunks:    []string{"first", "second"},
+		},
+		{
+			name:      "entities",
+			text:      "aa bold text",
+			entities:  []*MessageEntity{{Type: "bold", Offset: 3, Length: 9}},
+			maxLength: 10,
+			chunks:    []string{"aa", "bold text"},
+		},
+		{
+			name:      "html",
+			text:      "aa <b>bold text</b> b",
+			parseMode: "HTML",
+			maxLength: 18,
+			chunks:    []string{"aa", "<b>bold text</b> b"},
+		},
+		{
+			name:      "html entity",
+			text:      "a&amp;b",
+			parseMode: "HTML",
+			maxLength: 5,
+			chunks:    []string{"a", "&amp;", "b"},
+		},
+		{
+			name:      "markdown",
+			text:      "aa *bold text* b",
+			parseMode: "MarkdownV2",
+			maxLength: 13,
+			chunks:    []string{"aa", "*bold text* b"},
+		},
+		{
+			name:      "markdown link",
+			text:      "see [the docs](https://example.com/a b)",
+			parseMode: "MarkdownV2",
+			maxLength: 36,
+			chunks:    []string{"see", "[the docs](https://example.com/a b)"},
+		},
+		{
+			name:      "markdown escape",
+			text:      "abc\\*def",
+			parseMode: "MarkdownV2",
+			maxLength: 4,
+			chunks:    []string{"abc", "\\*de", "f"},
+		},
+		{
+			name:      "markdown code",
+			text:      "x ```\ncode line\n``` y",
+			parseMode: "MarkdownV2",
+			maxLength: 19,
+			chunks:    []string{"x ```\ncode line\n```", "y"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			safe, err := splitPoints(tc.text, tc.parseMode, tc.entities)
+			if err != nil {
+				t.Fatalf("unable to find split points: %v", err)
+			}
+			chunks, err := splitText(tc.text, safe, tc.maxLength)
+			if err != nil {
+				t.Fatalf("unable to split text: %v", err)
+			}
+			var texts []string
+			for _, chunk := range chunks {
+				texts = append(texts, tc.text[chunk.start:chunk.end])
+			}
+			if !reflect.DeepEqual(texts, tc.chunks) {
+				t.Fatalf("expected chunks %q, got %q", tc.chunks, texts)
+			}
+		})
+	}
+}
+
+func TestSplitTextLongEntity(t *testing.T) {
+	text := "a <b>very long bold text</b>"
+	safe, _ := splitPoints(text, "HTML", nil)
+	if _, err := splitText(text, safe, 10); err != ErrMessageTooLong {
+		t.Fatalf("expected ErrMessageTooLong, got %v", err)
+	}
+}
+
+func TestChunkEntities(t *testing.T) {
+	text := "😀 first\nsecond bold"
+	entities := []*MessageEntity{
+		{Type: "italic", Offset: 3, Length: 5},
+		{Type: "bold", Offset: 16, Length: 4},
+	}
+	safe, _ := splitPoints(text, "", entities)
+	chunks, err := splitText(text, safe, 12)
+	if err != nil {
+		t.Fatalf("unable to split text: %v", err)
+	}
+	if len(chunks) != 2 {
+		t.Fatalf("expected 2 chunks, got %d", len(chunks))
+	}
+	first := chunkEntities(text, entities, chunks[0])
+	second := chunkEntities(text, entities, chunks[1])
+	if len(first) != 1 || first[0].Type != "italic" || first[0].Offset != 3 ||
+		len(second) != 1 || second[0].Type != "bold" || second[0].Offset != 7 {
+		t.Fatalf("unexpected entities: %+v, %+v", first, second)
+	}
+	if entities[1].Offset != 16 {
+		t.Fatalf("original entities should not be changed")
+	}
+}
@@ -0,0 +1,153 @@
+package tbot
+
+import "io"
+
+/*
+TelegramClient is implemented by Client. Store TelegramClient instead of *Client
+in the application to replace it with a mock in tests. Mock can embed
+TelegramClient and implement only methods used by the code under test:
+
+	type mockClient struct {
+		tbot.TelegramClient
+		sent []string
+	}
+
+	func (m *mockClient) SendMessage(chatID tbot.SendChatID, text string, opts ...tbot.SendOption) (*tbot.Message, error) {
+		m.sent = append(m.sent, text)
+		return &tbot.Message{Text: text}, nil
+	}
+*/
+type TelegramClient interface {
+	GetMe() (*User, error)
+	GetWebhookInfo() (*WebhookInfo, error)
+	DeleteWebhook(dropPending bool) error
+	LogOut() error
+	Close() error
+	SendMessage(chatID SendChatID, text string, opts ...SendOption) (*Message, error)
+	SendLongMessage(chatID SendChatID, text string, opts ...SendOption) ([]*Message, error)
+	ForwardMessage(chatID, fromChatID SendChatID, messageID int, opts ...SendOption) (*Message, error)
+	CopyMessage(chatID, fromChatID SendChatID, messageID int, opts ...SendOption) (int, error)
+	ForwardMessages(chatID, fromChatID SendChatID, messageIDs []int, opts ...SendOption) ([]MessageID, error)
+	CopyMessages(chatID, fromChatID SendChatID, messageIDs []int, opts ...SendOption) ([]MessageID, error)
+	SendAudio(chatID SendChatID, audio interface{}, opts ...SendOption) (*Message, error)
+	SendAudioFile(chatID SendChatID, filename string, opts ...SendOption) (*Message, error)
+	SendPhoto(chatID SendChatID, photo interface{}, opts ...SendOption) (*Message, error)
+	SendPhotoFile(chatID SendChatID, filename string, opts ...SendOption) (*Message, error)
+	SendDocument(chatID SendChatID, document interface{}, opts ...SendOption) (*Message, error)
+	SendDocumentFile(chatID SendChatID, filename string, opts ...SendOption) (*Message, error)
+	SendVideo(chatID SendChatID, video interface{}, opts ...SendOption) (*Message, error)
+	SendVideoFile(chatID SendChatID, filename string, opts ...SendOption) (*Message, error)
+	SendAnimation(chatID SendChatID, animation interface{}, opts ...SendOption) (*Message, error)
+	SendAnimationFile(chatID SendChatID, filename string, opts ...SendOption) (*Message, error)
+	SendVoice(chatID SendChatID, voice interface{}, opts ...SendOption) (*Message, error)
+	SendVoiceFile(chatID SendChatID, filename string, opts ...SendOption) (*Message, error)
+	SendVideoNote(chatID SendChatID, videoNote interface{}, opts ...SendOption) (*Message, error)
+	SendVideoNoteFile(chatID SendChatID, filename string, opts ...SendOption) (*Message, error)
+	SendMediaGroup(chatID SendChatID, media []InputMedia, opts ...SendOption) ([]*Message, error)
+	SendLocation(chatID SendChatID, latitude, longitude float64, opts ...SendOption) (*Message, error)
+	EditMessageLiveLocation(chatID SendChatID, messageID int, latitude, longitude float64, opts ...SendOption) (*Message, error)
+	EditInlineMessageLiveLocation(inlineMessageID string, latitude, longitude float64, opts ...SendOption) error
+	StopMessageLiveLocation(chatID SendChatID, messageID int, opts ...SendOption) (*Message, error)
+	StopInlineMessageLiveLocation(inlineMessageID string, opts ...SendOption) error
+	SendVenue(chatID SendChatID, latitude, longitude float64, title, address string, opts ...SendOption) (*Message, error)
+	SendContact(chatID SendChatID, phoneNumber, firstName string, opts ...SendOption) (*Message, error)
+	SendChatAction(chatID SendChatID, action ChatAction, opts ...SendOption) error
+	GetUserProfilePhotos(userID int64, opts ...SendOption) (*UserProfilePhotos, error)
+	GetAllUserProfilePhotos(userID int64) ([][]PhotoSize, error)
+	GetFile(fileID string) (*File, error)
+	BanChatMember(chatID SendChatID, userID int64, opts ...SendOption) error
+	UnbanChatMember(chatID SendChatID, userID int64) error
+	BanChatSenderChat(chatID SendChatID, senderChatID int64) error
+	UnbanChatSenderChat(chatID SendChatID, senderChatID int64) error
+	RestrictChatMember(chatID SendChatID, userID int64, perm *ChatPermissions, opts ...SendOption) error
+	PromoteChatMember(chatID SendChatID, userID int64, opts ...SendOption) error
+	DemoteChatMember(chatID SendChatID, userID int64) error
+	ExportChatInviteLink(chatID SendChatID) (string, error)
+	CreateChatInviteLink(chatID SendChatID, opts ...SendOption) (*ChatInviteLink, error)
+	EditChatInviteLink(chatID SendChatID, inviteLink string, opts ...SendOption) (*ChatInviteLink, error)
+	RevokeChatInviteLink(chatID SendChatID, inviteLink string) (*ChatInviteLink, error)
+	ApproveChatJoinRequest(chatID SendChatID, userID int64) error
+	DeclineChatJoinRequest(chatID SendChatID, userID int64) error
+	SetChatPhoto(chatID SendChatID, photo *InputFile) error
+	SetChatPhotoFile(chatID SendChatID, filename string) error
+	DeleteChatPhoto(chatID SendChatID) error
+	SetChatTitle(chatID SendChatID, title string) error
+	SetChatDescription(chatID SendChatID, description string) error
+	PinChatMessage(chatID SendChatID, messageID int, opts ...SendOption) error
+	UnpinChatMessage(chatID SendChatID, messageID int) error
+	UnpinAllChatMessages(chatID SendChatID) error
+	CreateForumTopic(chatID SendChatID, name string, opts ...SendOption) (*ForumTopic, error)
+	EditForumTopic(chatID SendChatID, messageThreadID int, opts ...SendOption) error
+	CloseForumTopic(chatID SendChatID, messageThreadID int) error
+	ReopenForumTopic(chatID SendChatID, messageThreadID int) error
+	DeleteForumTopic(chatID SendChatID, messageThreadID int) error
+	UnpinAllForumTopicMessages(chatID SendChatID, messageThreadID int) error
+	GetForumTopicIconStickers() ([]Sticker, error)
+	LeaveChat(chatID SendChatID) error
+	GetChat(chatID SendChatID) (*Chat, error)
+	GetChatAdministrators(chatID SendChatID) ([]*ChatMember, error)
+	GetChatMemberCount(chatID SendChatID) (int, error)
+	GetChatMembersCount(chatID SendChatID) (int, error)
+	GetChatMember(chatID SendChatID, userID int64) (*ChatMember, error)
+	SetChatStickerSet(chatID SendChatID, stickerSetName string) error
+	DeleteChatStickerSet(chatID SendChatID) error
+	AnswerCallbackQuery(callbackQueryID string, opts ...SendOption) error
+	GetMyCommands(opts ...SendOption) (*[]BotCommand, error)
+	SetMyCommands(commands []BotCommand, opts ...SendOption) error
+	DeleteMyCommands(opts ...SendOption) error
+	SyncCommands(set *CommandSet) error
+	SetChatMenuButton(opts ...SendOption) error
+	GetChatMenuButton(opts ...SendOption) (MenuButton, error)
+	SetMyName(name, languageCode string) error
+	GetMyName(languageCode string) (string, error)
+	SetMyDescription(description, languageCode string) error
+	GetMyDescription(languageCode string) (string, error)
+	SetMyShortDescription(shortDescription, languageCode string) error
+	GetMyShortDescription(languageCode string) (string, error)
+	EditMessageText(chatID SendChatID, messageID int, text string, opts ...SendOption) (*Message, error)
+	EditInlineMessageText(inlineMessageID, text string, opts ...SendOption) error
+	EditMessageCaption(chatID SendChatID, messageID int, caption string, opts ...SendOption) (*Message, error)
+	EditInlineMessageCaption(inlineMessageID, caption string, opts ...SendOption) error
+	EditMessageMedia(chatID SendChatID, messageID int, media InputMedia, opts ...SendOption) (*Message, error)
+	EditInlineMessageMedia(inlineMessageID string, media InputMedia, opts ...SendOption) error
+	EditMessageReplyMarkup(chatID SendChatID, messageID int, opts ...SendOption) (*Message, error)
+	EditInlineMessageReplyMarkup(inlineMessageID string, opts ...SendOption) error
+	DeleteMessage(chatID SendChatID, messageID int) error
+	DeleteMessages(chatID SendChatID, messageIDs []int) error
+	DeleteBusinessMessages(businessConnectionID string, messageIDs []int) error
+	SetMessageReaction(chatID SendChatID, messageID int, reactions []ReactionType, opts ...SendOption) error
+	SendSticker(chatID SendChatID, sticker interface{}, opts ...SendOption) (*Message, error)
+	SendStickerFile(chatID SendChatID, filename string, opts ...SendOption) (*Message, error)
+	GetStickerSet(name string) (*StickerSet, error)
+	UploadStickerFile(userID int, filename string) (*File, error)
+	CreateNewStickerSetFile(userID int, name, title, stickerFilename, emojis string, opts ...SendOption) error
+	CreateNewStickerSet(userID int, name, title, fileID, emojis string, opts ...SendOption) error
+	AddStickerToSetFile(userID int, name, filename, emojis string, opts ...SendOption) error
+	AddStickerToSet(userID int, name, fileID, emojis string, opts ...SendOption) error
+	SetStickerPositionInSet(fileID string, pos int) error
+	DeleteStickerFromSet(fileID string) error
+	SetStickerSetThumb(userID int, name, thumb string) error
+	SetStickerSetThumbFile(userID int, name, thumbnailFilename string) error
+	AnswerWebAppQuery(webAppQueryID string, result InlineQueryResult) (*SentWebAppMessage, error)
+	AnswerInlineQuery(inlineQueryID string, results []InlineQueryResult, opts ...SendOption) error
+	SendInvoice(chatID SendChatID, title, description, payload, providerToken, currency string, prices []LabeledPrice, opts ...SendOption) (*Message, error)
+	CreateInvoiceLink(title, description, payload, providerToken, currency string, prices []LabeledPrice, opts ...SendOption) (string, error)
+	AnswerShippingQuery(shippingQueryID string, ok bool, opts ...SendOption) error
+	AnswerPreCheckoutQuery(preCheckoutQueryID string, ok bool, opts ...SendOption) error
+	SetPassportDataErrors(userID int, errors []PassportElementError) error
+	SendGame(chatID SendChatID, gameShortName string, opts ...SendOption) (*Message, error)
+	SetGameScore(userID int64, score int, opts ...SendOption) (*Message, error)
+	GetGameHighScores(userID int64, opts ...SendOption) ([]*GameHighScore, error)
+	SendPoll(chatID SendChatID, question string, options []string, opts ...SendOption) (*Message, error)
+	SendDice(chatID SendChatID, opts ...SendOption) (*Message, error)
+	StopPoll(chatID SendChatID, messageID int, opts ...SendOption) (*Poll, error)
+	SetChatAdministratorCustomTitle(chatID SendChatID, userID int64, customTitle string) error
+	SetChatPermissions(chatID SendChatID, permissions *ChatPermissions, opts ...SendOption) error
+	FileURL(file *File) string
+	DownloadFile(fileID string, w io.Writer) error
+	DownloadFilePath(filePath string, w io.Writer) error
+	Broadcast(chatIDs []ChatID, text string, opts ...SendOption) (*BroadcastResult, error)
+	WithChatAction(chatID SendChatID, action ChatAction, fn func() error, opts ...SendOption) error
+}
+
+var _ TelegramClient = (*Client)(nil)
@@ -0,0 +1,36 @@
+package tbot_test
+
+import (
+	"testing"
+
+	"github.com/yanzay/tbot/v2"
+)
+
+type mockClient struct {
+	tbot.TelegramClient
+	sent []string
+}
+
+func (m *mockClient) SendMessage(chatID tbot.SendChatID, text string, opts ...tbot.SendOption) (*tbot.Message, error) {
+	m.sent = append(m.sent, text)
+	return &tbot.Message{MessageID: len(m.sent), Text: text}, nil
+}
+
+type greeter struct {
+	client tbot.TelegramClient
+}
+
+func (g *greeter) handle(m *tbot.Message) {
+	g.client.SendMessage(tbot.ChatID(m.Chat.ID), "Hello, "+m.From.FirstName, tbot.OptDisableNotification)
+}
+
+func TestMockClient(t *testing.T) {
+	client := &mockClient{}
+	g := &greeter{client: client}
+	g.handle(&tbot.Message{Chat: tbot.Chat{ID: 42}, From: &tbot.User{FirstName: "Alice"}})
+	if len(client.sent) != 1 || client.sent[0] != "Hello, Alice" {
+		t.Fatalf("unexpected messages: %v", client.sent)
+	}
+	// concrete client can be used in place of the mock
+	g.client = tbot.NewClient("TOKEN", nil, "")
+}
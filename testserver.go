@@ -0,0 +1,189 @@
+package tbot
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// APICall is a Bot API request captured by TestServer
+type APICall struct {
+	Method string
+	Params url.Values
+	Files  map[string]string // uploaded file names by field
+}
+
+/*
+TestServer runs bot handlers without network access. Updates are passed
+to handlers with Send, requests to Bot API are captured instead of sending
+them to Telegram and can be inspected with Calls. For example:
+
+	bot := tbot.NewTestServer()
+	bot.HandleMessage("/ping", func(m *tbot.Message) {
+		bot.Client().SendMessage(tbot.ChatID(m.Chat.ID), "pong")
+	})
+	bot.Send(&tbot.Update{Message: &tbot.Message{Text: "/ping", Chat: tbot.Chat{ID: 42}}})
+	calls := bot.Calls("sendMessage")
+	if len(calls) != 1 || calls[0].Params.Get("text") != "pong" {
+		t.Fatalf("unexpected calls: %v", calls)
+	}
+
+Send* and Edit* methods get a message with the same chat and text as the request,
+other methods get True in response. Use Respond to set other responses.
+*/
+type TestServer struct {
+	*Server
+
+	mu            sync.Mutex
+	calls         []APICall
+	responses     map[string]string
+	marks         map[string]int
+	lastMessageID int
+}
+
+// NewTestServer creates TestServer. Bot user is set to "testbot" with ID 1,
+// so Start is not needed to handle commands.
+func NewTestServer(options ...ServerOption) *TestServer {
+	ts := &TestServer{responses: make(map[string]string), marks: make(map[string]int)}
+	httpClient := &http.Client{Transport: testRoundTripper(ts.roundTrip)}
+	options = append(options, WithHTTPClient(httpClient))
+	ts.Server = New("TEST_TOKEN", options...)
+	ts.me = &User{ID: 1, IsBot: true, FirstName: "Test", Username: "testbot"}
+	return ts
+}
+
+// Send passes update to the handlers and returns when they are done
+func (ts *TestServer) Send(update *Update) {
+	ts.processSingleUpdate(update)
+}
+
+// Respond sets JSON result returned for all requests to the Bot API method, e.g.
+//
+//	ts.Respond("getChatMemberCount", "42")
+func (ts *TestServer) Respond(method string, result string) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	ts.responses[method] = result
+}
+
+// Calls returns captured requests to the Bot API method, or all requests if method is empty
+func (ts *TestServer) Calls(method string) []APICall {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	var calls []APICall
+	for _, call := range ts.calls {
+		if method == "" || call.Method == method {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// Mark records that the handler ran, check it with Ran:
+//
+//	bot.HandleCallback(func(cq *tbot.CallbackQuery) {
+//		bot.Mark("callback")
+//	})
+//	bot.Send(&tbot.Update{CallbackQuery: &tbot.CallbackQuery{Data: "yes"}})
+//	if bot.Ran("callback") != 1 {
+//		t.Fatalf("callback handler didn't run")
+//	}
+func (ts *TestServer) Mark(name string) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	ts.marks[name]++
+}
+
+// Ran returns how many times Mark was called with the name
+func (ts *TestServer) Ran(name string) int {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	return ts.marks[name]
+}
+
+// Reset forgets captured requests and marks
+func (ts *TestServer) Reset() {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	ts.calls = nil
+	ts.marks = make(map[string]int)
+}
+
+type testRoundTripper func(*http.Request) (*http.Response, error)
+
+func (f testRoundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func (ts *TestServer) roundTrip(r *http.Request) (*http.Response, error) {
+	call := APICall{Method: path.Base(r.URL.Path), Params: url.Values{}}
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		err := r.ParseMultipartForm(1 << 20)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse request: %v", err)
+		}
+		call.Params = url.Values(r.MultipartForm.Value)
+		call.Files = make(map[string]string)
+		for field, files := range r.MultipartForm.File {
+			call.Files[field] = files[0].Filename
+		}
+		r.MultipartForm.RemoveAll()
+	} else if r.Body != nil {
+		err := r.ParseForm()
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse request: %v", err)
+		}
+		call.Params = r.PostForm
+	}
+
+	ts.mu.Lock()
+	ts.calls = append(ts.calls, call)
+	result, ok := ts.responses[call.Method]
+	if !ok {
+		result = ts.defaultResult(call)
+	}
+	ts.mu.Unlock()
+
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       ioutil.NopCloser(strings.NewReader(`{"ok": true, "result": ` + result + `}`)),
+		Request:    r,
+	}, nil
+}
+
+// defaultResult must be called with ts.mu held
+func (ts *TestServer) defaultResult(call APICall) string {
+	if call.Method == "getMe" {
+		return structString(ts.me)
+	}
+	isSend := strings.HasPrefix(call.Method, "send") && call.Method != "sendChatAction"
+	isEdit := strings.HasPrefix(call.Method, "edit") || call.Method == "stopMessageLiveLocation"
+	if call.Params.Get("inline_message_id") != "" || !(isSend || isEdit) {
+		return "true"
+	}
+	msg := &Message{
+		Date:    time.Now().Unix(),
+		From:    ts.me,
+		Text:    call.Params.Get("text"),
+		Caption: call.Params.Get("caption"),
+	}
+	msg.Chat.ID, _ = strconv.ParseInt(call.Params.Get("chat_id"), 10, 64)
+	if isEdit {
+		msg.MessageID, _ = strconv.Atoi(call.Params.Get("message_id"))
+		msg.EditDate = msg.Date
+	} else {
+		ts.lastMessageID++
+		msg.MessageID = ts.lastMessageID
+	}
+	if call.Method == "sendMediaGroup" {
+		return structString([]*Message{msg})
+	}
+	return structString(msg)
+}
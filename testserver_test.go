@@ -0,0 +1,70 @@
+package tbot_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/yanzay/tbot/v2"
+)
+
+func TestTestServer(t *testing.T) {
+	bot := tbot.NewTestServer()
+	var args []string
+	bot.HandleCommand("/echo", func(m *tbot.Message, a []string) {
+		bot.Mark("echo")
+		args = a
+		msg, err := bot.Client().SendMessage(tbot.ChatID(m.Chat.ID), strings.Join(a, " "))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		_, err = bot.Client().EditMessageText(tbot.ChatID(m.Chat.ID), msg.MessageID, "edited")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		_, err = bot.Client().SendDocument(tbot.ChatID(m.Chat.ID), tbot.InputFileReader("echo.txt", strings.NewReader("echo")))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+	bot.Send(&tbot.Update{Message: &tbot.Message{Text: "/echo@testbot hello world", Chat: tbot.Chat{ID: 42}}})
+	if bot.Ran("echo") != 1 || strings.Join(args, " ") != "hello world" {
+		t.Fatalf("command handler not called: %v", args)
+	}
+	bot.Send(&tbot.Update{Message: &tbot.Message{Text: "/echo@otherbot hello", Chat: tbot.Chat{ID: 42}}})
+	if bot.Ran("echo") != 1 {
+		t.Fatalf("command of other bot should not be handled")
+	}
+	calls := bot.Calls("")
+	if len(calls) != 3 {
+		t.Fatalf("expected 3 calls, got %v", calls)
+	}
+	if calls[0].Method != "sendMessage" || calls[0].Params.Get("chat_id") != "42" || calls[0].Params.Get("text") != "hello world" {
+		t.Fatalf("unexpected sendMessage call: %+v", calls[0])
+	}
+	if calls[1].Method != "editMessageText" || calls[1].Params.Get("message_id") != "1" {
+		t.Fatalf("unexpected editMessageText call: %+v", calls[1])
+	}
+	if calls[2].Method != "sendDocument" || calls[2].Files["document"] != "echo.txt" || calls[2].Params.Get("chat_id") != "42" {
+		t.Fatalf("unexpected sendDocument call: %+v", calls[2])
+	}
+	bot.Reset()
+	if len(bot.Calls("")) != 0 || bot.Ran("echo") != 0 {
+		t.Fatalf("calls and marks should be empty after reset")
+	}
+}
+
+func TestTestServerRespond(t *testing.T) {
+	bot := tbot.NewTestServer()
+	bot.Respond("getChat", `{"id": 42, "type": "group", "title": "Test group"}`)
+	chat, err := bot.Client().GetChat(tbot.ChatID(42))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if chat.Title != "Test group" {
+		t.Fatalf("unexpected chat: %+v", chat)
+	}
+	err = bot.Client().SendChatAction(tbot.ChatID(42), tbot.ActionTyping)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -1,5 +1,10 @@
 package tbot
 
+import (
+	"encoding/json"
+	"fmt"
+)
+
 // User is telegram user
 type User struct {
 	ID                      int    `json:"id"`
@@ -27,6 +32,7 @@ type Chat struct {
 	Type                  string           `json:"type"`
 	Title                 string           `json:"title"`
 	Username              string           `json:"username"`
+	IsForum               bool             `json:"is_forum"`
 	FirstName             string           `json:"first_name"`
 	LastName              string           `json:"last_name"`
 	Photo                 *ChatPhoto       `json:"photo"`
@@ -40,6 +46,7 @@ type Chat struct {
 	StickerSetName        string           `json:"sticker_set_name"`
 	CanSetStickerSet      bool             `json:"can_set_sticker_set"`
 	LinkedChatID          int64            `json:"linked_chat_id"`
+	HasProtectedContent   bool             `json:"has_protected_content"`
 	Location              *ChatLocation    `json:"location"`
 }
 
@@ -54,9 +61,9 @@ type MessageEntity struct {
 	Type     string `json:"type"`
 	Offset   int    `json:"offset"`
 	Length   int    `json:"length"`
-	URL      string `json:"url"`
-	User     *User  `json:"user"`
-	Language string `json:"language"`
+	URL      string `json:"url,omitempty"`
+	User     *User  `json:"user,omitempty"`
+	Language string `json:"language,omitempty"`
 }
 
 // Audio represents an audio file to be treated as music by the Telegram clients
@@ -106,6 +113,9 @@ type Game struct {
 type Animation struct {
 	FileID       string     `json:"file_id"`
 	FileUniqueID string     `json:"file_unique_id"`
+	Width        int        `json:"width"`
+	Height       int        `json:"height"`
+	Duration     int        `json:"duration"`
 	Thumb        *PhotoSize `json:"thumb"`
 	FileName     string     `json:"file_name"`
 	MimeType     string     `json:"mime_type"`
@@ -119,6 +129,7 @@ type Sticker struct {
 	Width        int           `json:"width"`
 	Height       int           `json:"height"`
 	IsAnimated   bool          `json:"is_animated"`
+	IsVideo      bool          `json:"is_video"`
 	Thumb        *PhotoSize    `json:"thumb"`
 	Emoji        string        `json:"emoji"`
 	MaskPosition *MaskPosition `json:"mask_position"`
@@ -172,20 +183,28 @@ type Contact struct {
 	FirstName   string `json:"first_name"`
 	LastName    string `json:"last_name"`
 	UserID      int    `json:"user_id"`
+	VCard       string `json:"vcard"`
 }
 
 // Location represents a point on the map
 type Location struct {
-	Longitude float64 `json:"longitude"`
-	Latitude  float64 `json:"latitude"`
+	Longitude            float64 `json:"longitude"`
+	Latitude             float64 `json:"latitude"`
+	HorizontalAccuracy   float64 `json:"horizontal_accuracy"`
+	LivePeriod           int     `json:"live_period"`
+	Heading              int     `json:"heading"`
+	ProximityAlertRadius int     `json:"proximity_alert_radius"`
 }
 
 // Venue represents a venue
 type Venue struct {
-	Location     Location `json:"location"`
-	Title        string   `json:"title"`
-	Address      string   `json:"address"`
-	FoursquareID string   `json:"foursquare_id"`
+	Location        Location `json:"location"`
+	Title           string   `json:"title"`
+	Address         string   `json:"address"`
+	FoursquareID    string   `json:"foursquare_id"`
+	FoursquareType  string   `json:"foursquare_type"`
+	GooglePlaceID   string   `json:"google_place_id"`
+	GooglePlaceType string   `json:"google_place_type"`
 }
 
 // Invoice contains basic information about an invoice
@@ -229,7 +248,11 @@ type ShippingAddress struct {
 // Message represents a message
 type Message struct {
 	MessageID             int                   `json:"message_id"`
+	MessageThreadID       int                   `json:"message_thread_id"` // topic of the forum message, see IsTopicMessage
+	IsTopicMessage        bool                  `json:"is_topic_message"`
 	From                  *User                 `json:"from"`
+	SenderChat            *Chat                 `json:"sender_chat"` // sender of messages sent on behalf of a chat
+	BusinessConnectionID  string                `json:"business_connection_id"`
 	Date                  int64                 `json:"date"`
 	Chat                  Chat                  `json:"chat"`
 	ForwardFrom           *User                 `json:"forward_from"`
@@ -245,6 +268,7 @@ type Message struct {
 	Text                  string                `json:"text"`
 	Entities              []*MessageEntity      `json:"entities"`
 	CaptionEntities       []*MessageEntity      `json:"caption_entities"`
+	Animation             *Animation            `json:"animation"`
 	Audio                 *Audio                `json:"audio"`
 	Document              *Document             `json:"document"`
 	Game                  *Game                 `json:"game"`
@@ -274,7 +298,11 @@ type Message struct {
 	SuccessfulPayment     *SuccessfulPayment    `json:"successful_payment"`
 	ConnectedWebsite      string                `json:"connected_website"`
 	PassportData          *PassportData         `json:"passport_data"`
+	WebAppData            *WebAppData           `json:"web_app_data"`
 	ReplyMarkup           *InlineKeyboardMarkup `json:"reply_markup"`
+
+	// client is set by Server for incoming messages, see Reply
+	client *Client
 }
 
 // InlineQuery represents an incoming inline query
@@ -343,6 +371,69 @@ type Update struct {
 	PreCheckoutQuery   *PreCheckoutQuery   `json:"pre_checkout_query"`
 	Poll               *Poll               `json:"poll"`
 	PollAnswer         *PollAnswer         `json:"poll_answer"`
+
+	MessageReaction      *MessageReactionUpdated      `json:"message_reaction"`
+	MessageReactionCount *MessageReactionCountUpdated `json:"message_reaction_count"`
+
+	MyChatMember    *ChatMemberUpdated `json:"my_chat_member"`
+	ChatJoinRequest *ChatJoinRequest   `json:"chat_join_request"`
+
+	BusinessConnection    *BusinessConnection `json:"business_connection"`
+	BusinessMessage       *Message            `json:"business_message"`
+	EditedBusinessMessage *Message            `json:"edited_business_message"`
+}
+
+// BusinessConnection describes the connection of the bot with a business account.
+// Bot receives it when connected to the account or when the connection is changed.
+type BusinessConnection struct {
+	ID         string `json:"id"` // use with OptBusinessConnectionID to act on behalf of the account
+	User       User   `json:"user"`
+	UserChatID int64  `json:"user_chat_id"`
+	Date       int64  `json:"date"`
+	CanReply   bool   `json:"can_reply"`
+	IsEnabled  bool   `json:"is_enabled"`
+}
+
+// ChatJoinRequest represents a request to join the chat, see Client.ApproveChatJoinRequest
+type ChatJoinRequest struct {
+	Chat       Chat            `json:"chat"`
+	From       User            `json:"from"`
+	UserChatID int64           `json:"user_chat_id"` // private chat with the user to contact them before approval
+	Date       int64           `json:"date"`
+	Bio        string          `json:"bio"`
+	InviteLink *ChatInviteLink `json:"invite_link"`
+}
+
+// ChatMemberUpdated represents changes in the status of a chat member
+type ChatMemberUpdated struct {
+	Chat          Chat       `json:"chat"`
+	From          User       `json:"from"`
+	Date          int64      `json:"date"`
+	OldChatMember ChatMember `json:"old_chat_member"`
+	NewChatMember ChatMember `json:"new_chat_member"`
+}
+
+// Left reports if the member is not in the chat anymore, see ChatMember.IsInChat
+func (u *ChatMemberUpdated) Left() bool {
+	return !u.NewChatMember.IsInChat()
+}
+
+// WebAppData contains data sent by a Web App opened with KeyboardButton.WebApp
+type WebAppData struct {
+	Data       string `json:"data"`        // data sent by Telegram.WebApp.sendData, can be arbitrary
+	ButtonText string `json:"button_text"` // text of the button the Web App was opened from
+}
+
+// DecodeWebAppData decodes JSON data sent by a Web App into v.
+// Returns error if the message has no web_app_data.
+func (m *Message) DecodeWebAppData(v interface{}) error {
+	if m.WebAppData == nil {
+		return fmt.Errorf("message has no web app data")
+	}
+	if err := json.Unmarshal([]byte(m.WebAppData.Data), v); err != nil {
+		return fmt.Errorf("unable to decode web app data: %v", err)
+	}
+	return nil
 }
 
 // PassportData contains information about Telegram Passport data shared with the bot by the user
@@ -380,15 +471,19 @@ type EncryptedCredentials struct {
 
 // Poll represents native telegram poll
 type Poll struct {
-	ID                    string       `json:"id"`
-	Question              string       `json:"question"`
-	Options               []PollOption `json:"options"`
-	TotalVoterCount       int          `json:"total_voter_count"`
-	IsClosed              bool         `json:"is_closed"`
-	IsAnonymous           bool         `json:"is_anonymous"`
-	Type                  string       `json:"type"`
-	AllowsMultipleAnswers bool         `json:"allows_multiple_answers"`
-	CorrectOptionID       int          `json:"correct_option_id"`
+	ID                    string           `json:"id"`
+	Question              string           `json:"question"`
+	Options               []PollOption     `json:"options"`
+	TotalVoterCount       int              `json:"total_voter_count"`
+	IsClosed              bool             `json:"is_closed"`
+	IsAnonymous           bool             `json:"is_anonymous"`
+	Type                  string           `json:"type"`
+	AllowsMultipleAnswers bool             `json:"allows_multiple_answers"`
+	CorrectOptionID       int              `json:"correct_option_id"`
+	Explanation           string           `json:"explanation"`
+	ExplanationEntities   []*MessageEntity `json:"explanation_entities"`
+	OpenPeriod            int              `json:"open_period"`
+	CloseDate             int64            `json:"close_date"`
 }
 
 // Dice represents native telegram dice
@@ -409,3 +504,166 @@ type PollAnswer struct {
 	User      User  `json:"user"`
 	OptionIDs []int `json:"option_ids"`
 }
+
+// ReactionType is a type of message reaction: ReactionTypeEmoji, ReactionTypeCustomEmoji or ReactionTypePaid
+type ReactionType interface {
+	reactionType() string
+}
+
+var (
+	_ ReactionType = ReactionTypeEmoji{}
+	_ ReactionType = ReactionTypeCustomEmoji{}
+	_ ReactionType = ReactionTypePaid{}
+)
+
+// ReactionTypeEmoji is a reaction with a regular emoji, e.g. "👍"
+type ReactionTypeEmoji struct {
+	Emoji string `json:"emoji"`
+}
+
+func (ReactionTypeEmoji) reactionType() string { return "emoji" }
+
+// MarshalJSON adds reaction type to JSON representation
+func (r ReactionTypeEmoji) MarshalJSON() ([]byte, error) {
+	type reaction ReactionTypeEmoji
+	return marshalReaction(r, reaction(r))
+}
+
+// ReactionTypeCustomEmoji is a reaction with a custom emoji
+type ReactionTypeCustomEmoji struct {
+	CustomEmojiID string `json:"custom_emoji_id"`
+}
+
+func (ReactionTypeCustomEmoji) reactionType() string { return "custom_emoji" }
+
+// MarshalJSON adds reaction type to JSON representation
+func (r ReactionTypeCustomEmoji) MarshalJSON() ([]byte, error) {
+	type reaction ReactionTypeCustomEmoji
+	return marshalReaction(r, reaction(r))
+}
+
+// ReactionTypePaid is a paid reaction, it can't be set by bots
+type ReactionTypePaid struct{}
+
+func (ReactionTypePaid) reactionType() string { return "paid" }
+
+// MarshalJSON adds reaction type to JSON representation
+func (r ReactionTypePaid) MarshalJSON() ([]byte, error) {
+	return marshalReaction(r, struct{}{})
+}
+
+func marshalReaction(r ReactionType, fields interface{}) ([]byte, error) {
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+	typeField := fmt.Sprintf(`{"type":%q`, r.reactionType())
+	if string(data) == "{}" {
+		return []byte(typeField + "}"), nil
+	}
+	return append([]byte(typeField+","), data[1:]...), nil
+}
+
+// unmarshalReaction decodes reaction by its type, unknown reaction types are returned as nil
+func unmarshalReaction(data []byte) (ReactionType, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	var typed struct {
+		Type string `json:"type"`
+	}
+	err := json.Unmarshal(data, &typed)
+	if err != nil {
+		return nil, err
+	}
+	switch typed.Type {
+	case "emoji":
+		r := ReactionTypeEmoji{}
+		err = json.Unmarshal(data, &r)
+		return r, err
+	case "custom_emoji":
+		r := ReactionTypeCustomEmoji{}
+		err = json.Unmarshal(data, &r)
+		return r, err
+	case "paid":
+		return ReactionTypePaid{}, nil
+	}
+	return nil, nil
+}
+
+func unmarshalReactions(data []json.RawMessage) ([]ReactionType, error) {
+	reactions := make([]ReactionType, 0, len(data))
+	for _, d := range data {
+		r, err := unmarshalReaction(d)
+		if err != nil {
+			return nil, err
+		}
+		if r != nil {
+			reactions = append(reactions, r)
+		}
+	}
+	return reactions, nil
+}
+
+// MessageReactionUpdated represents a change of a reaction on a message performed by a user.
+// Reactions of unknown types are skipped.
+type MessageReactionUpdated struct {
+	Chat        Chat           `json:"chat"`
+	MessageID   int            `json:"message_id"`
+	User        *User          `json:"user"`
+	ActorChat   *Chat          `json:"actor_chat"`
+	Date        int64          `json:"date"`
+	OldReaction []ReactionType `json:"old_reaction"`
+	NewReaction []ReactionType `json:"new_reaction"`
+}
+
+// UnmarshalJSON decodes reactions by their types
+func (m *MessageReactionUpdated) UnmarshalJSON(data []byte) error {
+	type update MessageReactionUpdated
+	var raw struct {
+		*update
+		OldReaction []json.RawMessage `json:"old_reaction"`
+		NewReaction []json.RawMessage `json:"new_reaction"`
+	}
+	raw.update = (*update)(m)
+	err := json.Unmarshal(data, &raw)
+	if err != nil {
+		return err
+	}
+	m.OldReaction, err = unmarshalReactions(raw.OldReaction)
+	if err != nil {
+		return err
+	}
+	m.NewReaction, err = unmarshalReactions(raw.NewReaction)
+	return err
+}
+
+// ReactionCount represents a reaction added to a message along with the number of times it was added.
+// Type is nil for reactions of unknown types.
+type ReactionCount struct {
+	Type       ReactionType `json:"type"`
+	TotalCount int          `json:"total_count"`
+}
+
+// UnmarshalJSON decodes reaction by its type
+func (r *ReactionCount) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Type       json.RawMessage `json:"type"`
+		TotalCount int             `json:"total_count"`
+	}
+	err := json.Unmarshal(data, &raw)
+	if err != nil {
+		return err
+	}
+	r.TotalCount = raw.TotalCount
+	r.Type, err = unmarshalReaction(raw.Type)
+	return err
+}
+
+// MessageReactionCountUpdated represents reaction changes on a message with anonymous reactions
+type MessageReactionCountUpdated struct {
+	Chat      Chat             `json:"chat"`
+	MessageID int              `json:"message_id"`
+	Date      int64            `json:"date"`
+	Reactions []*ReactionCount `json:"reactions"`
+}
@@ -0,0 +1,79 @@
+package tbot
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+/*
+WithStallTimeout calls onStall if no updates were processed for d,
+e.g. to alert about silently stalled long polling or misconfigured webhook.
+onStall is called once per stall, the timer is reset by the next update.
+It works in both long polling and webhook modes, the first period starts with Server.Start.
+*/
+func WithStallTimeout(d time.Duration, onStall func()) ServerOption {
+	return func(s *Server) {
+		s.watchdog = newWatchdog(d, onStall)
+	}
+}
+
+// watchdog tracks time of the last processed update
+type watchdog struct {
+	timeout time.Duration
+	onStall func()
+	now     func() time.Time
+
+	mu      sync.Mutex
+	last    time.Time
+	stalled bool
+}
+
+func newWatchdog(timeout time.Duration, onStall func()) *watchdog {
+	return &watchdog{
+		timeout: timeout,
+		onStall: onStall,
+		now:     time.Now,
+	}
+}
+
+// touch resets the timer
+func (w *watchdog) touch() {
+	w.mu.Lock()
+	w.last = w.now()
+	w.stalled = false
+	w.mu.Unlock()
+}
+
+// check calls onStall if timeout passed since the last update
+func (w *watchdog) check() {
+	w.mu.Lock()
+	stalled := !w.stalled && w.now().Sub(w.last) >= w.timeout
+	if stalled {
+		w.stalled = true
+	}
+	w.mu.Unlock()
+	if stalled {
+		w.onStall()
+	}
+}
+
+// run checks the timer until ctx is done
+func (w *watchdog) run(ctx context.Context) {
+	w.touch()
+	// check often enough to report stall with 10% precision
+	interval := w.timeout / 10
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.check()
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package tbot
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWatchdogStall(t *testing.T) {
+	var stalls int
+	now := time.Now()
+	s := New("TOKEN", WithStallTimeout(5*time.Minute, func() { stalls++ }))
+	s.watchdog.now = func() time.Time { return now }
+	s.watchdog.touch()
+
+	now = now.Add(4 * time.Minute)
+	s.watchdog.check()
+	if stalls != 0 {
+		t.Fatalf("stall reported before timeout")
+	}
+	now = now.Add(time.Minute)
+	s.watchdog.check()
+	now = now.Add(time.Minute)
+	s.watchdog.check()
+	if stalls != 1 {
+		t.Fatalf("expected stall reported once, got %d", stalls)
+	}
+
+	// update resets the timer
+	s.processSingleUpdate(&Update{Message: &Message{Text: "hi"}})
+	now = now.Add(4 * time.Minute)
+	s.watchdog.check()
+	if stalls != 1 {
+		t.Fatalf("stall reported after update")
+	}
+	now = now.Add(time.Minute)
+	s.watchdog.check()
+	if stalls != 2 {
+		t.Fatalf("expected the second stall, got %d", stalls)
+	}
+}
+
+func TestWatchdogRun(t *testing.T) {
+	httpClient, _ := testTransport(t)
+	stalled := make(chan struct{}, 1)
+	s := New("TOKEN", WithHTTPClient(httpClient), WithStallTimeout(50*time.Millisecond, func() { stalled <- struct{}{} }))
+	done := make(chan error)
+	go func() {
+		done <- s.Start()
+	}()
+	select {
+	case <-stalled:
+	case <-time.After(time.Second):
+		t.Fatalf("stall is not reported")
+	}
+	s.Stop()
+	<-done
+}